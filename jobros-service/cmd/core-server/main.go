@@ -1,16 +1,23 @@
 package main
 
 import (
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apiserver"
 	"github.com/maxime-joseph/Jobros/jobros-service/internal/app"
 	"log"
 )
 
 func main() {
-	_, err := app.NewAppContext()
+	appCtx, err := app.NewAppContext()
 	if err != nil {
 		log.Fatalf("Failed to initialize application context: %v", err)
 	}
-
-	// Now you can use appCtx.MongoClient, appCtx.Database, etc.
 	log.Println("Application context initialized successfully")
+
+	apiServer, err := apiserver.New(appCtx)
+	if err != nil {
+		log.Fatalf("Failed to initialize API server: %v", err)
+	}
+	if err := apiServer.Run(); err != nil {
+		log.Fatalf("API server stopped: %v", err)
+	}
 }
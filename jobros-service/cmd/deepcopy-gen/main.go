@@ -0,0 +1,50 @@
+// Command deepcopy-gen generates the deepcopy methods of the annotated types of
+// a package into zz_generated.deepcopy.go. It is meant to run from go:generate:
+//
+//	//go:generate go run github.com/maxime-joseph/Jobros/jobros-service/cmd/deepcopy-gen
+//
+// With -verify, it fails instead if the generated file is not up to date.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/codegen/deepcopy"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the package to generate the deepcopy methods of")
+	verify := flag.Bool("verify", false, "fail if the generated file is not up to date instead of writing it")
+	flag.Parse()
+
+	src, err := deepcopy.Generate(*dir)
+	if err != nil {
+		log.Fatalf("deepcopy-gen: %v", err)
+	}
+	output := filepath.Join(*dir, deepcopy.OutputFile)
+
+	if *verify {
+		current, err := os.ReadFile(output)
+		if err != nil && !os.IsNotExist(err) {
+			log.Fatalf("deepcopy-gen: %v", err)
+		}
+		if !bytes.Equal(current, src) {
+			log.Fatalf("deepcopy-gen: %s is out of date, run go generate", output)
+		}
+		return
+	}
+
+	if src == nil {
+		if err := os.Remove(output); err != nil && !os.IsNotExist(err) {
+			log.Fatalf("deepcopy-gen: %v", err)
+		}
+		return
+	}
+	if err := os.WriteFile(output, src, 0o644); err != nil {
+		log.Fatalf("deepcopy-gen: %v", err)
+	}
+}
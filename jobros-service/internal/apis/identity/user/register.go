@@ -0,0 +1,20 @@
+package user
+
+import (
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+// SchemeGroupVersion is the internal version of the user types.
+var SchemeGroupVersion = runtime.GroupVersion{Group: apis.APIGroup, Version: runtime.APIVersionInternal}
+
+var (
+	// SchemeBuilder registers the internal user types.
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+	// AddToScheme adds the internal user types to a scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+func addKnownTypes(scheme *runtime.Scheme) error {
+	return scheme.AddKnownTypes(&User{})
+}
@@ -0,0 +1,62 @@
+// Package user holds the internal representation of the user types, the hub
+// every served version of the API converts to and from. Internal types are
+// never encoded: handlers decode a versioned object, convert it, and convert
+// the result back to the version of the request.
+package user
+
+//go:generate go run github.com/maxime-joseph/Jobros/jobros-service/cmd/deepcopy-gen
+
+import (
+	"time"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+// Status is a stage of the account lifecycle.
+type Status string
+
+// StatusChange records a transition of the account status.
+type StatusChange struct {
+	From   Status
+	To     Status
+	Reason string
+	Actor  string
+	At     time.Time
+}
+
+// Verification tells which information of the user has been verified.
+type Verification struct {
+	Email        bool
+	Phone        bool
+	Identity     bool
+	Professional bool
+}
+
+// Security holds the login state of the user.
+type Security struct {
+	MFAEnabled      bool
+	LoginAttempts   int
+	LastLogin       time.Time
+	LastUpdated     time.Time
+	PasswordChanged time.Time
+}
+
+// User is the internal representation of a user.
+//
+// +jobros:deepcopy-gen:object=true
+type User struct {
+	runtime.TypeMeta
+	runtime.ObjectMeta
+	Email         string
+	Phone         string
+	Roles         []string
+	Status        Status
+	StatusHistory []StatusChange
+	UpdatedAt     time.Time
+	Verification  Verification
+	Security      Security
+}
+
+func (u *User) GetGroupVersionKind() runtime.GroupVersionKind {
+	return SchemeGroupVersion.WithKind("User")
+}
@@ -0,0 +1,134 @@
+// Package install builds the Scheme of the whole API: the internal types and
+// the types of every served version, with the conversions between them. It
+// also builds the admission hooks of every package, and the discovery and
+// OpenAPI documents of the served resources.
+package install
+
+import (
+	"net/http"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis"
+	internaluser "github.com/maxime-joseph/Jobros/jobros-service/internal/apis/identity/user"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/invite"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/loginhistory"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/oauth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/password"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/user"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/discovery"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/openapi"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/registry"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+// StorageVersion is the version resources are stored in, whatever the version
+// they are served in.
+var StorageVersion = runtime.GroupVersion{Group: apis.APIGroup, Version: apis.APIVersion}
+
+// ServedVersions are the versions of the API, preferred first.
+var ServedVersions = []runtime.GroupVersion{
+	{Group: apis.APIGroup, Version: apis.APIVersion},
+}
+
+var schemeBuilder = runtime.NewSchemeBuilder(
+	internaluser.AddToScheme,
+	user.AddToScheme,
+)
+
+// NewScheme returns a Scheme with the internal types and every served version
+// registered.
+func NewScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := schemeBuilder.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+var admissionBuilder = registry.NewAdmissionBuilder(
+	user.AddToAdmission,
+)
+
+// NewAdmission returns an Admission with the hooks of every package.
+func NewAdmission() (*registry.Admission, error) {
+	admission := registry.NewAdmission()
+	if err := admissionBuilder.AddToAdmission(admission); err != nil {
+		return nil, err
+	}
+	return admission, nil
+}
+
+// resourceOptions return the registry options of every served resource.
+var resourceOptions = []func() registry.Options{
+	user.RESTOptions,
+}
+
+// APIResources returns the served resources.
+func APIResources() []discovery.APIResource {
+	resources := make([]discovery.APIResource, 0, len(resourceOptions))
+	for _, opts := range resourceOptions {
+		resources = append(resources, opts().APIResource())
+	}
+	return resources
+}
+
+// NewDiscovery returns the discovery of the served versions of scheme and of
+// the served resources.
+func NewDiscovery(scheme *runtime.Scheme) (*discovery.Handler, error) {
+	handler := discovery.NewHandler(scheme, apis.AppVersion, ServedVersions)
+	for _, resource := range APIResources() {
+		if err := handler.AddResource(resource); err != nil {
+			return nil, err
+		}
+	}
+	return handler, nil
+}
+
+// switchRoleRoute describes the role switch route of the auth package, which
+// the openapi package depends on.
+var switchRoleRoute = openapi.Route{
+	Method:      http.MethodPost,
+	Path:        "/auth/role",
+	OperationID: "switchRole",
+	Summary:     "Issue new tokens with another role of the user active",
+	Tag:         "auth",
+	Auth:        true,
+	Request: struct {
+		Role string `json:"role" binding:"required"`
+	}{},
+	Response: struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+		Role         string `json:"role"`
+	}{},
+	Description: "The new token pair",
+	Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusServiceUnavailable},
+}
+
+// routes return the descriptions of the routes served besides the registry.
+var routes = []func() []openapi.Route{
+	user.OpenAPIRoutes,
+	password.OpenAPIRoutes,
+	func() []openapi.Route { return []openapi.Route{switchRoleRoute} },
+	loginhistory.OpenAPIRoutes,
+	oauth.OpenAPIRoutes,
+	invite.OpenAPIRoutes,
+}
+
+// NewOpenAPI returns the OpenAPI document of the served resources and of the
+// other routes of the API.
+func NewOpenAPI(scheme *runtime.Scheme) (*openapi.Document, error) {
+	builder := openapi.NewBuilder(scheme, "Jobros API", apis.AppVersion)
+	for _, resource := range APIResources() {
+		if err := builder.AddResource(resource); err != nil {
+			return nil, err
+		}
+	}
+	for _, described := range routes {
+		for _, route := range described() {
+			if err := builder.AddRoute(route); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return builder.Document(), nil
+}
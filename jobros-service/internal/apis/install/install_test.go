@@ -0,0 +1,68 @@
+package install
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	internaluser "github.com/maxime-joseph/Jobros/jobros-service/internal/apis/identity/user"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewScheme(t *testing.T) {
+	scheme, err := NewScheme()
+	require.NoError(t, err)
+
+	assert.True(t, scheme.Recognizes(internaluser.SchemeGroupVersion.WithKind("User")))
+	for _, gv := range ServedVersions {
+		assert.True(t, scheme.Recognizes(gv.WithKind("User")), gv.String())
+
+		// Every served version converts to and from the storage version.
+		u := &user.User{Email: "jane@example.com"}
+		served, err := scheme.ConvertToVersion(u, gv)
+		require.NoError(t, err)
+		stored, err := scheme.ConvertToVersion(served, StorageVersion)
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", stored.(*user.User).Email)
+	}
+	assert.Contains(t, ServedVersions, StorageVersion)
+}
+
+func TestNewAdmission(t *testing.T) {
+	admission, err := NewAdmission()
+	require.NoError(t, err)
+	assert.NotNil(t, admission)
+}
+
+func TestNewDiscovery(t *testing.T) {
+	scheme, err := NewScheme()
+	require.NoError(t, err)
+	_, err = NewDiscovery(scheme)
+	assert.NoError(t, err)
+}
+
+var update = flag.Bool("update", false, "update the committed OpenAPI document")
+
+// TestNewOpenAPI fails when the committed document drifts from the types and
+// routes; run go test -update to regenerate it.
+func TestNewOpenAPI(t *testing.T) {
+	scheme, err := NewScheme()
+	require.NoError(t, err)
+	doc, err := NewOpenAPI(scheme)
+	require.NoError(t, err)
+	got, err := json.MarshalIndent(doc, "", "  ")
+	require.NoError(t, err)
+	got = append(got, '\n')
+
+	committed := filepath.Join("..", "..", "..", "api", "openapi.json")
+	if *update {
+		require.NoError(t, os.WriteFile(committed, got, 0o644))
+	}
+	want, err := os.ReadFile(committed)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "api/openapi.json is out of date, run go test ./internal/apis/install -update")
+}
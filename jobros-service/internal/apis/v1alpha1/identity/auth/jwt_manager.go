@@ -1,41 +1,219 @@
 package auth
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis"
 	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// TokenType tells access tokens from refresh tokens, in the typ claim, so that
+// neither is accepted in place of the other.
+type TokenType string
+
+const (
+	TokenTypeAccess  TokenType = "access"
+	TokenTypeRefresh TokenType = "refresh"
+)
+
+// ErrWrongTokenType is returned for tokens of another type than expected.
+var ErrWrongTokenType = errors.New("wrong token type")
+
 type JWTClaims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	Type   TokenType `json:"typ"`
+	UserID string    `json:"user_id"`
+	// Role is the active role: the one authorization checks consult.
+	Role string `json:"role"`
+	// Roles are all the roles of the user the token may switch to.
+	Roles  []string `json:"roles,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+	// ClientID is set on tokens issued to a third-party application acting on
+	// behalf of the user.
+	ClientID string `json:"client_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
+const (
+	// DefaultAccessTokenTTL is how long access tokens are valid unless configured otherwise.
+	DefaultAccessTokenTTL = 15 * time.Minute
+	// DefaultRefreshTokenTTL is how long refresh tokens are valid unless configured otherwise.
+	DefaultRefreshTokenTTL = 168 * time.Hour // 7 days
+)
+
 type JWTManager struct {
 	runtime.Object // Embed the Object interface
+	mu             sync.RWMutex
 	secret         []byte
+	previousSecret []byte
+	// previousExpiry is when the tokens signed with previousSecret have all
+	// expired, after which it is dropped.
+	previousExpiry  time.Time
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	issuer          string
+	now             func() time.Time
 }
 
-func NewJWTManager() (*JWTManager, error) {
-	secret, err := getJWTSecret()
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize JWT manager: %w", err)
+// JWTOption configures a JWTManager.
+type JWTOption func(m *JWTManager) error
+
+// WithSecret signs and verifies tokens with secret.
+func WithSecret(secret []byte) JWTOption {
+	return func(m *JWTManager) error {
+		m.secret = secret
+		return nil
+	}
+}
+
+// WithSecretFile reads the signing secret from a key file, see SecretFromFile.
+func WithSecretFile(path string) JWTOption {
+	return func(m *JWTManager) error {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read JWT secret file: %w", err)
+		}
+		m.secret = SecretFromFile(content)
+		return nil
+	}
+}
+
+// SecretFromFile returns the signing secret held by the content of a key file,
+// ignoring surrounding whitespace. Secrets rotated in the key file must be read
+// with it too, or the secret would change with the whitespace.
+func SecretFromFile(content []byte) []byte {
+	return bytes.TrimSpace(content)
+}
+
+// WithTokenTTLs sets the lifetimes of access and refresh tokens. Zero values
+// keep the defaults.
+func WithTokenTTLs(accessTokenTTL time.Duration, refreshTokenTTL time.Duration) JWTOption {
+	return func(m *JWTManager) error {
+		if accessTokenTTL < 0 || refreshTokenTTL < 0 {
+			return fmt.Errorf("token lifetimes must not be negative")
+		}
+		if accessTokenTTL > 0 {
+			m.accessTokenTTL = accessTokenTTL
+		}
+		if refreshTokenTTL > 0 {
+			m.refreshTokenTTL = refreshTokenTTL
+		}
+		return nil
+	}
+}
+
+// WithIssuer sets the iss claim of issued tokens and rejects tokens from
+// other issuers.
+func WithIssuer(issuer string) JWTOption {
+	return func(m *JWTManager) error {
+		m.issuer = issuer
+		return nil
+	}
+}
+
+// WithClock makes the manager read the time from now, for tests.
+func WithClock(now func() time.Time) JWTOption {
+	return func(m *JWTManager) error {
+		m.now = now
+		return nil
 	}
-	return &JWTManager{secret: []byte(secret)}, nil
 }
 
-func getJWTSecret() (string, error) {
-	secret := os.Getenv("JWT_SECRET_KEY")
-	if secret == "" {
-		return "", fmt.Errorf("JWT_SECRET_KEY environment variable is not set")
+// NewJWTManager creates a JWTManager. A secret must be given with WithSecret
+// or WithSecretFile.
+func NewJWTManager(opts ...JWTOption) (*JWTManager, error) {
+	m := &JWTManager{
+		accessTokenTTL:  DefaultAccessTokenTTL,
+		refreshTokenTTL: DefaultRefreshTokenTTL,
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		if err := opt(m); err != nil {
+			return nil, fmt.Errorf("failed to initialize JWT manager: %w", err)
+		}
+	}
+	if len(m.secret) == 0 {
+		return nil, fmt.Errorf("failed to initialize JWT manager: no secret configured")
 	}
-	return secret, nil
+	return m, nil
 }
+
+// SetSecret rotates the signing secret. Tokens signed with the secret being
+// replaced stay valid until they expire or the secret is rotated again: the
+// secret is dropped once the longest lived of them, a refresh token, expired.
+func (m *JWTManager) SetSecret(secret []byte) error {
+	if len(secret) == 0 {
+		return fmt.Errorf("JWT secret must not be empty")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if bytes.Equal(secret, m.secret) {
+		return nil
+	}
+	m.previousSecret = m.secret
+	m.previousExpiry = m.now().Add(m.refreshTokenTTL)
+	m.secret = secret
+	return nil
+}
+
+// signingSecret returns the secret new tokens are signed with.
+func (m *JWTManager) signingSecret() []byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.secret
+}
+
+// verificationSecrets returns the secrets tokens may be signed with, current first.
+func (m *JWTManager) verificationSecrets() [][]byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.previousSecret == nil || !m.now().Before(m.previousExpiry) {
+		return [][]byte{m.secret}
+	}
+	return [][]byte{m.secret, m.previousSecret}
+}
+
+// AccessTokenTTL returns how long access tokens are valid.
+func (m *JWTManager) AccessTokenTTL() time.Duration {
+	return m.accessTokenTTL
+}
+
+// RefreshTokenTTL returns how long refresh tokens are valid.
+func (m *JWTManager) RefreshTokenTTL() time.Duration {
+	return m.refreshTokenTTL
+}
+
+// newTokenID returns a random identifier used as the jti claim, so that single
+// tokens can be revoked.
+func newTokenID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(fmt.Sprintf("failed to generate token id: %v", err))
+	}
+	return hex.EncodeToString(id)
+}
+
+// registeredClaims returns the registered claims of a token issued now and
+// valid for ttl.
+func (m *JWTManager) registeredClaims(ttl time.Duration) jwt.RegisteredClaims {
+	now := m.now()
+	return jwt.RegisteredClaims{
+		ID:        newTokenID(),
+		Issuer:    m.issuer,
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+	}
+}
+
 func (m *JWTManager) GetGroupVersionKind() runtime.GroupVersionKind {
 	return runtime.GroupVersionKind{
 		Group:   apis.APIGroup,
@@ -44,91 +222,159 @@ func (m *JWTManager) GetGroupVersionKind() runtime.GroupVersionKind {
 	}
 }
 
-func (m *JWTManager) DeepCopy() runtime.Object {
-	// Since JWTManager only contains a byte slice, a simple copy is sufficient
+// DeepCopy copies the manager. It is written by hand rather than generated
+// because the mutex guarding the secrets must not be copied.
+func (m *JWTManager) DeepCopy() *JWTManager {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	secretCopy := make([]byte, len(m.secret))
 	copy(secretCopy, m.secret)
+	var previousCopy []byte
+	if m.previousSecret != nil {
+		previousCopy = make([]byte, len(m.previousSecret))
+		copy(previousCopy, m.previousSecret)
+	}
 
 	return &JWTManager{
-		secret: secretCopy,
+		secret:          secretCopy,
+		previousSecret:  previousCopy,
+		previousExpiry:  m.previousExpiry,
+		accessTokenTTL:  m.accessTokenTTL,
+		refreshTokenTTL: m.refreshTokenTTL,
+		issuer:          m.issuer,
+		now:             m.now,
 	}
 }
 
-func (m *JWTManager) ValidateToken(tokenString string) bool {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return m.secret, nil
-	})
+func (m *JWTManager) DeepCopyObject() runtime.Object {
+	return m.DeepCopy()
+}
+
+// ValidateToken reports whether the token is a valid token of tokenType.
+func (m *JWTManager) ValidateToken(tokenString string, tokenType TokenType) bool {
+	_, err := m.GetTokenClaims(tokenString, tokenType)
+	return err == nil
+}
+
+// validateIdentity checks that the claims name a user acting with one of
+// their roles.
+func validateIdentity(claims *JWTClaims) error {
+	if claims.UserID == "" || claims.Role == "" {
+		return fmt.Errorf("token has no user or role")
+	}
+	if len(claims.Roles) > 0 && !containsRole(claims.Roles, claims.Role) {
+		return fmt.Errorf("%w: %q", ErrRoleNotAssigned, claims.Role)
+	}
+	return nil
+}
 
+// GetTokenClaims verifies the signature of the token, its type, its identity
+// claims, and its time and issuer claims against the clock of the manager,
+// and returns its claims.
+func (m *JWTManager) GetTokenClaims(tokenString string, tokenType TokenType) (*JWTClaims, error) {
+	var claims *JWTClaims
+	var err error
+	for _, secret := range m.verificationSecrets() {
+		claims, err = parseToken(tokenString, secret)
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			break
+		}
+	}
 	if err != nil {
-		return false
+		return nil, err
 	}
 
-	claims, ok := token.Claims.(*JWTClaims)
-	if !ok || !token.Valid {
-		return false
+	if claims.Type != tokenType {
+		return nil, fmt.Errorf("%w: expected %s token, got %q", ErrWrongTokenType, tokenType, claims.Type)
+	}
+	if err := validateIdentity(claims); err != nil {
+		return nil, err
+	}
+	if err := m.validateClaims(claims); err != nil {
+		return nil, err
 	}
+	return claims, nil
+}
 
-	if claims.UserID == "" || claims.Role == "" {
-		return false
+func parseToken(tokenString string, secret []byte) (*JWTClaims, error) {
+	claims := &JWTClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return secret, nil
+	}, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return nil, err
 	}
+	return claims, nil
+}
+
+func (m *JWTManager) validateClaims(claims *JWTClaims) error {
+	now := m.now()
 
 	// reject tokens that have expired
-	if claims.ExpiresAt.Time.Before(time.Now()) {
-		return false
+	if claims.ExpiresAt == nil || !now.Before(claims.ExpiresAt.Time) {
+		return fmt.Errorf("token is expired")
 	}
 
 	// reject tokens that claim to be issued in the future:
-	if claims.IssuedAt != nil && claims.IssuedAt.Time.After(time.Now()) {
-		return false
+	if claims.IssuedAt != nil && claims.IssuedAt.Time.After(now) {
+		return fmt.Errorf("token used before issued")
 	}
 
 	// ensure the token isn't being used before its valid time:
-	if claims.NotBefore != nil && time.Now().Before(claims.NotBefore.Time) {
-		return false
+	if claims.NotBefore != nil && now.Before(claims.NotBefore.Time) {
+		return fmt.Errorf("token is not valid yet")
+	}
+
+	if m.issuer != "" && claims.Issuer != m.issuer {
+		return fmt.Errorf("token was issued by %q", claims.Issuer)
 	}
 
-	return true
+	return nil
 }
 
-func (m *JWTManager) GetTokenClaims(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return m.secret, nil
-	})
+func (m *JWTManager) GenerateRefreshToken(userID string, role string, scopes ...string) (string, error) {
+	return m.generateRefreshToken(userID, []string{role}, role, scopes)
+}
+
+func (m *JWTManager) generateRefreshToken(userID string, roles []string, activeRole string, scopes []string) (string, error) {
+	granted, err := ResolveScopes(activeRole, scopes)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		return claims, nil
+	refreshClaims := &JWTClaims{
+		Type:             TokenTypeRefresh,
+		UserID:           userID,
+		Role:             activeRole,
+		Roles:            roles,
+		Scopes:           granted,
+		RegisteredClaims: m.registeredClaims(m.refreshTokenTTL),
 	}
 
-	return nil, fmt.Errorf("invalid token claims")
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString(m.signingSecret())
 }
 
-func (m *JWTManager) GenerateRefreshToken(userID string, role string) (string, error) {
-	refreshClaims := &JWTClaims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(168 * time.Hour)), // 7 days
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
-	}
-
-	return jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString(m.secret)
+func (m *JWTManager) GenerateTokenPair(userID string, role string, scopes ...string) (accessToken string, refreshToken string, err error) {
+	return m.GenerateTokenPairWithRoles(userID, []string{role}, role, scopes...)
 }
 
-func (m *JWTManager) GenerateTokenPair(userID string, role string) (accessToken string, refreshToken string, err error) {
-	accessToken, err = m.GenerateAccessToken(userID, role)
+// GenerateTokenPairWithRoles issues tokens for a user holding several roles,
+// acting with activeRole. Scopes are bounded by the active role.
+func (m *JWTManager) GenerateTokenPairWithRoles(userID string, roles []string, activeRole string, scopes ...string) (accessToken string, refreshToken string, err error) {
+	if !containsRole(roles, activeRole) {
+		return "", "", fmt.Errorf("%w: %q", ErrRoleNotAssigned, activeRole)
+	}
+
+	accessToken, err = m.generateAccessToken(userID, roles, activeRole, "", scopes)
 	if err != nil {
 		return "", "", err
 	}
 
-	refreshToken, err = m.GenerateRefreshToken(userID, role)
+	refreshToken, err = m.generateRefreshToken(userID, roles, activeRole, scopes)
 	if err != nil {
 		return "", "", err
 	}
@@ -136,18 +382,35 @@ func (m *JWTManager) GenerateTokenPair(userID string, role string) (accessToken
 	return accessToken, refreshToken, nil
 }
 
-func (m *JWTManager) GenerateAccessToken(userID string, role string) (string, error) {
+// GenerateAccessToken issues an access token limited to the requested scopes, or
+// to every scope of the role when none are requested.
+func (m *JWTManager) GenerateAccessToken(userID string, role string, scopes ...string) (string, error) {
+	return m.generateAccessToken(userID, []string{role}, role, "", scopes)
+}
+
+// GenerateDelegatedAccessToken issues an access token for the application
+// identified by clientID, acting on behalf of the user with the given scopes.
+func (m *JWTManager) GenerateDelegatedAccessToken(userID string, role string, clientID string, scopes ...string) (string, error) {
+	return m.generateAccessToken(userID, []string{role}, role, clientID, scopes)
+}
+
+func (m *JWTManager) generateAccessToken(userID string, roles []string, activeRole string, clientID string, scopes []string) (string, error) {
+	granted, err := ResolveScopes(activeRole, scopes)
+	if err != nil {
+		return "", err
+	}
+
 	accessClaims := &JWTClaims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+		Type:             TokenTypeAccess,
+		UserID:           userID,
+		Role:             activeRole,
+		Roles:            roles,
+		Scopes:           granted,
+		ClientID:         clientID,
+		RegisteredClaims: m.registeredClaims(m.accessTokenTTL),
 	}
 
-	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims).SignedString(m.secret)
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims).SignedString(m.signingSecret())
 	if err != nil {
 		return "", err
 	}
@@ -1,9 +1,11 @@
 package auth
 
 import (
+	"errors"
 	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis"
 	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -13,15 +15,13 @@ import (
 
 func TestNewJWTManager(t *testing.T) {
 	// Test with missing secret
-	os.Unsetenv("JWT_SECRET_KEY")
 	_, err := NewJWTManager()
 	if err == nil {
-		t.Error("Expected error when JWT_SECRET_KEY is not set")
+		t.Error("Expected error when no secret is configured")
 	}
 
 	// Test with valid secret
-	os.Setenv("JWT_SECRET_KEY", "test-secret")
-	manager, err := NewJWTManager()
+	manager, err := NewJWTManager(WithSecret([]byte("test-secret")))
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -30,8 +30,113 @@ func TestNewJWTManager(t *testing.T) {
 	}
 }
 
+func TestNewJWTManager_SecretFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt.key")
+	if err := os.WriteFile(path, []byte("file-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewJWTManager(WithSecretFile(path))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(manager.secret) != "file-secret" {
+		t.Errorf("Expected secret read from file, got %q", manager.secret)
+	}
+
+	if _, err := NewJWTManager(WithSecretFile(filepath.Join(t.TempDir(), "missing.key"))); err == nil {
+		t.Error("Expected error for a missing secret file")
+	}
+}
+
+func TestJWTManager_Options(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	manager, err := NewJWTManager(
+		WithSecret([]byte("test-secret")),
+		WithTokenTTLs(5*time.Minute, 24*time.Hour),
+		WithIssuer("https://auth.jobros.test"),
+		WithClock(func() time.Time { return now }),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	accessToken, refreshToken, err := manager.GenerateTokenPair("user123", RoleClient)
+	if err != nil {
+		t.Fatalf("Failed to generate token pair: %v", err)
+	}
+	access, err := manager.GetTokenClaims(accessToken, TokenTypeAccess)
+	if err != nil {
+		t.Fatalf("Failed to parse access token: %v", err)
+	}
+	refresh, err := manager.GetTokenClaims(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		t.Fatalf("Failed to parse refresh token: %v", err)
+	}
+	if !access.ExpiresAt.Time.Equal(now.Add(5*time.Minute)) || !refresh.ExpiresAt.Time.Equal(now.Add(24*time.Hour)) {
+		t.Errorf("Unexpected expiries: access %v, refresh %v", access.ExpiresAt, refresh.ExpiresAt)
+	}
+	if access.Issuer != "https://auth.jobros.test" {
+		t.Errorf("Expected issuer claim, got %q", access.Issuer)
+	}
+
+	// The token expires according to the clock of the manager
+	now = now.Add(5 * time.Minute)
+	if manager.ValidateToken(accessToken, TokenTypeAccess) {
+		t.Error("Expected access token to be expired")
+	}
+	if !manager.ValidateToken(refreshToken, TokenTypeRefresh) {
+		t.Error("Expected refresh token to be valid")
+	}
+
+	// Tokens from another issuer are rejected
+	other, _ := NewJWTManager(WithSecret([]byte("test-secret")), WithClock(func() time.Time { return now }))
+	otherToken, _ := other.GenerateAccessToken("user123", RoleClient)
+	if manager.ValidateToken(otherToken, TokenTypeAccess) {
+		t.Error("Expected token without the issuer to be rejected")
+	}
+}
+
+func TestJWTManager_SetSecret(t *testing.T) {
+	manager, _ := NewJWTManager(WithSecret([]byte("first-secret")))
+	before, _ := manager.GenerateAccessToken("user123", RoleClient)
+
+	if err := manager.SetSecret([]byte("second-secret")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	after, _ := manager.GenerateAccessToken("user123", RoleClient)
+	if !manager.ValidateToken(before, TokenTypeAccess) || !manager.ValidateToken(after, TokenTypeAccess) {
+		t.Error("Expected tokens signed with the current and previous secrets to be valid")
+	}
+
+	other, _ := NewJWTManager(WithSecret([]byte("second-secret")))
+	if !other.ValidateToken(after, TokenTypeAccess) {
+		t.Error("Expected new tokens to be signed with the rotated secret")
+	}
+
+	_ = manager.SetSecret([]byte("third-secret"))
+	if manager.ValidateToken(before, TokenTypeAccess) {
+		t.Error("Expected tokens signed with a secret rotated twice to be rejected")
+	}
+}
+
+func TestJWTManager_SetSecret_PreviousExpires(t *testing.T) {
+	now := time.Now()
+	manager, _ := NewJWTManager(WithSecret([]byte("first-secret")), WithClock(func() time.Time { return now }))
+
+	_ = manager.SetSecret([]byte("second-secret"))
+	if len(manager.verificationSecrets()) != 2 {
+		t.Fatal("Expected the previous secret to be kept while its tokens are valid")
+	}
+
+	now = now.Add(manager.RefreshTokenTTL())
+	if secrets := manager.verificationSecrets(); len(secrets) != 1 || string(secrets[0]) != "second-secret" {
+		t.Error("Expected the previous secret to be dropped once its refresh tokens expired")
+	}
+}
+
 func TestJWTManager_GetGroupVersionKind(t *testing.T) {
-	manager, _ := NewJWTManager()
+	manager, _ := NewJWTManager(WithSecret([]byte("test-secret")))
 	expectedGVK := runtime.GroupVersionKind{
 		Group:   apis.APIGroup,
 		Version: apis.APIVersion,
@@ -46,13 +151,13 @@ func TestJWTManager_GetGroupVersionKind(t *testing.T) {
 }
 
 func TestJWTManager_DeepCopy(t *testing.T) {
-	manager, _ := NewJWTManager()
+	manager, _ := NewJWTManager(WithSecret([]byte("test-secret")))
 
 	// Modify the secret of the original manager to ensure deep copy works
 	originalSecret := []byte("test-secret-key")
 	manager.secret = originalSecret
 
-	copiedManager := manager.DeepCopy().(*JWTManager)
+	copiedManager := manager.DeepCopy()
 
 	// Check if the copied manager is a different instance
 	if copiedManager == manager {
@@ -72,8 +177,7 @@ func TestJWTManager_DeepCopy(t *testing.T) {
 }
 
 func TestJWTManager_GenerateAccessToken(t *testing.T) {
-	os.Setenv("JWT_SECRET_KEY", "test-secret")
-	manager, _ := NewJWTManager()
+	manager, _ := NewJWTManager(WithSecret([]byte("test-secret")))
 
 	token, err := manager.GenerateAccessToken("user123", "admin")
 	if err != nil {
@@ -84,7 +188,7 @@ func TestJWTManager_GenerateAccessToken(t *testing.T) {
 	}
 
 	// Validate the generated token
-	claims, err := manager.GetTokenClaims(token)
+	claims, err := manager.GetTokenClaims(token, TokenTypeAccess)
 	if err != nil {
 		t.Errorf("Failed to parse token claims: %v", err)
 	}
@@ -97,8 +201,7 @@ func TestJWTManager_GenerateAccessToken(t *testing.T) {
 }
 
 func TestJWTManager_GenerateTokenPair(t *testing.T) {
-	os.Setenv("JWT_SECRET_KEY", "test-secret")
-	manager, _ := NewJWTManager()
+	manager, _ := NewJWTManager(WithSecret([]byte("test-secret")))
 
 	accessToken, refreshToken, err := manager.GenerateTokenPair("user123", "admin")
 	if err != nil {
@@ -110,8 +213,7 @@ func TestJWTManager_GenerateTokenPair(t *testing.T) {
 }
 
 func TestJWTManager_ValidateToken(t *testing.T) {
-	os.Setenv("JWT_SECRET_KEY", "test-secret")
-	manager, _ := NewJWTManager()
+	manager, _ := NewJWTManager(WithSecret([]byte("test-secret")))
 
 	tests := []struct {
 		name      string
@@ -130,6 +232,7 @@ func TestJWTManager_ValidateToken(t *testing.T) {
 			name: "Expired token",
 			setup: func() string {
 				claims := &JWTClaims{
+					Type:   TokenTypeAccess,
 					UserID: "user123",
 					Role:   "admin",
 					RegisteredClaims: jwt.RegisteredClaims{
@@ -146,6 +249,7 @@ func TestJWTManager_ValidateToken(t *testing.T) {
 			name: "Future issued token",
 			setup: func() string {
 				claims := &JWTClaims{
+					Type:   TokenTypeAccess,
 					UserID: "user123",
 					Role:   "admin",
 					RegisteredClaims: jwt.RegisteredClaims{
@@ -162,6 +266,7 @@ func TestJWTManager_ValidateToken(t *testing.T) {
 			name: "Invalid signature",
 			setup: func() string {
 				claims := &JWTClaims{
+					Type:   TokenTypeAccess,
 					UserID: "user123",
 					Role:   "admin",
 					RegisteredClaims: jwt.RegisteredClaims{
@@ -178,7 +283,7 @@ func TestJWTManager_ValidateToken(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			token := tt.setup()
-			if got := manager.ValidateToken(token); got != tt.wantValid {
+			if got := manager.ValidateToken(token, TokenTypeAccess); got != tt.wantValid {
 				t.Errorf("ValidateToken() = %v, want %v", got, tt.wantValid)
 			}
 		})
@@ -186,12 +291,11 @@ func TestJWTManager_ValidateToken(t *testing.T) {
 }
 
 func TestJWTManager_GetTokenClaims(t *testing.T) {
-	os.Setenv("JWT_SECRET_KEY", "test-secret")
-	manager, _ := NewJWTManager()
+	manager, _ := NewJWTManager(WithSecret([]byte("test-secret")))
 
 	// Test valid token claims
 	token, _ := manager.GenerateAccessToken("user123", "admin")
-	claims, err := manager.GetTokenClaims(token)
+	claims, err := manager.GetTokenClaims(token, TokenTypeAccess)
 	if err != nil {
 		t.Errorf("Failed to get token claims: %v", err)
 	}
@@ -200,8 +304,53 @@ func TestJWTManager_GetTokenClaims(t *testing.T) {
 	}
 
 	// Test invalid token
-	_, err = manager.GetTokenClaims("invalid-token")
+	_, err = manager.GetTokenClaims("invalid-token", TokenTypeAccess)
 	if err == nil {
 		t.Error("Expected error for invalid token")
 	}
 }
+
+func TestJWTManager_GetTokenClaims_Type(t *testing.T) {
+	manager, _ := NewJWTManager(WithSecret([]byte("test-secret")))
+	accessToken, refreshToken, err := manager.GenerateTokenPair("user123", RoleClient)
+	if err != nil {
+		t.Fatalf("Failed to generate token pair: %v", err)
+	}
+
+	if _, err := manager.GetTokenClaims(refreshToken, TokenTypeAccess); !errors.Is(err, ErrWrongTokenType) {
+		t.Errorf("Expected refresh token to be refused as access token, got %v", err)
+	}
+	if _, err := manager.GetTokenClaims(accessToken, TokenTypeRefresh); !errors.Is(err, ErrWrongTokenType) {
+		t.Errorf("Expected access token to be refused as refresh token, got %v", err)
+	}
+
+	// Tokens without a type, issued before the typ claim, are refused.
+	claims := &JWTClaims{UserID: "user123", Role: RoleClient, RegisteredClaims: jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}}
+	untyped, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+	if manager.ValidateToken(untyped, TokenTypeAccess) {
+		t.Error("Expected token without typ claim to be refused")
+	}
+}
+
+func TestJWTManager_GenerateAccessToken_Scopes(t *testing.T) {
+	manager, _ := NewJWTManager(WithSecret([]byte("test-secret")))
+
+	token, err := manager.GenerateAccessToken("user123", RoleProvider, ScopeServicesRead)
+	if err != nil {
+		t.Fatalf("Failed to generate access token: %v", err)
+	}
+	claims, err := manager.GetTokenClaims(token, TokenTypeAccess)
+	if err != nil {
+		t.Fatalf("Failed to parse token claims: %v", err)
+	}
+	if !reflect.DeepEqual(claims.Scopes, []string{ScopeServicesRead}) {
+		t.Errorf("Expected scopes [%s], got %v", ScopeServicesRead, claims.Scopes)
+	}
+
+	// Scopes beyond the role's permissions are refused
+	if _, err := manager.GenerateAccessToken("user123", RoleClient, ScopeUsersAdminister); err == nil {
+		t.Error("Expected error when requesting a scope the role does not allow")
+	}
+}
@@ -1,14 +1,53 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
 )
 
+// ClaimsContextKey is the gin context key under which AuthMiddleware stores the
+// claims of the validated token.
+const ClaimsContextKey = "claims"
+
+// TokenCheck is an additional check applied by AuthMiddleware to the claims of
+// an otherwise valid token. It rejects the token by returning ErrTokenRevoked
+// or ErrUserNotActive, possibly wrapped. Other errors are failures to check
+// the token, such as a database outage, and fail the request without
+// rejecting the token.
+type TokenCheck func(ctx context.Context, claims *JWTClaims) error
+
+// ErrUserNotActive is returned by ActiveUserCheck for users that are pending,
+// suspended, deactivated or deleted.
+var ErrUserNotActive = errors.New("user is not active")
+
+// UserStatusLookup reports whether a user may currently use their tokens.
+type UserStatusLookup interface {
+	IsUserActive(ctx context.Context, userID string) (bool, error)
+}
+
+// ActiveUserCheck returns a TokenCheck rejecting tokens of users that are not active.
+func ActiveUserCheck(users UserStatusLookup) TokenCheck {
+	return func(ctx context.Context, claims *JWTClaims) error {
+		active, err := users.IsUserActive(ctx, claims.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to check user status: %w", err)
+		}
+		if !active {
+			return ErrUserNotActive
+		}
+		return nil
+	}
+}
+
 // AuthMiddleware is a middleware that checks if the request has a valid JWT token
-func AuthMiddleware(jwtManager *JWTManager) gin.HandlerFunc {
+// and that it passes all the given checks
+func AuthMiddleware(jwtManager *JWTManager, checks ...TokenCheck) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -22,11 +61,59 @@ func AuthMiddleware(jwtManager *JWTManager) gin.HandlerFunc {
 			return
 		}
 
-		token := parts[1]
-		if !jwtManager.ValidateToken(token) {
+		claims, err := jwtManager.GetTokenClaims(parts[1], TokenTypeAccess)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			return
 		}
+		for _, check := range checks {
+			err := check(c.Request.Context(), claims)
+			switch {
+			case err == nil:
+				continue
+			case errors.Is(err, ErrUserNotActive):
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Account is not active"})
+			case errors.Is(err, ErrTokenRevoked):
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			default:
+				// The token may well be valid: answering 401 would log the
+				// user out.
+				glog.Errorf("failed to check the token of %s: %v", claims.UserID, err)
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Service temporarily unavailable"})
+			}
+			return
+		}
+		c.Set(ClaimsContextKey, claims)
+
+		c.Next()
+	}
+}
+
+// ClaimsFromContext returns the claims stored by AuthMiddleware.
+func ClaimsFromContext(c *gin.Context) (*JWTClaims, bool) {
+	value, ok := c.Get(ClaimsContextKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(*JWTClaims)
+	return claims, ok
+}
+
+// ScopeMiddleware rejects requests whose token does not grant all the given
+// scopes. It must run after AuthMiddleware.
+func ScopeMiddleware(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := ClaimsFromContext(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication is required"})
+			return
+		}
+
+		if !claims.HasScopes(scopes...) {
+			c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, strings.Join(scopes, " ")))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient scope", "requiredScopes": scopes})
+			return
+		}
 
 		c.Next()
 	}
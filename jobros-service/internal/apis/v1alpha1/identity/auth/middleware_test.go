@@ -1,11 +1,12 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
 	"time"
 
@@ -18,8 +19,7 @@ func setupTest(t *testing.T) (*gin.Engine, *JWTManager) {
 	router := gin.New()
 
 	// Set JWT_SECRET_KEY for testing
-	os.Setenv("JWT_SECRET_KEY", "test-secret-key")
-	jwtManager, err := NewJWTManager()
+	jwtManager, err := NewJWTManager(WithSecret([]byte("test-secret-key")))
 	if err != nil {
 		t.Fatalf("Failed to create JWTManager: %v", err)
 	}
@@ -69,6 +69,7 @@ func TestAuthMiddleware_ExpiredToken(t *testing.T) {
 
 	// Generate an expired token
 	accessClaims := &JWTClaims{
+		Type:   TokenTypeAccess,
 		UserID: "testuser",
 		Role:   "testrole",
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -96,6 +97,7 @@ func TestAuthMiddleware_InvalidSignature(t *testing.T) {
 
 	// Generate a token with a different secret
 	accessClaims := &JWTClaims{
+		Type:   TokenTypeAccess,
 		UserID: "testuser",
 		Role:   "testrole",
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -135,3 +137,147 @@ func TestAuthMiddleware_ValidToken_WithRealJWTManager(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestAuthMiddleware_RefreshToken(t *testing.T) {
+	router, jwtManager := setupTest(t)
+	_, refreshToken, err := jwtManager.GenerateTokenPair("testuser", "testrole")
+	if err != nil {
+		t.Fatalf("Failed to generate token pair: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", refreshToken))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestScopeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager, err := NewJWTManager(WithSecret([]byte("test-secret-key")))
+	if err != nil {
+		t.Fatalf("Failed to create JWTManager: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(AuthMiddleware(jwtManager))
+	router.GET("/services", ScopeMiddleware(ScopeServicesWrite), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(scopes ...string) *httptest.ResponseRecorder {
+		token, err := jwtManager.GenerateAccessToken("testuser", RoleProvider, scopes...)
+		if err != nil {
+			t.Fatalf("Failed to generate access token: %v", err)
+		}
+		req, _ := http.NewRequest("GET", "/services", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request().Code)
+	assert.Equal(t, http.StatusOK, request(ScopeServicesWrite).Code)
+
+	w := request(ScopeServicesRead)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "insufficient_scope")
+}
+
+func TestAuthMiddleware_RevokedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager, err := NewJWTManager(WithSecret([]byte("test-secret-key")))
+	if err != nil {
+		t.Fatalf("Failed to create JWTManager: %v", err)
+	}
+	revocations := NewMemoryRevocationStore()
+
+	router := gin.New()
+	router.Use(AuthMiddleware(jwtManager, RevocationCheck(revocations)))
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	accessToken, err := jwtManager.GenerateAccessToken("testuser", "testrole")
+	if err != nil {
+		t.Fatalf("Failed to generate access token: %v", err)
+	}
+	request := func() int {
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request())
+
+	claims, _ := jwtManager.GetTokenClaims(accessToken, TokenTypeAccess)
+	_ = revocations.RevokeToken(context.Background(), claims.ID, claims.ExpiresAt.Time)
+	assert.Equal(t, http.StatusUnauthorized, request())
+}
+
+type fakeUserStatuses map[string]bool
+
+func (f fakeUserStatuses) IsUserActive(_ context.Context, userID string) (bool, error) {
+	return f[userID], nil
+}
+
+func TestAuthMiddleware_InactiveUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager, err := NewJWTManager(WithSecret([]byte("test-secret-key")))
+	if err != nil {
+		t.Fatalf("Failed to create JWTManager: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(AuthMiddleware(jwtManager, ActiveUserCheck(fakeUserStatuses{"active": true, "suspended": false})))
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(userID string) *httptest.ResponseRecorder {
+		accessToken, _ := jwtManager.GenerateAccessToken(userID, "testrole")
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request("active").Code)
+	w := request("suspended")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Account is not active")
+}
+
+type failingUserStatuses struct{}
+
+func (failingUserStatuses) IsUserActive(context.Context, string) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestAuthMiddleware_CheckFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager, err := NewJWTManager(WithSecret([]byte("test-secret-key")))
+	if err != nil {
+		t.Fatalf("Failed to create JWTManager: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(AuthMiddleware(jwtManager, ActiveUserCheck(failingUserStatuses{})))
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	accessToken, _ := jwtManager.GenerateAccessToken("testuser", "testrole")
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// A database outage does not log the user out.
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
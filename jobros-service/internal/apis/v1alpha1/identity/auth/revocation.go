@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	revokedTokensCollection     = "revoked_tokens"
+	revokedUserTokensCollection = "revoked_user_tokens"
+)
+
+// ErrTokenRevoked is returned by RevocationCheck for revoked tokens.
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// RevocationStore keeps the IDs (jti) of revoked tokens until they expire, and
+// the time before which all the tokens of a user were revoked.
+type RevocationStore interface {
+	RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	// RevokeUserTokens revokes every token of the user issued up to before.
+	RevokeUserTokens(ctx context.Context, userID string, before time.Time) error
+	// UserTokensRevokedBefore returns the time set by RevokeUserTokens, or the
+	// zero time if the tokens of the user were never revoked.
+	UserTokensRevokedBefore(ctx context.Context, userID string) (time.Time, error)
+}
+
+// RevocationCheck returns a TokenCheck rejecting tokens revoked in store.
+func RevocationCheck(store RevocationStore) TokenCheck {
+	return func(ctx context.Context, claims *JWTClaims) error {
+		if claims.ID != "" {
+			revoked, err := store.IsTokenRevoked(ctx, claims.ID)
+			if err != nil {
+				return fmt.Errorf("failed to check token revocation: %w", err)
+			}
+			if revoked {
+				return ErrTokenRevoked
+			}
+		}
+
+		before, err := store.UserTokensRevokedBefore(ctx, claims.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to check token revocation: %w", err)
+		}
+		if !before.IsZero() && (claims.IssuedAt == nil || !claims.IssuedAt.Time.After(before)) {
+			return ErrTokenRevoked
+		}
+		return nil
+	}
+}
+
+// MemoryRevocationStore keeps revoked token IDs in memory.
+type MemoryRevocationStore struct {
+	mu          sync.Mutex
+	revoked     map[string]time.Time
+	userRevoked map[string]time.Time
+	now         func() time.Time
+}
+
+// NewMemoryRevocationStore creates an empty MemoryRevocationStore.
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		revoked:     make(map[string]time.Time),
+		userRevoked: make(map[string]time.Time),
+		now:         time.Now,
+	}
+}
+
+func (s *MemoryRevocationStore) RevokeToken(_ context.Context, tokenID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, expiry := range s.revoked {
+		if now.After(expiry) {
+			delete(s.revoked, id)
+		}
+	}
+	s.revoked[tokenID] = expiresAt
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsTokenRevoked(_ context.Context, tokenID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.revoked[tokenID]
+	return ok, nil
+}
+
+func (s *MemoryRevocationStore) RevokeUserTokens(_ context.Context, userID string, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if before.After(s.userRevoked[userID]) {
+		s.userRevoked[userID] = before
+	}
+	return nil
+}
+
+func (s *MemoryRevocationStore) UserTokensRevokedBefore(_ context.Context, userID string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.userRevoked[userID], nil
+}
+
+type revokedToken struct {
+	TokenID   string    `bson:"_id"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// MongoRevocationStore keeps revoked token IDs in MongoDB, shared by all
+// replicas. Entries are removed by a TTL index once the token has expired.
+type MongoRevocationStore struct {
+	collection     *mongo.Collection
+	userCollection *mongo.Collection
+	// tokenLifetime is the lifetime of the longest lived token, after which a
+	// revocation of all the tokens of a user no longer needs to be kept.
+	tokenLifetime time.Duration
+}
+
+type revokedUserTokens struct {
+	UserID        string    `bson:"_id"`
+	RevokedBefore time.Time `bson:"revokedBefore"`
+	ExpiresAt     time.Time `bson:"expiresAt"`
+}
+
+// NewMongoRevocationStore creates a store backed by the revoked_tokens
+// collection. Revocations of all the tokens of a user are kept for
+// tokenLifetime, the lifetime of the longest lived token, which is the refresh
+// token TTL of the JWT manager.
+func NewMongoRevocationStore(ctx context.Context, database *mongo.Database, tokenLifetime time.Duration) (*MongoRevocationStore, error) {
+	if tokenLifetime <= 0 {
+		return nil, fmt.Errorf("token lifetime must be positive")
+	}
+	store := &MongoRevocationStore{
+		collection:     database.Collection(revokedTokensCollection),
+		userCollection: database.Collection(revokedUserTokensCollection),
+		tokenLifetime:  tokenLifetime,
+	}
+
+	for _, collection := range []*mongo.Collection{store.collection, store.userCollection} {
+		_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create revoked token index: %w", err)
+		}
+	}
+
+	return store, nil
+}
+
+func (s *MongoRevocationStore) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	_, err := s.collection.ReplaceOne(ctx,
+		bson.M{"_id": tokenID},
+		revokedToken{TokenID: tokenID, ExpiresAt: expiresAt},
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+func (s *MongoRevocationStore) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	count, err := s.collection.CountDocuments(ctx, bson.M{"_id": tokenID}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *MongoRevocationStore) RevokeUserTokens(ctx context.Context, userID string, before time.Time) error {
+	// $max keeps the latest cutoff when revocations race.
+	_, err := s.userCollection.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{
+			"$max": bson.M{"revokedBefore": before, "expiresAt": before.Add(s.tokenLifetime)},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (s *MongoRevocationStore) UserTokensRevokedBefore(ctx context.Context, userID string) (time.Time, error) {
+	var revoked revokedUserTokens
+	err := s.userCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&revoked)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return revoked.RevokedBefore, nil
+}
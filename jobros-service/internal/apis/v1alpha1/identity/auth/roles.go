@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
+)
+
+// ErrRoleNotAssigned is returned when a token is requested for a role the user
+// does not hold.
+var ErrRoleNotAssigned = errors.New("role is not assigned to the user")
+
+func containsRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectActiveRole returns the role a user logging in acts with: the requested
+// role if the user holds it, otherwise the first of their roles.
+func SelectActiveRole(roles []string, requested string) (string, error) {
+	if len(roles) == 0 {
+		return "", fmt.Errorf("%w: the user has no roles", ErrRoleNotAssigned)
+	}
+	if requested == "" {
+		return roles[0], nil
+	}
+	if !containsRole(roles, requested) {
+		return "", fmt.Errorf("%w: %q", ErrRoleNotAssigned, requested)
+	}
+	return requested, nil
+}
+
+// UserRolesLookup returns the roles users currently hold, none for unknown
+// users.
+type UserRolesLookup interface {
+	UserRoles(ctx context.Context, userID string) ([]string, error)
+}
+
+// SwitchActiveRole issues a new token pair for the user of claims acting with
+// role. Roles are the roles the user currently holds, rather than those of the
+// token, so that a role removed from the user cannot be switched to. Tokens
+// carrying every scope of their role get every scope of the new role; narrowed
+// tokens keep only the scopes the new role also allows.
+func (m *JWTManager) SwitchActiveRole(claims *JWTClaims, roles []string, role string) (accessToken string, refreshToken string, err error) {
+	if claims.ClientID != "" {
+		return "", "", errors.New("tokens issued to applications cannot switch roles")
+	}
+	if !containsRole(roles, role) {
+		return "", "", fmt.Errorf("%w: %q", ErrRoleNotAssigned, role)
+	}
+
+	var scopes []string
+	if !containsAllScopes(claims.Scopes, RoleScopes[claims.Role]) {
+		for _, scope := range claims.Scopes {
+			if containsAllScopes(RoleScopes[role], []string{scope}) {
+				scopes = append(scopes, scope)
+			}
+		}
+		if len(scopes) == 0 {
+			return "", "", fmt.Errorf("none of the token scopes are allowed for role %q", role)
+		}
+	}
+
+	return m.GenerateTokenPairWithRoles(claims.UserID, roles, role, scopes...)
+}
+
+func containsAllScopes(set []string, subset []string) bool {
+	return (&JWTClaims{Scopes: set}).HasScopes(subset...)
+}
+
+// RoleMiddleware rejects requests whose active role is not one of roles. It
+// must run after AuthMiddleware.
+func RoleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := ClaimsFromContext(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication is required"})
+			return
+		}
+
+		if !containsRole(roles, claims.Role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Active role is not allowed", "allowedRoles": roles})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+type switchRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// SwitchRoleHandler issues a new token pair with another role of the
+// authenticated user as active role, among the roles users returns. The token
+// presented is revoked in revocations once the new pair is issued, so that the
+// previous role cannot be used alongside the new one. It must run after
+// AuthMiddleware.
+func SwitchRoleHandler(jwtManager *JWTManager, users UserRolesLookup, revocations RevocationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := ClaimsFromContext(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication is required"})
+			return
+		}
+
+		var req switchRoleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		roles, err := users.UserRoles(c.Request.Context(), claims.UserID)
+		if err != nil {
+			glog.Errorf("failed to read the roles of %s: %v", claims.UserID, err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Service temporarily unavailable"})
+			return
+		}
+
+		accessToken, refreshToken, err := jwtManager.SwitchActiveRole(claims, roles, req.Role)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err := revocations.RevokeToken(c.Request.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+			glog.Errorf("failed to revoke the token of %s after a role switch: %v", claims.UserID, err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Service temporarily unavailable"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"accessToken":  accessToken,
+			"refreshToken": refreshToken,
+			"role":         req.Role,
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectActiveRole(t *testing.T) {
+	roles := []string{RoleClient, RoleProvider}
+
+	role, err := SelectActiveRole(roles, "")
+	assert.NoError(t, err)
+	assert.Equal(t, RoleClient, role)
+
+	role, err = SelectActiveRole(roles, RoleProvider)
+	assert.NoError(t, err)
+	assert.Equal(t, RoleProvider, role)
+
+	_, err = SelectActiveRole(roles, RoleAdmin)
+	assert.True(t, errors.Is(err, ErrRoleNotAssigned))
+
+	_, err = SelectActiveRole(nil, "")
+	assert.Error(t, err)
+}
+
+func TestJWTManager_SwitchActiveRole(t *testing.T) {
+	manager, _ := NewJWTManager(WithSecret([]byte("test-secret")))
+	roles := []string{RoleClient, RoleProvider}
+
+	accessToken, _, err := manager.GenerateTokenPairWithRoles("user123", roles, RoleClient)
+	require.NoError(t, err)
+	claims, err := manager.GetTokenClaims(accessToken, TokenTypeAccess)
+	require.NoError(t, err)
+	assert.Equal(t, RoleClient, claims.Role)
+	assert.Equal(t, roles, claims.Roles)
+
+	accessToken, _, err = manager.SwitchActiveRole(claims, roles, RoleProvider)
+	require.NoError(t, err)
+	switched, err := manager.GetTokenClaims(accessToken, TokenTypeAccess)
+	require.NoError(t, err)
+	assert.Equal(t, RoleProvider, switched.Role)
+	assert.ElementsMatch(t, RoleScopes[RoleProvider], switched.Scopes)
+
+	// Narrowed tokens stay narrowed
+	accessToken, _, err = manager.GenerateTokenPairWithRoles("user123", roles, RoleClient, ScopeBookingsRead)
+	require.NoError(t, err)
+	claims, _ = manager.GetTokenClaims(accessToken, TokenTypeAccess)
+	accessToken, _, err = manager.SwitchActiveRole(claims, roles, RoleProvider)
+	require.NoError(t, err)
+	switched, _ = manager.GetTokenClaims(accessToken, TokenTypeAccess)
+	assert.Equal(t, []string{ScopeBookingsRead}, switched.Scopes)
+
+	// Roles the user does not hold are refused
+	_, _, err = manager.SwitchActiveRole(claims, roles, RoleAdmin)
+	assert.True(t, errors.Is(err, ErrRoleNotAssigned))
+	// Including roles of the token removed from the user since
+	_, _, err = manager.SwitchActiveRole(claims, []string{RoleClient}, RoleProvider)
+	assert.True(t, errors.Is(err, ErrRoleNotAssigned))
+	_, _, err = manager.GenerateTokenPairWithRoles("user123", roles, RoleAdmin)
+	assert.True(t, errors.Is(err, ErrRoleNotAssigned))
+}
+
+type staticRoles map[string][]string
+
+func (r staticRoles) UserRoles(_ context.Context, userID string) ([]string, error) {
+	return r[userID], nil
+}
+
+func TestRoleMiddleware_SwitchRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager, err := NewJWTManager(WithSecret([]byte("test-secret-key")))
+	require.NoError(t, err)
+
+	revocations := NewMemoryRevocationStore()
+	router := gin.New()
+	router.Use(AuthMiddleware(jwtManager, RevocationCheck(revocations)))
+	users := staticRoles{"user123": {RoleClient, RoleProvider}}
+	router.POST("/auth/role", SwitchRoleHandler(jwtManager, users, revocations))
+	router.POST("/services", RoleMiddleware(RoleProvider), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	do := func(method string, path string, token string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	clientToken, _, err := jwtManager.GenerateTokenPairWithRoles("user123", []string{RoleClient, RoleProvider}, RoleClient)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, do("POST", "/services", clientToken, "").Code)
+
+	w := do("POST", "/auth/role", clientToken, `{"role":"provider"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, http.StatusCreated, do("POST", "/services", resp["accessToken"], "").Code)
+
+	// The token of the previous role is revoked
+	assert.Equal(t, http.StatusUnauthorized, do("POST", "/auth/role", clientToken, `{"role":"provider"}`).Code)
+
+	clientToken, _, err = jwtManager.GenerateTokenPairWithRoles("user123", []string{RoleClient, RoleProvider}, RoleClient)
+	require.NoError(t, err)
+	w = do("POST", "/auth/role", clientToken, `{"role":"admin"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// The token still lists provider, but the user no longer holds it
+	users["user123"] = []string{RoleClient}
+	w = do("POST", "/auth/role", clientToken, `{"role":"provider"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	RoleAdmin    = "admin"
+	RoleClient   = "client"
+	RoleProvider = "provider"
+)
+
+const (
+	ScopeProfileRead     = "profile:read"
+	ScopeProfileWrite    = "profile:write"
+	ScopeServicesRead    = "services:read"
+	ScopeServicesWrite   = "services:write"
+	ScopeBookingsRead    = "bookings:read"
+	ScopeBookingsWrite   = "bookings:write"
+	ScopeMessagesRead    = "messages:read"
+	ScopeMessagesWrite   = "messages:write"
+	ScopePayoutsRead     = "payouts:read"
+	ScopeUsersAdminister = "users:admin"
+)
+
+// RoleScopes lists the scopes a user with the given role may be granted. A token
+// never carries a scope its role does not allow.
+var RoleScopes = map[string][]string{
+	RoleClient: {
+		ScopeProfileRead, ScopeProfileWrite,
+		ScopeServicesRead,
+		ScopeBookingsRead, ScopeBookingsWrite,
+		ScopeMessagesRead, ScopeMessagesWrite,
+	},
+	RoleProvider: {
+		ScopeProfileRead, ScopeProfileWrite,
+		ScopeServicesRead, ScopeServicesWrite,
+		ScopeBookingsRead, ScopeBookingsWrite,
+		ScopeMessagesRead, ScopeMessagesWrite,
+		ScopePayoutsRead,
+	},
+	RoleAdmin: {
+		ScopeProfileRead, ScopeProfileWrite,
+		ScopeServicesRead, ScopeServicesWrite,
+		ScopeBookingsRead, ScopeBookingsWrite,
+		ScopeMessagesRead, ScopeMessagesWrite,
+		ScopePayoutsRead,
+		ScopeUsersAdminister,
+	},
+}
+
+// ResolveScopes returns the scopes to grant a token for role. Without requested
+// scopes the token gets every scope of the role; otherwise every requested scope
+// must be allowed for the role.
+func ResolveScopes(role string, requested []string) ([]string, error) {
+	allowed := make(map[string]bool)
+	for _, scope := range RoleScopes[role] {
+		allowed[scope] = true
+	}
+
+	if len(requested) == 0 {
+		return append([]string(nil), RoleScopes[role]...), nil
+	}
+
+	granted := make(map[string]bool)
+	for _, scope := range requested {
+		if !allowed[scope] {
+			return nil, fmt.Errorf("scope %q is not allowed for role %q", scope, role)
+		}
+		granted[scope] = true
+	}
+
+	scopes := make([]string, 0, len(granted))
+	for scope := range granted {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return scopes, nil
+}
+
+// HasScopes reports whether the claims grant all the given scopes.
+func (c *JWTClaims) HasScopes(scopes ...string) bool {
+	granted := make(map[string]bool, len(c.Scopes))
+	for _, scope := range c.Scopes {
+		granted[scope] = true
+	}
+	for _, scope := range scopes {
+		if !granted[scope] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveScopes(t *testing.T) {
+	// No requested scopes grants every scope of the role
+	scopes, err := ResolveScopes(RoleClient, nil)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, RoleScopes[RoleClient], scopes)
+
+	// Requested scopes narrow the token
+	scopes, err = ResolveScopes(RoleProvider, []string{ScopeServicesWrite, ScopeServicesRead, ScopeServicesRead})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ScopeServicesRead, ScopeServicesWrite}, scopes)
+
+	// Scopes outside the role are rejected
+	_, err = ResolveScopes(RoleClient, []string{ScopeUsersAdminister})
+	assert.Error(t, err)
+
+	// Unknown roles get no scopes
+	scopes, err = ResolveScopes("unknown", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, scopes)
+}
+
+func TestJWTClaims_HasScopes(t *testing.T) {
+	claims := &JWTClaims{Scopes: []string{ScopeProfileRead, ScopeBookingsRead}}
+
+	assert.True(t, claims.HasScopes())
+	assert.True(t, claims.HasScopes(ScopeProfileRead))
+	assert.True(t, claims.HasScopes(ScopeProfileRead, ScopeBookingsRead))
+	assert.False(t, claims.HasScopes(ScopeProfileRead, ScopeProfileWrite))
+}
@@ -0,0 +1,140 @@
+package invite
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/app"
+)
+
+var (
+	// ErrRegistrationClosed is returned when registration is closed.
+	ErrRegistrationClosed = errors.New("registration is closed")
+	// ErrInviteRequired is returned when registering without an invite code in
+	// invite-only mode.
+	ErrInviteRequired = errors.New("an invite code is required to register")
+	// ErrInviteInvalid is returned for unknown, expired or used up invite codes.
+	ErrInviteInvalid = errors.New("invite code is invalid or expired")
+	// ErrInviteRoleMismatch is returned when registering with another role than
+	// the one the invite is for.
+	ErrInviteRoleMismatch = errors.New("invite code is not valid for this role")
+)
+
+// codeAlphabet leaves out characters that are easily confused when an invite
+// code is typed by hand.
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const codeLength = 10
+
+// newCode returns a random invite code.
+func newCode() (string, error) {
+	b := make([]byte, codeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate invite code: %w", err)
+	}
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b), nil
+}
+
+// Gate decides whether a registration is allowed by the registration mode and
+// the invite code presented.
+type Gate struct {
+	mode      RegistrationMode
+	inviteTTL time.Duration
+	store     Store
+	now       func() time.Time
+}
+
+// NewGate creates a Gate from the registration configuration.
+func NewGate(config app.RegistrationConfig, store Store) (*Gate, error) {
+	mode := RegistrationMode(config.Mode)
+	switch mode {
+	case ModeOpen, ModeInviteOnly, ModeClosed:
+	default:
+		return nil, fmt.Errorf("unknown registration mode %q", config.Mode)
+	}
+	return &Gate{mode: mode, inviteTTL: config.InviteTTL, store: store, now: time.Now}, nil
+}
+
+// Mode returns the registration mode.
+func (g *Gate) Mode() RegistrationMode {
+	return g.mode
+}
+
+// Check tells whether a user can register with role and the invite code, which
+// may be empty in open mode. It returns the invite, if any. Registration must
+// call Redeem once the user has been created.
+func (g *Gate) Check(ctx context.Context, code string, role string) (*Invite, error) {
+	if g.mode == ModeClosed {
+		return nil, ErrRegistrationClosed
+	}
+	if code == "" {
+		if g.mode == ModeInviteOnly {
+			return nil, ErrInviteRequired
+		}
+		return nil, nil
+	}
+
+	invite, err := g.store.GetInvite(ctx, code)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrInviteInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read invite: %w", err)
+	}
+	if !invite.Usable(g.now()) {
+		return nil, ErrInviteInvalid
+	}
+	if invite.Role != "" && role != invite.Role {
+		return nil, ErrInviteRoleMismatch
+	}
+	return invite, nil
+}
+
+// Redeem records that the user registered with the invite code. It fails with
+// ErrInviteInvalid if the invite was used up since Check.
+func (g *Gate) Redeem(ctx context.Context, code string, userID string) (*Invite, error) {
+	invite, err := g.store.RedeemInvite(ctx, code, Redemption{UserID: userID, At: g.now()})
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInviteUnusable) {
+		return nil, ErrInviteInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem invite: %w", err)
+	}
+	return invite, nil
+}
+
+// CreateInvite creates an invite for up to maxUses registrations with role,
+// expiring after ttl, or after the configured invite TTL when ttl is zero.
+func (g *Gate) CreateInvite(ctx context.Context, createdBy string, role string, maxUses int, ttl time.Duration) (*Invite, error) {
+	if maxUses < 1 {
+		return nil, errors.New("an invite must allow at least one use")
+	}
+	if ttl <= 0 {
+		ttl = g.inviteTTL
+	}
+
+	code, err := newCode()
+	if err != nil {
+		return nil, err
+	}
+	now := g.now()
+	invite := &Invite{
+		Code:        code,
+		CreatedBy:   createdBy,
+		Role:        role,
+		MaxUses:     maxUses,
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+		Redemptions: []Redemption{},
+	}
+	if err := g.store.CreateInvite(ctx, invite); err != nil {
+		return nil, fmt.Errorf("failed to create invite: %w", err)
+	}
+	return invite, nil
+}
@@ -0,0 +1,83 @@
+package invite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGate(t *testing.T, mode RegistrationMode, store Store) *Gate {
+	gate, err := NewGate(app.RegistrationConfig{Mode: string(mode), InviteTTL: 24 * time.Hour}, store)
+	require.NoError(t, err)
+	return gate
+}
+
+func TestNewGate_UnknownMode(t *testing.T) {
+	_, err := NewGate(app.RegistrationConfig{Mode: "waitlist"}, NewMemoryStore())
+	assert.Error(t, err)
+}
+
+func TestGate_Check(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	invites := newTestGate(t, ModeOpen, store)
+	providerInvite, err := invites.CreateInvite(ctx, "admin", "provider", 2, 0)
+	require.NoError(t, err)
+	assert.Len(t, providerInvite.Code, codeLength)
+
+	tests := []struct {
+		name string
+		mode RegistrationMode
+		code string
+		role string
+		err  error
+	}{
+		{"open without code", ModeOpen, "", "client", nil},
+		{"open with unknown code", ModeOpen, "UNKNOWN", "client", ErrInviteInvalid},
+		{"invite-only without code", ModeInviteOnly, "", "client", ErrInviteRequired},
+		{"invite-only with code", ModeInviteOnly, providerInvite.Code, "provider", nil},
+		{"invite for another role", ModeInviteOnly, providerInvite.Code, "client", ErrInviteRoleMismatch},
+		{"closed", ModeClosed, providerInvite.Code, "provider", ErrRegistrationClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestGate(t, tt.mode, store).Check(ctx, tt.code, tt.role)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestGate_Redeem(t *testing.T) {
+	ctx := context.Background()
+	gate := newTestGate(t, ModeInviteOnly, NewMemoryStore())
+	invite, err := gate.CreateInvite(ctx, "admin", "", 2, 0)
+	require.NoError(t, err)
+
+	for _, userID := range []string{"user-1", "user-2"} {
+		_, err := gate.Check(ctx, invite.Code, "client")
+		require.NoError(t, err)
+		_, err = gate.Redeem(ctx, invite.Code, userID)
+		require.NoError(t, err)
+	}
+
+	_, err = gate.Check(ctx, invite.Code, "client")
+	assert.ErrorIs(t, err, ErrInviteInvalid)
+	_, err = gate.Redeem(ctx, invite.Code, "user-3")
+	assert.ErrorIs(t, err, ErrInviteInvalid)
+
+	stored, err := gate.store.GetInvite(ctx, invite.Code)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Uses)
+	assert.Equal(t, "user-2", stored.Redemptions[1].UserID)
+
+	expiring, err := gate.CreateInvite(ctx, "admin", "", 1, time.Hour)
+	require.NoError(t, err)
+	gate.now = func() time.Time { return expiring.ExpiresAt }
+	_, err = gate.Check(ctx, expiring.Code, "client")
+	assert.ErrorIs(t, err, ErrInviteInvalid)
+}
@@ -0,0 +1,167 @@
+package invite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	invitesCollection  = "invites"
+	waitlistCollection = "waitlist"
+)
+
+// MongoStore is a Store backed by MongoDB. Invites are kept after they expire
+// so that redemptions remain auditable.
+type MongoStore struct {
+	invites  *mongo.Collection
+	waitlist *mongo.Collection
+}
+
+// NewMongoStore creates a MongoStore and ensures its indexes exist.
+func NewMongoStore(ctx context.Context, database *mongo.Database) (*MongoStore, error) {
+	store := &MongoStore{
+		invites:  database.Collection(invitesCollection),
+		waitlist: database.Collection(waitlistCollection),
+	}
+
+	if _, err := store.invites.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "createdAt", Value: -1}},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create indexes on %s: %w", invitesCollection, err)
+	}
+	if _, err := store.waitlist.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: 1}}},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create indexes on %s: %w", waitlistCollection, err)
+	}
+
+	return store, nil
+}
+
+func (s *MongoStore) CreateInvite(ctx context.Context, invite *Invite) error {
+	_, err := s.invites.InsertOne(ctx, invite)
+	return err
+}
+
+func (s *MongoStore) GetInvite(ctx context.Context, code string) (*Invite, error) {
+	var invite Invite
+	err := s.invites.FindOne(ctx, bson.M{"_id": code}).Decode(&invite)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+func (s *MongoStore) ListInvites(ctx context.Context) ([]Invite, error) {
+	cursor, err := s.invites.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	var invites []Invite
+	if err := cursor.All(ctx, &invites); err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+func (s *MongoStore) RedeemInvite(ctx context.Context, code string, redemption Redemption) (*Invite, error) {
+	// Checking the uses in the filter keeps concurrent redemptions from going
+	// over MaxUses.
+	var invite Invite
+	err := s.invites.FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":       code,
+			"expiresAt": bson.M{"$gt": redemption.At},
+			"$expr":     bson.M{"$lt": bson.A{"$uses", "$maxUses"}},
+		},
+		bson.M{
+			"$inc":  bson.M{"uses": 1},
+			"$push": bson.M{"redemptions": redemption},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&invite)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, err := s.GetInvite(ctx, code); err != nil {
+			return nil, err
+		}
+		return nil, ErrInviteUnusable
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+func (s *MongoStore) AddToWaitlist(ctx context.Context, entry *WaitlistEntry) error {
+	entry.Email = normalizeEmail(entry.Email)
+	res, err := s.waitlist.InsertOne(ctx, entry)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrAlreadyOnWaitlist
+	}
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		entry.ID = id
+	}
+	return nil
+}
+
+func (s *MongoStore) GetWaitlistEntry(ctx context.Context, id primitive.ObjectID) (*WaitlistEntry, error) {
+	var entry WaitlistEntry
+	err := s.waitlist.FindOne(ctx, bson.M{"_id": id}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *MongoStore) ListWaitlist(ctx context.Context, status WaitlistStatus, limit int) ([]WaitlistEntry, error) {
+	cursor, err := s.waitlist.Find(ctx,
+		bson.M{"status": status},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var entries []WaitlistEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *MongoStore) ApproveWaitlistEntry(ctx context.Context, id primitive.ObjectID, inviteCode string, by string, at time.Time) (*WaitlistEntry, error) {
+	var entry WaitlistEntry
+	err := s.waitlist.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": WaitlistPending},
+		bson.M{"$set": bson.M{
+			"status":     WaitlistApproved,
+			"approvedAt": at,
+			"approvedBy": by,
+			"inviteCode": inviteCode,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
@@ -0,0 +1,99 @@
+package invite
+
+import (
+	"net/http"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/openapi"
+)
+
+// OpenAPIRoutes describes the registration, waitlist and invite routes.
+func OpenAPIRoutes() []openapi.Route {
+	admin := []int{http.StatusForbidden}
+	return []openapi.Route{{
+		Method:      http.MethodGet,
+		Path:        "/registration",
+		OperationID: "getRegistration",
+		Summary:     "Get the active registration mode",
+		Tag:         "registration",
+		Response: struct {
+			Mode RegistrationMode `json:"mode" binding:"oneof=open invite-only closed"`
+		}{},
+		Description: "The registration mode",
+	}, {
+		Method:      http.MethodPost,
+		Path:        "/waitlist",
+		OperationID: "joinWaitlist",
+		Summary:     "Join the waitlist",
+		Tag:         "registration",
+		Request:     joinWaitlistRequest{},
+		Status:      http.StatusAccepted,
+		Response: struct {
+			Status WaitlistStatus `json:"status"`
+		}{},
+		Description: "The email is on the waitlist, whether it just joined or was already on it",
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden},
+	}, {
+		Method:      http.MethodGet,
+		Path:        "/waitlist",
+		OperationID: "listWaitlist",
+		Summary:     "List the waitlist entries with a status, oldest first",
+		Tag:         "registration",
+		Auth:        true,
+		Query: struct {
+			Status WaitlistStatus `form:"status" binding:"omitempty,oneof=pending approved"`
+		}{},
+		Response: struct {
+			Entries []WaitlistEntry `json:"entries"`
+		}{},
+		Description: "The waitlist entries",
+		Errors:      admin,
+	}, {
+		Method:      http.MethodPost,
+		Path:        "/waitlist/approve",
+		OperationID: "approveWaitlist",
+		Summary:     "Approve waitlist entries, sending each an invite",
+		Tag:         "registration",
+		Auth:        true,
+		Request:     approveWaitlistRequest{},
+		Response: struct {
+			Approved []WaitlistEntry `json:"approved"`
+			Skipped  []string        `json:"skipped"`
+		}{},
+		Description: "The approved entries and the ids skipped",
+		Errors:      append([]int{http.StatusBadRequest}, admin...),
+	}, {
+		Method:      http.MethodPost,
+		Path:        "/invites",
+		OperationID: "createInvite",
+		Summary:     "Create an invite code",
+		Tag:         "registration",
+		Auth:        true,
+		Request:     createInviteRequest{},
+		Status:      http.StatusCreated,
+		Response:    Invite{},
+		Description: "The invite",
+		Errors:      append([]int{http.StatusBadRequest}, admin...),
+	}, {
+		Method:      http.MethodGet,
+		Path:        "/invites",
+		OperationID: "listInvites",
+		Summary:     "List the invites with their redemptions, newest first",
+		Tag:         "registration",
+		Auth:        true,
+		Response: struct {
+			Invites []Invite `json:"invites"`
+		}{},
+		Description: "The invites",
+		Errors:      admin,
+	}, {
+		Method:      http.MethodGet,
+		Path:        "/invites/{code}",
+		OperationID: "readInvite",
+		Summary:     "Get an invite with its redemptions",
+		Tag:         "registration",
+		Auth:        true,
+		Response:    Invite{},
+		Description: "The invite",
+		Errors:      append([]int{http.StatusNotFound}, admin...),
+	}}
+}
@@ -0,0 +1,281 @@
+package invite
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultListLimit = 100
+
+// maxInviteTTL is the longest an invite created through the API may be valid.
+const maxInviteTTL = 90 * 24 * time.Hour
+
+// Sender delivers the invite code of an approved waitlist entry.
+type Sender interface {
+	SendInvite(ctx context.Context, entry *WaitlistEntry, invite *Invite) error
+}
+
+// LogSender logs invite codes. It stands in until a notification service
+// emails them.
+type LogSender struct{}
+
+func (LogSender) SendInvite(_ context.Context, entry *WaitlistEntry, invite *Invite) error {
+	glog.Infof("invite %s issued to waitlist entry %s (%s)", invite.Code, entry.ID.Hex(), entry.Email)
+	return nil
+}
+
+// Server serves the invite and waitlist endpoints.
+type Server struct {
+	gate   *Gate
+	store  Store
+	sender Sender
+	now    func() time.Time
+}
+
+// NewServer creates a Server. sender may be nil, in which case invite codes are
+// only logged.
+func NewServer(gate *Gate, store Store, sender Sender) *Server {
+	if sender == nil {
+		sender = LogSender{}
+	}
+	return &Server{gate: gate, store: store, sender: sender, now: time.Now}
+}
+
+// RegisterRoutes registers the public registration and waitlist endpoints and
+// the administration endpoints, which require the users:administer scope.
+func (s *Server) RegisterRoutes(router gin.IRouter, authMiddleware gin.HandlerFunc) {
+	router.GET("/registration", s.GetRegistration)
+	router.POST("/waitlist", s.JoinWaitlist)
+
+	admin := router.Group("", authMiddleware, auth.ScopeMiddleware(auth.ScopeUsersAdminister))
+	{
+		admin.POST("/invites", s.CreateInvite)
+		admin.GET("/invites", s.ListInvites)
+		admin.GET("/invites/:code", s.GetInvite)
+		admin.GET("/waitlist", s.ListWaitlist)
+		admin.POST("/waitlist/approve", s.ApproveWaitlist)
+	}
+}
+
+// GetRegistration tells the app which registration mode is active, so that it
+// can ask for an invite code or offer the waitlist.
+func (s *Server) GetRegistration(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"mode": s.gate.Mode()})
+}
+
+type createInviteRequest struct {
+	Role    string `json:"role"`
+	MaxUses int    `json:"maxUses" binding:"required,min=1"`
+	TTL     string `json:"ttl"`
+}
+
+// CreateInvite creates an invite code. The TTL is a duration such as "72h", at
+// most 90 days, and defaults to the configured invite TTL.
+func (s *Server) CreateInvite(c *gin.Context) {
+	claims, _ := auth.ClaimsFromContext(c)
+
+	var req createInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, ok := auth.RoleScopes[req.Role]; req.Role != "" && !ok {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Unknown role"})
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 || ttl > maxInviteTTL {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "ttl must be a positive duration such as 72h, of at most 2160h"})
+			return
+		}
+	}
+
+	invite, err := s.gate.CreateInvite(c.Request.Context(), claims.UserID, req.Role, req.MaxUses, ttl)
+	if err != nil {
+		glog.Errorf("failed to create invite: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invite"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invite)
+}
+
+// ListInvites lists all the invites with their redemptions, newest first.
+func (s *Server) ListInvites(c *gin.Context) {
+	invites, err := s.store.ListInvites(c.Request.Context())
+	if err != nil {
+		glog.Errorf("failed to list invites: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to list invites"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"invites": invites})
+}
+
+// GetInvite returns an invite with its redemptions.
+func (s *Server) GetInvite(c *gin.Context) {
+	invite, err := s.store.GetInvite(c.Request.Context(), c.Param("code"))
+	if errors.Is(err, ErrNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
+		return
+	}
+	if err != nil {
+		glog.Errorf("failed to read invite: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to read invite"})
+		return
+	}
+	c.JSON(http.StatusOK, invite)
+}
+
+type joinWaitlistRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"omitempty,oneof=client provider"`
+	City  string `json:"city"`
+}
+
+// JoinWaitlist adds an email to the waitlist. It is open to anyone unless
+// registration is closed. An email already on the waitlist gets the same
+// answer, so that it does not tell who signed up.
+func (s *Server) JoinWaitlist(c *gin.Context) {
+	if s.gate.Mode() == ModeClosed {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": ErrRegistrationClosed.Error()})
+		return
+	}
+
+	var req joinWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry := &WaitlistEntry{
+		Email:     req.Email,
+		Role:      req.Role,
+		City:      req.City,
+		Status:    WaitlistPending,
+		CreatedAt: s.now(),
+	}
+	err := s.store.AddToWaitlist(c.Request.Context(), entry)
+	if err != nil && !errors.Is(err, ErrAlreadyOnWaitlist) {
+		glog.Errorf("failed to add to waitlist: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to join the waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": WaitlistPending})
+}
+
+// ListWaitlist lists waitlist entries, oldest first, filtered by the status
+// query parameter (pending by default).
+func (s *Server) ListWaitlist(c *gin.Context) {
+	status := WaitlistStatus(c.DefaultQuery("status", string(WaitlistPending)))
+	entries, err := s.store.ListWaitlist(c.Request.Context(), status, defaultListLimit)
+	if err != nil {
+		glog.Errorf("failed to list waitlist: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to list the waitlist"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+type approveWaitlistRequest struct {
+	// IDs selects the entries to approve. When empty, the Count oldest pending
+	// entries are approved.
+	IDs   []string `json:"ids" binding:"max=500"`
+	Count int      `json:"count" binding:"omitempty,min=1,max=500"`
+}
+
+// ApproveWaitlist approves a batch of waitlist entries, issuing each a single
+// use invite for the role it asked for. Entries that are unknown or already
+// approved are reported as skipped.
+func (s *Server) ApproveWaitlist(c *gin.Context) {
+	claims, _ := auth.ClaimsFromContext(c)
+	ctx := c.Request.Context()
+
+	var req approveWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.IDs) == 0 && req.Count == 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Either ids or count is required"})
+		return
+	}
+
+	var ids []primitive.ObjectID
+	skipped := []string{}
+	for _, hex := range req.IDs {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			skipped = append(skipped, hex)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if len(req.IDs) == 0 {
+		entries, err := s.store.ListWaitlist(ctx, WaitlistPending, req.Count)
+		if err != nil {
+			glog.Errorf("failed to list waitlist: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve the waitlist"})
+			return
+		}
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+		}
+	}
+
+	approved := []WaitlistEntry{}
+	for _, id := range ids {
+		entry, err := s.approve(ctx, id, claims.UserID)
+		if errors.Is(err, ErrNotFound) {
+			skipped = append(skipped, id.Hex())
+			continue
+		}
+		if err != nil {
+			glog.Errorf("failed to approve waitlist entry %s: %v", id.Hex(), err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error":    "Failed to approve the waitlist",
+				"approved": approved,
+			})
+			return
+		}
+		approved = append(approved, *entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"approved": approved, "skipped": skipped})
+}
+
+// approve issues an invite for a pending waitlist entry and marks it approved.
+// The invite of an entry approved concurrently is left unused.
+func (s *Server) approve(ctx context.Context, id primitive.ObjectID, approvedBy string) (*WaitlistEntry, error) {
+	entry, err := s.store.GetWaitlistEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status != WaitlistPending {
+		return nil, ErrNotFound
+	}
+
+	invite, err := s.gate.CreateInvite(ctx, approvedBy, entry.Role, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	entry, err = s.store.ApproveWaitlistEntry(ctx, id, invite.Code, approvedBy, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sender.SendInvite(ctx, entry, invite); err != nil {
+		glog.Errorf("failed to send invite to waitlist entry %s: %v", id.Hex(), err)
+	}
+	return entry, nil
+}
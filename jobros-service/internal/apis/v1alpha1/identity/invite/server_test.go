@@ -0,0 +1,116 @@
+package invite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sentInvites map[string]string
+
+func (s sentInvites) SendInvite(_ context.Context, entry *WaitlistEntry, invite *Invite) error {
+	s[entry.Email] = invite.Code
+	return nil
+}
+
+func setupServer(t *testing.T) (*gin.Engine, *Gate, sentInvites, string) {
+	gin.SetMode(gin.TestMode)
+	jwtManager, err := auth.NewJWTManager(auth.WithSecret([]byte("test-secret-key")))
+	require.NoError(t, err)
+	adminToken, err := jwtManager.GenerateAccessToken("admin-1", auth.RoleAdmin)
+	require.NoError(t, err)
+
+	store := NewMemoryStore()
+	gate := newTestGate(t, ModeInviteOnly, store)
+	sent := sentInvites{}
+	router := gin.New()
+	NewServer(gate, store, sent).RegisterRoutes(router, auth.AuthMiddleware(jwtManager))
+	return router, gate, sent, adminToken
+}
+
+func request(router *gin.Engine, method string, path string, token string, body interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateInvite(t *testing.T) {
+	router, _, _, adminToken := setupServer(t)
+
+	w := request(router, "POST", "/invites", "", gin.H{"maxUses": 10})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = request(router, "POST", "/invites", adminToken, gin.H{"maxUses": 10, "role": "provider", "ttl": "72h"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var invite Invite
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &invite))
+	assert.Equal(t, "admin-1", invite.CreatedBy)
+	assert.Equal(t, "provider", invite.Role)
+	assert.Equal(t, 10, invite.MaxUses)
+	assert.Equal(t, 72*time.Hour, invite.ExpiresAt.Sub(invite.CreatedAt))
+
+	for _, ttl := range []interface{}{3600000000000, "3600000000000", "-1h", "2161h"} {
+		w = request(router, "POST", "/invites", adminToken, gin.H{"maxUses": 1, "ttl": ttl})
+		assert.Equal(t, http.StatusBadRequest, w.Code, ttl)
+	}
+
+	w = request(router, "POST", "/invites", adminToken, gin.H{"maxUses": 1, "role": "superuser"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWaitlistApproval(t *testing.T) {
+	router, gate, sent, adminToken := setupServer(t)
+
+	w := request(router, "GET", "/registration", "", nil)
+	assert.JSONEq(t, `{"mode":"invite-only"}`, w.Body.String())
+
+	for _, email := range []string{"first@example.com", "second@example.com", "third@example.com"} {
+		w := request(router, "POST", "/waitlist", "", gin.H{"email": email, "role": "provider", "city": "Lyon"})
+		require.Equal(t, http.StatusAccepted, w.Code)
+	}
+	// Joining again is answered the same, to not reveal who signed up.
+	w = request(router, "POST", "/waitlist", "", gin.H{"email": "First@example.com"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.JSONEq(t, `{"status":"pending"}`, w.Body.String())
+
+	w = request(router, "POST", "/waitlist/approve", adminToken, gin.H{"count": 2})
+	require.Equal(t, http.StatusOK, w.Code)
+	var res struct {
+		Approved []WaitlistEntry `json:"approved"`
+		Skipped  []string        `json:"skipped"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Len(t, res.Approved, 2)
+	assert.Equal(t, "first@example.com", res.Approved[0].Email)
+	assert.Equal(t, "second@example.com", res.Approved[1].Email)
+	assert.Len(t, sent, 2)
+
+	invite, err := gate.Check(context.Background(), sent["first@example.com"], "provider")
+	require.NoError(t, err)
+	assert.Equal(t, 1, invite.MaxUses)
+
+	approvedID := res.Approved[0].ID.Hex()
+	w = request(router, "POST", "/waitlist/approve", adminToken, gin.H{"ids": []string{approvedID, "bogus"}})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Empty(t, res.Approved)
+	assert.ElementsMatch(t, []string{"bogus", approvedID}, res.Skipped)
+
+	w = request(router, "GET", "/waitlist", adminToken, nil)
+	assert.Contains(t, w.Body.String(), "third@example.com")
+	assert.NotContains(t, w.Body.String(), "first@example.com")
+}
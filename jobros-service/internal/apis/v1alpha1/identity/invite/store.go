@@ -0,0 +1,161 @@
+package invite
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrNotFound is returned by a Store when the requested record does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrInviteUnusable is returned when redeeming an expired or used up invite.
+	ErrInviteUnusable = errors.New("invite has expired or has no uses left")
+	// ErrAlreadyOnWaitlist is returned when the email is already on the waitlist.
+	ErrAlreadyOnWaitlist = errors.New("email is already on the waitlist")
+)
+
+// Store persists invites and the waitlist.
+type Store interface {
+	CreateInvite(ctx context.Context, invite *Invite) error
+	GetInvite(ctx context.Context, code string) (*Invite, error)
+	// ListInvites returns all the invites, newest first.
+	ListInvites(ctx context.Context) ([]Invite, error)
+	// RedeemInvite records a redemption if the invite is still usable at
+	// redemption.At, and returns the updated invite.
+	RedeemInvite(ctx context.Context, code string, redemption Redemption) (*Invite, error)
+
+	AddToWaitlist(ctx context.Context, entry *WaitlistEntry) error
+	GetWaitlistEntry(ctx context.Context, id primitive.ObjectID) (*WaitlistEntry, error)
+	// ListWaitlist returns up to limit entries with the status, oldest first.
+	ListWaitlist(ctx context.Context, status WaitlistStatus, limit int) ([]WaitlistEntry, error)
+	// ApproveWaitlistEntry marks a pending entry approved with its invite code.
+	ApproveWaitlistEntry(ctx context.Context, id primitive.ObjectID, inviteCode string, by string, at time.Time) (*WaitlistEntry, error)
+}
+
+// normalizeEmail returns the form under which waitlist emails are compared.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// MemoryStore is a Store keeping everything in memory, for tests and local
+// development.
+type MemoryStore struct {
+	mu       sync.Mutex
+	invites  map[string]Invite
+	waitlist []WaitlistEntry
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{invites: make(map[string]Invite)}
+}
+
+func (s *MemoryStore) CreateInvite(_ context.Context, invite *Invite) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invites[invite.Code] = *invite
+	return nil
+}
+
+func (s *MemoryStore) GetInvite(_ context.Context, code string) (*Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	invite, ok := s.invites[code]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &invite, nil
+}
+
+func (s *MemoryStore) ListInvites(_ context.Context) ([]Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	invites := make([]Invite, 0, len(s.invites))
+	for _, invite := range s.invites {
+		invites = append(invites, invite)
+	}
+	sort.Slice(invites, func(i, j int) bool { return invites[i].CreatedAt.After(invites[j].CreatedAt) })
+	return invites, nil
+}
+
+func (s *MemoryStore) RedeemInvite(_ context.Context, code string, redemption Redemption) (*Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	invite, ok := s.invites[code]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !invite.Usable(redemption.At) {
+		return nil, ErrInviteUnusable
+	}
+	invite.Uses++
+	invite.Redemptions = append(invite.Redemptions, redemption)
+	s.invites[code] = invite
+	return &invite, nil
+}
+
+func (s *MemoryStore) AddToWaitlist(_ context.Context, entry *WaitlistEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.Email = normalizeEmail(entry.Email)
+	for _, existing := range s.waitlist {
+		if existing.Email == entry.Email {
+			return ErrAlreadyOnWaitlist
+		}
+	}
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	s.waitlist = append(s.waitlist, *entry)
+	return nil
+}
+
+func (s *MemoryStore) GetWaitlistEntry(_ context.Context, id primitive.ObjectID) (*WaitlistEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range s.waitlist {
+		if entry.ID == id {
+			return &entry, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) ListWaitlist(_ context.Context, status WaitlistStatus, limit int) ([]WaitlistEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []WaitlistEntry
+	for _, entry := range s.waitlist {
+		if entry.Status == status {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+func (s *MemoryStore) ApproveWaitlistEntry(_ context.Context, id primitive.ObjectID, inviteCode string, by string, at time.Time) (*WaitlistEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, entry := range s.waitlist {
+		if entry.ID != id || entry.Status != WaitlistPending {
+			continue
+		}
+		entry.Status = WaitlistApproved
+		entry.ApprovedAt = &at
+		entry.ApprovedBy = by
+		entry.InviteCode = inviteCode
+		s.waitlist[i] = entry
+		return &entry, nil
+	}
+	return nil, ErrNotFound
+}
@@ -0,0 +1,64 @@
+package invite
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RegistrationMode controls who can create an account.
+type RegistrationMode string
+
+const (
+	// ModeOpen lets anyone register. An invite code is optional.
+	ModeOpen RegistrationMode = "open"
+	// ModeInviteOnly requires a valid invite code to register.
+	ModeInviteOnly RegistrationMode = "invite-only"
+	// ModeClosed refuses every registration.
+	ModeClosed RegistrationMode = "closed"
+)
+
+// Invite is a code letting up to MaxUses users register until it expires. When
+// Role is set, the users registering with it get that role.
+type Invite struct {
+	Code        string       `json:"code" bson:"_id"`
+	CreatedBy   string       `json:"createdBy" bson:"createdBy"`
+	Role        string       `json:"role,omitempty" bson:"role,omitempty"`
+	MaxUses     int          `json:"maxUses" bson:"maxUses"`
+	Uses        int          `json:"uses" bson:"uses"`
+	ExpiresAt   time.Time    `json:"expiresAt" bson:"expiresAt"`
+	CreatedAt   time.Time    `json:"createdAt" bson:"createdAt"`
+	Redemptions []Redemption `json:"redemptions" bson:"redemptions"`
+}
+
+// Redemption records a registration made with an invite.
+type Redemption struct {
+	UserID string    `json:"userId" bson:"userId"`
+	At     time.Time `json:"at" bson:"at"`
+}
+
+// Usable reports whether the invite can still be redeemed at now.
+func (i *Invite) Usable(now time.Time) bool {
+	return i.Uses < i.MaxUses && now.Before(i.ExpiresAt)
+}
+
+// WaitlistStatus is the state of a waitlist entry.
+type WaitlistStatus string
+
+const (
+	WaitlistPending  WaitlistStatus = "pending"
+	WaitlistApproved WaitlistStatus = "approved"
+)
+
+// WaitlistEntry is a person waiting for an invite.
+type WaitlistEntry struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Email      string             `json:"email" bson:"email"`
+	Role       string             `json:"role,omitempty" bson:"role,omitempty"`
+	City       string             `json:"city,omitempty" bson:"city,omitempty"`
+	Status     WaitlistStatus     `json:"status" bson:"status"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+	ApprovedAt *time.Time         `json:"approvedAt,omitempty" bson:"approvedAt,omitempty"`
+	ApprovedBy string             `json:"approvedBy,omitempty" bson:"approvedBy,omitempty"`
+	InviteCode string             `json:"inviteCode,omitempty" bson:"inviteCode,omitempty"`
+}
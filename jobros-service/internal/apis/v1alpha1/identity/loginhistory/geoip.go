@@ -0,0 +1,113 @@
+package loginhistory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Location is the coarse location of an IP address.
+type Location struct {
+	Country string `json:"country,omitempty" bson:"country,omitempty"`
+	City    string `json:"city,omitempty" bson:"city,omitempty"`
+}
+
+type geoIPRange struct {
+	start    net.IP
+	end      net.IP
+	location Location
+}
+
+// GeoIPDatabase resolves IP addresses to locations from an offline database,
+// so that no request leaves the cluster. The database is a CSV file with a
+// network in CIDR notation, an ISO country code and an optional city per line:
+//
+//	network,country,city
+//	81.2.69.0/24,GB,London
+type GeoIPDatabase struct {
+	ranges []geoIPRange
+}
+
+// LoadGeoIPDatabase reads a GeoIP database from path.
+func LoadGeoIPDatabase(path string) (*GeoIPDatabase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	defer file.Close()
+
+	return ParseGeoIPDatabase(file)
+}
+
+// ParseGeoIPDatabase reads a GeoIP database in the format of LoadGeoIPDatabase.
+// Networks must not overlap.
+func ParseGeoIPDatabase(r io.Reader) (*GeoIPDatabase, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+
+	db := &GeoIPDatabase{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GeoIP database: %w", err)
+		}
+		if line == 1 && record[0] == "network" {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("invalid GeoIP database line %d: expected network and country", line)
+		}
+
+		_, network, err := net.ParseCIDR(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid GeoIP database line %d: %w", line, err)
+		}
+		location := Location{Country: strings.ToUpper(strings.TrimSpace(record[1]))}
+		if len(record) > 2 {
+			location.City = strings.TrimSpace(record[2])
+		}
+
+		start := network.IP.To16()
+		end := make(net.IP, len(start))
+		mask := network.Mask
+		if len(mask) == net.IPv4len {
+			mask = append(net.CIDRMask(96, 128)[:12], mask...)
+		}
+		for i := range start {
+			end[i] = start[i] | ^mask[i]
+		}
+		db.ranges = append(db.ranges, geoIPRange{start: start, end: end, location: location})
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return bytes.Compare(db.ranges[i].start, db.ranges[j].start) < 0
+	})
+	return db, nil
+}
+
+// Lookup returns the location of ip, or false if it is not in the database.
+func (db *GeoIPDatabase) Lookup(ip net.IP) (Location, bool) {
+	if db == nil || ip == nil {
+		return Location{}, false
+	}
+	ip = ip.To16()
+
+	// Find the last range starting at or before ip.
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return bytes.Compare(db.ranges[i].start, ip) > 0
+	}) - 1
+	if i < 0 || bytes.Compare(ip, db.ranges[i].end) > 0 {
+		return Location{}, false
+	}
+	return db.ranges[i].location, true
+}
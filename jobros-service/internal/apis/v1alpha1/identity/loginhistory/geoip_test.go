@@ -0,0 +1,51 @@
+package loginhistory
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGeoIPDatabase = `network,country,city
+# Test networks
+81.2.69.0/24,gb,London
+89.160.20.112/28,SE,Linköping
+2001:218::/32,JP,
+`
+
+func TestGeoIPDatabase_Lookup(t *testing.T) {
+	db, err := ParseGeoIPDatabase(strings.NewReader(testGeoIPDatabase))
+	require.NoError(t, err)
+
+	tests := []struct {
+		ip       string
+		location Location
+		found    bool
+	}{
+		{"81.2.69.142", Location{Country: "GB", City: "London"}, true},
+		{"81.2.70.1", Location{}, false},
+		{"89.160.20.127", Location{Country: "SE", City: "Linköping"}, true},
+		{"89.160.20.128", Location{}, false},
+		{"2001:218:1::1", Location{Country: "JP"}, true},
+		{"10.0.0.1", Location{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			location, found := db.Lookup(net.ParseIP(tt.ip))
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.location, location)
+		})
+	}
+}
+
+func TestParseGeoIPDatabase_Invalid(t *testing.T) {
+	_, err := ParseGeoIPDatabase(strings.NewReader("not-a-network,GB\n"))
+	assert.Error(t, err)
+
+	_, err = ParseGeoIPDatabase(strings.NewReader("81.2.69.0/24\n"))
+	assert.Error(t, err)
+}
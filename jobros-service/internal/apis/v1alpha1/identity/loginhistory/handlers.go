@@ -0,0 +1,83 @@
+package loginhistory
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/oauth"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+
+	// DeviceIDHeader carries the identifier the app generates for its device.
+	DeviceIDHeader = "X-Device-ID"
+)
+
+// AttemptFromRequest describes a login attempt made with the request c.
+func AttemptFromRequest(c *gin.Context, userID string, method Method, outcome Outcome) Attempt {
+	return Attempt{
+		UserID:    userID,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		DeviceID:  c.GetHeader(DeviceIDHeader),
+		Method:    method,
+		Outcome:   outcome,
+	}
+}
+
+// OAuthLoginHook records the exchanges of authorization codes and refresh
+// tokens by the OAuth server as logins of their user. Failures to record are
+// logged rather than failing the login.
+func OAuthLoginHook(recorder *Recorder) oauth.LoginHook {
+	return func(c *gin.Context, userID string, grantType string, succeeded bool) {
+		method := MethodOAuth
+		if grantType == oauth.GrantTypeRefreshToken {
+			method = MethodRefreshToken
+		}
+		outcome := OutcomeFailure
+		if succeeded {
+			outcome = OutcomeSuccess
+		}
+		if _, err := recorder.Record(c.Request.Context(), AttemptFromRequest(c, userID, method, outcome)); err != nil {
+			glog.Errorf("failed to record login of user %s: %v", userID, err)
+		}
+	}
+}
+
+// ListHandler returns the login history of the authenticated user, newest
+// first. It must run after AuthMiddleware.
+func ListHandler(store Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := auth.ClaimsFromContext(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication is required"})
+			return
+		}
+
+		limit := defaultListLimit
+		if value := c.Query("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 || parsed > maxListLimit {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+				return
+			}
+			limit = parsed
+		}
+
+		events, err := store.List(c.Request.Context(), claims.UserID, limit)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to list logins"})
+			return
+		}
+		if events == nil {
+			events = []Event{}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"items": events})
+	}
+}
@@ -0,0 +1,27 @@
+package loginhistory
+
+import (
+	"net/http"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/openapi"
+)
+
+// OpenAPIRoutes describes the login history route.
+func OpenAPIRoutes() []openapi.Route {
+	return []openapi.Route{{
+		Method:      http.MethodGet,
+		Path:        "/auth/logins",
+		OperationID: "listLogins",
+		Summary:     "List the login attempts of the user, newest first",
+		Tag:         "auth",
+		Auth:        true,
+		Query: struct {
+			Limit int `form:"limit" binding:"omitempty,min=1,max=200"`
+		}{},
+		Response: struct {
+			Items []Event `json:"items"`
+		}{},
+		Description: "The login attempts",
+		Errors:      []int{http.StatusBadRequest},
+	}}
+}
@@ -0,0 +1,141 @@
+package loginhistory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// AlertNewDevice is raised for a successful login from a device never used before.
+	AlertNewDevice = "new_device"
+	// AlertNewCountry is raised for a successful login from a country never seen before.
+	AlertNewCountry = "new_country"
+)
+
+// Notifier tells a user about a login from an unknown device or country.
+type Notifier interface {
+	NotifyNewLogin(ctx context.Context, event *Event, alerts []string) error
+}
+
+// LogNotifier logs new-login alerts. It stands in until a notification service
+// delivers them to users.
+type LogNotifier struct{}
+
+func (LogNotifier) NotifyNewLogin(_ context.Context, event *Event, alerts []string) error {
+	glog.Infof("new login alert %v for user %s from %s (%s)", alerts, event.UserID, event.IP, event.Location.Country)
+	return nil
+}
+
+// Attempt describes a login attempt to record.
+type Attempt struct {
+	UserID    string
+	IP        string
+	UserAgent string
+	// DeviceID identifies the device when the app sends one; otherwise the
+	// device is derived from the user agent.
+	DeviceID string
+	Method   Method
+	Outcome  Outcome
+}
+
+// Recorder records login attempts and alerts users of logins from new devices
+// or countries.
+type Recorder struct {
+	store    Store
+	geoIP    *GeoIPDatabase
+	notifier Notifier
+	now      func() time.Time
+}
+
+// NewRecorder creates a Recorder. geoIP may be nil, in which case locations are
+// not resolved and no new-country alert is raised.
+func NewRecorder(store Store, geoIP *GeoIPDatabase, notifier Notifier) *Recorder {
+	if notifier == nil {
+		notifier = LogNotifier{}
+	}
+	return &Recorder{store: store, geoIP: geoIP, notifier: notifier, now: time.Now}
+}
+
+// deviceID derives a stable identifier for the device of an attempt.
+func deviceID(attempt Attempt) string {
+	source := attempt.DeviceID
+	if source == "" {
+		source = attempt.UserAgent
+	}
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Record stores the attempt. For a successful login that is not the first of
+// the user, it notifies the user when the device or the country is new.
+// Notification failures are logged rather than failing the login.
+func (r *Recorder) Record(ctx context.Context, attempt Attempt) (*Event, error) {
+	now := r.now()
+	event := &Event{
+		UserID:    attempt.UserID,
+		Time:      now,
+		IP:        attempt.IP,
+		UserAgent: attempt.UserAgent,
+		DeviceID:  deviceID(attempt),
+		Method:    attempt.Method,
+		Outcome:   attempt.Outcome,
+		ExpiresAt: now.Add(retention),
+	}
+	if location, ok := r.geoIP.Lookup(net.ParseIP(attempt.IP)); ok {
+		event.Location = location
+	}
+
+	var alerts []string
+	if event.Outcome == OutcomeSuccess {
+		var err error
+		if alerts, err = r.alerts(ctx, event); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.store.Add(ctx, event); err != nil {
+		return nil, fmt.Errorf("failed to record login: %w", err)
+	}
+
+	if len(alerts) > 0 {
+		if err := r.notifier.NotifyNewLogin(ctx, event, alerts); err != nil {
+			glog.Errorf("failed to notify user %s of new login: %v", event.UserID, err)
+		}
+	}
+	return event, nil
+}
+
+// alerts returns the alerts raised by a successful login, checked against the
+// previous successful logins of the user.
+func (r *Recorder) alerts(ctx context.Context, event *Event) ([]string, error) {
+	known, err := r.store.HasSucceeded(ctx, event.UserID, Event{})
+	if err != nil || !known {
+		return nil, err
+	}
+
+	var alerts []string
+	seenDevice, err := r.store.HasSucceeded(ctx, event.UserID, Event{DeviceID: event.DeviceID})
+	if err != nil {
+		return nil, err
+	}
+	if !seenDevice {
+		alerts = append(alerts, AlertNewDevice)
+	}
+
+	if event.Location.Country != "" {
+		seenCountry, err := r.store.HasSucceeded(ctx, event.UserID, Event{Location: Location{Country: event.Location.Country}})
+		if err != nil {
+			return nil, err
+		}
+		if !seenCountry {
+			alerts = append(alerts, AlertNewCountry)
+		}
+	}
+	return alerts, nil
+}
@@ -0,0 +1,111 @@
+package loginhistory
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNotifier struct {
+	alerts [][]string
+}
+
+func (n *fakeNotifier) NotifyNewLogin(_ context.Context, _ *Event, alerts []string) error {
+	n.alerts = append(n.alerts, alerts)
+	return nil
+}
+
+func newTestRecorder(t *testing.T) (*Recorder, *MemoryStore, *fakeNotifier) {
+	geoIP, err := ParseGeoIPDatabase(strings.NewReader(testGeoIPDatabase))
+	require.NoError(t, err)
+
+	store := NewMemoryStore()
+	notifier := &fakeNotifier{}
+	recorder := NewRecorder(store, geoIP, notifier)
+
+	now := time.Date(2024, 12, 20, 10, 0, 0, 0, time.UTC)
+	recorder.now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	return recorder, store, notifier
+}
+
+func TestRecorder_Record(t *testing.T) {
+	recorder, store, notifier := newTestRecorder(t)
+	ctx := context.Background()
+	login := func(ip string, userAgent string, outcome Outcome) {
+		_, err := recorder.Record(ctx, Attempt{UserID: "user1", IP: ip, UserAgent: userAgent, Method: MethodPassword, Outcome: outcome})
+		require.NoError(t, err)
+	}
+
+	// The first login of a user raises no alert
+	login("81.2.69.142", "iPhone", OutcomeSuccess)
+	assert.Empty(t, notifier.alerts)
+
+	// Same device and country
+	login("81.2.69.10", "iPhone", OutcomeSuccess)
+	assert.Empty(t, notifier.alerts)
+
+	// Failed attempts are recorded but raise no alert
+	login("89.160.20.113", "Firefox", OutcomeFailure)
+	assert.Empty(t, notifier.alerts)
+
+	login("81.2.69.10", "Firefox", OutcomeSuccess)
+	login("89.160.20.113", "Firefox", OutcomeSuccess)
+	assert.Equal(t, [][]string{{AlertNewDevice}, {AlertNewCountry}}, notifier.alerts)
+
+	events, err := store.List(ctx, "user1", 10)
+	require.NoError(t, err)
+	require.Len(t, events, 5)
+	assert.Equal(t, Location{Country: "SE", City: "Linköping"}, events[0].Location)
+	assert.Equal(t, OutcomeFailure, events[2].Outcome)
+}
+
+func TestListHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager, err := auth.NewJWTManager(auth.WithSecret([]byte("test-secret-key")))
+	require.NoError(t, err)
+
+	recorder, store, _ := newTestRecorder(t)
+	for i := 0; i < 3; i++ {
+		_, err := recorder.Record(context.Background(), Attempt{UserID: "user1", IP: "81.2.69.142", Method: MethodPassword, Outcome: OutcomeSuccess})
+		require.NoError(t, err)
+	}
+	_, err = recorder.Record(context.Background(), Attempt{UserID: "user2", IP: "81.2.69.142", Method: MethodPassword, Outcome: OutcomeSuccess})
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/users/me/logins", auth.AuthMiddleware(jwtManager), ListHandler(store))
+
+	token, _ := jwtManager.GenerateAccessToken("user1", auth.RoleClient)
+	req := httptest.NewRequest("GET", "/users/me/logins?limit=2", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Items []Event `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.Items, 2)
+	for _, event := range resp.Items {
+		assert.Equal(t, "user1", event.UserID)
+	}
+
+	req = httptest.NewRequest("GET", "/users/me/logins?limit=0", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,182 @@
+package loginhistory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	loginEventsCollection = "login_events"
+	// retention is how long login events are kept.
+	retention = 365 * 24 * time.Hour
+)
+
+// Method is how the user authenticated.
+type Method string
+
+const (
+	MethodPassword     Method = "password"
+	MethodOTP          Method = "otp"
+	MethodRefreshToken Method = "refresh_token"
+	MethodOAuth        Method = "oauth"
+)
+
+// Outcome is the result of a login attempt.
+type Outcome string
+
+const (
+	OutcomeSuccess     Outcome = "success"
+	OutcomeFailure     Outcome = "failure"
+	OutcomeMFARequired Outcome = "mfa_required"
+	OutcomeBlocked     Outcome = "blocked"
+)
+
+// Event is a login attempt of a user.
+type Event struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID    string             `json:"userId" bson:"userId"`
+	Time      time.Time          `json:"time" bson:"time"`
+	IP        string             `json:"ip" bson:"ip"`
+	UserAgent string             `json:"userAgent" bson:"userAgent"`
+	DeviceID  string             `json:"deviceId" bson:"deviceId"`
+	Location  Location           `json:"location" bson:"location"`
+	Method    Method             `json:"method" bson:"method"`
+	Outcome   Outcome            `json:"outcome" bson:"outcome"`
+	ExpiresAt time.Time          `json:"-" bson:"expiresAt"`
+}
+
+// Store keeps the login history of users.
+type Store interface {
+	Add(ctx context.Context, event *Event) error
+	// List returns the most recent events of the user, newest first.
+	List(ctx context.Context, userID string, limit int) ([]Event, error)
+	// HasSucceeded reports whether the user ever logged in successfully with
+	// events matching all the non-empty fields of filter.
+	HasSucceeded(ctx context.Context, userID string, filter Event) (bool, error)
+}
+
+// MemoryStore is a Store keeping events in memory, for tests and local development.
+type MemoryStore struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Add(_ context.Context, event *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	s.events = append(s.events, *event)
+	return nil
+}
+
+func (s *MemoryStore) List(_ context.Context, userID string, limit int) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []Event
+	for _, event := range s.events {
+		if event.UserID == userID {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.After(events[j].Time) })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (s *MemoryStore) HasSucceeded(_ context.Context, userID string, filter Event) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, event := range s.events {
+		if event.UserID != userID || event.Outcome != OutcomeSuccess {
+			continue
+		}
+		if filter.DeviceID != "" && event.DeviceID != filter.DeviceID {
+			continue
+		}
+		if filter.Location.Country != "" && event.Location.Country != filter.Location.Country {
+			continue
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// MongoStore is a Store backed by MongoDB. Events expire after a year.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a MongoStore and ensures its indexes exist.
+func NewMongoStore(ctx context.Context, database *mongo.Database) (*MongoStore, error) {
+	collection := database.Collection(loginEventsCollection)
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "time", Value: -1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "outcome", Value: 1}, {Key: "deviceId", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create login event indexes: %w", err)
+	}
+
+	return &MongoStore{collection: collection}, nil
+}
+
+func (s *MongoStore) Add(ctx context.Context, event *Event) error {
+	res, err := s.collection.InsertOne(ctx, event)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		event.ID = id
+	}
+	return nil
+}
+
+func (s *MongoStore) List(ctx context.Context, userID string, limit int) ([]Event, error) {
+	cursor, err := s.collection.Find(ctx,
+		bson.M{"userId": userID},
+		options.Find().SetSort(bson.D{{Key: "time", Value: -1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (s *MongoStore) HasSucceeded(ctx context.Context, userID string, filter Event) (bool, error) {
+	query := bson.M{"userId": userID, "outcome": OutcomeSuccess}
+	if filter.DeviceID != "" {
+		query["deviceId"] = filter.DeviceID
+	}
+	if filter.Location.Country != "" {
+		query["location.country"] = filter.Location.Country
+	}
+
+	count, err := s.collection.CountDocuments(ctx, query, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,210 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+)
+
+const (
+	tokenTypeHintAccessToken  = "access_token"
+	tokenTypeHintRefreshToken = "refresh_token"
+)
+
+type tokenInspectionRequest struct {
+	Token         string `form:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientID      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+// introspectionResponse is the response of the introspection endpoint, as
+// defined by RFC 7662. Inactive tokens only have active set.
+type introspectionResponse struct {
+	Active    bool   `json:"active"`
+	TokenType string `json:"token_type,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Role      string `json:"role,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+}
+
+// inspectedToken is a token presented to the introspection or revocation
+// endpoint, resolved to either an access token or a refresh token.
+type inspectedToken struct {
+	access  *auth.JWTClaims
+	refresh *RefreshToken
+}
+
+// clientID returns the client the token was issued to, empty for first-party tokens.
+func (t *inspectedToken) clientID() string {
+	if t.access != nil {
+		return t.access.ClientID
+	}
+	return t.refresh.ClientID
+}
+
+// bindTokenInspection authenticates the calling client and binds the request.
+// Only confidential clients may introspect or revoke tokens.
+func (s *Server) bindTokenInspection(c *gin.Context) (*tokenInspectionRequest, *Client, bool) {
+	c.Header("Cache-Control", "no-store")
+
+	var req tokenInspectionRequest
+	if err := c.ShouldBind(&req); err != nil {
+		oauthError(c, http.StatusBadRequest, errInvalidRequest, err.Error())
+		return nil, nil, false
+	}
+
+	client, ok := s.authenticateClient(c, req.ClientID, req.ClientSecret)
+	if !ok {
+		return nil, nil, false
+	}
+	if !client.Confidential {
+		oauthError(c, http.StatusUnauthorized, errInvalidClient, "Client credentials are required")
+		return nil, nil, false
+	}
+
+	return &req, client, true
+}
+
+// lookupToken resolves the presented token, trying the hinted type first. It
+// returns nil for tokens that are unknown, expired or revoked.
+func (s *Server) lookupToken(c *gin.Context, req *tokenInspectionRequest) (*inspectedToken, error) {
+	lookups := []func() (*inspectedToken, error){
+		func() (*inspectedToken, error) { return s.lookupAccessToken(c, req.Token) },
+		func() (*inspectedToken, error) { return s.lookupRefreshToken(c, req.Token) },
+	}
+	if req.TokenTypeHint == tokenTypeHintRefreshToken {
+		lookups[0], lookups[1] = lookups[1], lookups[0]
+	}
+
+	for _, lookup := range lookups {
+		token, err := lookup()
+		if err != nil || token != nil {
+			return token, err
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) lookupAccessToken(c *gin.Context, token string) (*inspectedToken, error) {
+	// Refresh tokens of the first-party apps are not access tokens.
+	claims, err := s.jwtManager.GetTokenClaims(token, auth.TokenTypeAccess)
+	if err != nil {
+		return nil, nil
+	}
+
+	err = auth.RevocationCheck(s.revocations)(c.Request.Context(), claims)
+	if errors.Is(err, auth.ErrTokenRevoked) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inspectedToken{access: claims}, nil
+}
+
+func (s *Server) lookupRefreshToken(c *gin.Context, token string) (*inspectedToken, error) {
+	refresh, err := s.store.GetRefreshToken(c.Request.Context(), hashToken(token))
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if refresh.Revoked || s.now().After(refresh.ExpiresAt) {
+		return nil, nil
+	}
+	return &inspectedToken{refresh: refresh}, nil
+}
+
+// Introspect implements RFC 7662 token introspection. Internal clients can
+// introspect any token, other clients only the tokens issued to them.
+func (s *Server) Introspect(c *gin.Context) {
+	req, client, ok := s.bindTokenInspection(c)
+	if !ok {
+		return
+	}
+
+	token, err := s.lookupToken(c, req)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	if token == nil || (!client.Internal && token.clientID() != client.ID) {
+		c.JSON(http.StatusOK, introspectionResponse{Active: false})
+		return
+	}
+
+	if token.access != nil {
+		claims := token.access
+		response := introspectionResponse{
+			Active:    true,
+			TokenType: "Bearer",
+			Sub:       claims.UserID,
+			Role:      claims.Role,
+			Scope:     strings.Join(claims.Scopes, " "),
+			ClientID:  claims.ClientID,
+			Exp:       claims.ExpiresAt.Unix(),
+			Jti:       claims.ID,
+		}
+		if claims.IssuedAt != nil {
+			response.Iat = claims.IssuedAt.Unix()
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, introspectionResponse{
+		Active:    true,
+		TokenType: tokenTypeHintRefreshToken,
+		Sub:       token.refresh.UserID,
+		Role:      token.refresh.Role,
+		Scope:     strings.Join(token.refresh.Scopes, " "),
+		ClientID:  token.refresh.ClientID,
+		Exp:       token.refresh.ExpiresAt.Unix(),
+	})
+}
+
+// Revoke implements RFC 7009 token revocation. Internal clients can revoke any
+// token, other clients only the tokens issued to them. As required by the RFC,
+// unknown and already invalid tokens are answered with a success.
+func (s *Server) Revoke(c *gin.Context) {
+	req, client, ok := s.bindTokenInspection(c)
+	if !ok {
+		return
+	}
+
+	token, err := s.lookupToken(c, req)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	if token == nil {
+		c.Status(http.StatusOK)
+		return
+	}
+	if !client.Internal && token.clientID() != client.ID {
+		oauthError(c, http.StatusForbidden, "unauthorized_client", "The token was not issued to this client")
+		return
+	}
+
+	ctx := c.Request.Context()
+	if token.access != nil {
+		err = s.revocations.RevokeToken(ctx, token.access.ID, token.access.ExpiresAt.Time)
+	} else {
+		_, err = s.store.ConsumeRefreshToken(ctx, token.refresh.TokenHash)
+	}
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,123 @@
+package oauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *testServer) registerInternalClient() (string, string) {
+	body, _ := json.Marshal(gin.H{"name": "Billing", "scopes": []string{auth.ScopeProfileRead}, "internal": true})
+	w := s.do(httptest.NewRequest("POST", "/oauth/clients", bytes.NewReader(body)), "admin1", auth.RoleAdmin)
+	require.Equal(s.t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp struct {
+		Client       Client `json:"client"`
+		ClientSecret string `json:"clientSecret"`
+	}
+	require.NoError(s.t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Client.ID, resp.ClientSecret
+}
+
+func (s *testServer) inspect(path string, clientID string, secret string, token string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	form := url.Values{"token": {token}}
+	req := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientID != "" {
+		req.SetBasicAuth(clientID, secret)
+	}
+	w := s.do(req, "", "")
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestServer_Introspect(t *testing.T) {
+	s := setupTest(t)
+	clientID, secret := s.registerInternalClient()
+
+	accessToken, err := s.jwtManager.GenerateAccessToken("user123", auth.RoleClient, auth.ScopeProfileRead)
+	require.NoError(t, err)
+
+	w, resp := s.inspect("/oauth/introspect", clientID, secret, accessToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, true, resp["active"])
+	assert.Equal(t, "user123", resp["sub"])
+	assert.Equal(t, auth.ScopeProfileRead, resp["scope"])
+
+	w, resp = s.inspect("/oauth/introspect", clientID, secret, "not-a-token")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, false, resp["active"])
+
+	// Refresh tokens are not access tokens
+	_, refreshToken, err := s.jwtManager.GenerateTokenPair("user123", auth.RoleClient)
+	require.NoError(t, err)
+	_, resp = s.inspect("/oauth/introspect", clientID, secret, refreshToken)
+	assert.Equal(t, false, resp["active"])
+
+	// Client credentials are required
+	w, _ = s.inspect("/oauth/introspect", "", "", accessToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w, _ = s.inspect("/oauth/introspect", clientID, "wrong", accessToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestServer_Revoke(t *testing.T) {
+	s := setupTest(t)
+	internalID, internalSecret := s.registerInternalClient()
+
+	accessToken, err := s.jwtManager.GenerateAccessToken("user123", auth.RoleClient)
+	require.NoError(t, err)
+
+	w, _ := s.inspect("/oauth/revoke", internalID, internalSecret, accessToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	_, resp := s.inspect("/oauth/introspect", internalID, internalSecret, accessToken)
+	assert.Equal(t, false, resp["active"])
+
+	// Revoking an unknown token succeeds
+	w, _ = s.inspect("/oauth/revoke", internalID, internalSecret, "unknown")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestServer_Revoke_RefreshToken(t *testing.T) {
+	s := setupTest(t)
+	clientID, secret := s.registerClient(true)
+	code := s.authorize(clientID, auth.ScopeBookingsRead)
+
+	_, resp := s.token(url.Values{
+		"grant_type": {"authorization_code"}, "client_id": {clientID}, "client_secret": {secret},
+		"code": {code}, "redirect_uri": {testRedirectURI}, "code_verifier": {testCodeVerifier},
+	})
+	refreshToken := resp["refresh_token"].(string)
+
+	// Clients can introspect their own tokens but not first-party ones
+	_, resp = s.inspect("/oauth/introspect", clientID, secret, refreshToken)
+	assert.Equal(t, true, resp["active"])
+	assert.Equal(t, clientID, resp["client_id"])
+
+	firstParty, _ := s.jwtManager.GenerateAccessToken("user123", auth.RoleClient)
+	_, resp = s.inspect("/oauth/introspect", clientID, secret, firstParty)
+	assert.Equal(t, false, resp["active"])
+	w, _ := s.inspect("/oauth/revoke", clientID, secret, firstParty)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w, _ = s.inspect("/oauth/revoke", clientID, secret, refreshToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = s.token(url.Values{
+		"grant_type": {"refresh_token"}, "client_id": {clientID}, "client_secret": {secret},
+		"refresh_token": {refreshToken},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,189 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	clientsCollection       = "oauth_clients"
+	codesCollection         = "oauth_authorization_codes"
+	consentsCollection      = "oauth_consents"
+	refreshTokensCollection = "oauth_refresh_tokens"
+)
+
+// MongoStore is a Store backed by MongoDB. Expired codes and refresh tokens are
+// removed through TTL indexes.
+type MongoStore struct {
+	clients       *mongo.Collection
+	codes         *mongo.Collection
+	consents      *mongo.Collection
+	refreshTokens *mongo.Collection
+}
+
+// NewMongoStore creates a MongoStore and ensures its indexes exist.
+func NewMongoStore(ctx context.Context, database *mongo.Database) (*MongoStore, error) {
+	store := &MongoStore{
+		clients:       database.Collection(clientsCollection),
+		codes:         database.Collection(codesCollection),
+		consents:      database.Collection(consentsCollection),
+		refreshTokens: database.Collection(refreshTokensCollection),
+	}
+
+	ttl := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	indexes := []struct {
+		collection *mongo.Collection
+		models     []mongo.IndexModel
+	}{
+		{store.codes, []mongo.IndexModel{ttl}},
+		{store.refreshTokens, []mongo.IndexModel{ttl, {Keys: bson.D{{Key: "userId", Value: 1}, {Key: "clientId", Value: 1}}}}},
+		{store.consents, []mongo.IndexModel{{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "clientId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}}},
+	}
+	for _, index := range indexes {
+		if _, err := index.collection.Indexes().CreateMany(ctx, index.models); err != nil {
+			return nil, fmt.Errorf("failed to create indexes on %s: %w", index.collection.Name(), err)
+		}
+	}
+
+	return store, nil
+}
+
+// findOne decodes the single document matching filter, mapping a missing
+// document to ErrNotFound.
+func findOne(ctx context.Context, collection *mongo.Collection, filter interface{}, out interface{}) error {
+	err := collection.FindOne(ctx, filter).Decode(out)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *MongoStore) CreateClient(ctx context.Context, client *Client) error {
+	_, err := s.clients.InsertOne(ctx, client)
+	return err
+}
+
+func (s *MongoStore) GetClient(ctx context.Context, clientID string) (*Client, error) {
+	var client Client
+	if err := findOne(ctx, s.clients, bson.M{"_id": clientID}, &client); err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (s *MongoStore) SaveAuthorizationCode(ctx context.Context, code *AuthorizationCode) error {
+	_, err := s.codes.InsertOne(ctx, code)
+	return err
+}
+
+func (s *MongoStore) GetAuthorizationCode(ctx context.Context, codeHash string) (*AuthorizationCode, error) {
+	var code AuthorizationCode
+	if err := findOne(ctx, s.codes, bson.M{"_id": codeHash}, &code); err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (s *MongoStore) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*AuthorizationCode, error) {
+	var code AuthorizationCode
+	err := s.codes.FindOneAndUpdate(ctx,
+		bson.M{"_id": codeHash},
+		bson.M{"$set": bson.M{"used": true}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&code)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (s *MongoStore) SaveConsent(ctx context.Context, consent *Consent) error {
+	_, err := s.consents.ReplaceOne(ctx,
+		bson.M{"userId": consent.UserID, "clientId": consent.ClientID},
+		consent,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+func (s *MongoStore) GetConsent(ctx context.Context, userID string, clientID string) (*Consent, error) {
+	var consent Consent
+	if err := findOne(ctx, s.consents, bson.M{"userId": userID, "clientId": clientID}, &consent); err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
+
+func (s *MongoStore) ListConsents(ctx context.Context, userID string) ([]Consent, error) {
+	cursor, err := s.consents.Find(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return nil, err
+	}
+	var consents []Consent
+	if err := cursor.All(ctx, &consents); err != nil {
+		return nil, err
+	}
+	return consents, nil
+}
+
+func (s *MongoStore) DeleteConsent(ctx context.Context, userID string, clientID string) error {
+	res, err := s.consents.DeleteOne(ctx, bson.M{"userId": userID, "clientId": clientID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) SaveRefreshToken(ctx context.Context, token *RefreshToken) error {
+	_, err := s.refreshTokens.InsertOne(ctx, token)
+	return err
+}
+
+func (s *MongoStore) GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	var token RefreshToken
+	if err := findOne(ctx, s.refreshTokens, bson.M{"_id": tokenHash}, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (s *MongoStore) ConsumeRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	var token RefreshToken
+	err := s.refreshTokens.FindOneAndUpdate(ctx,
+		bson.M{"_id": tokenHash},
+		bson.M{"$set": bson.M{"revoked": true}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (s *MongoStore) RevokeRefreshTokens(ctx context.Context, userID string, clientID string) error {
+	_, err := s.refreshTokens.UpdateMany(ctx,
+		bson.M{"userId": userID, "clientId": clientID},
+		bson.M{"$set": bson.M{"revoked": true}},
+	)
+	return err
+}
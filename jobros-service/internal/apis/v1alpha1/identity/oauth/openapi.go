@@ -0,0 +1,113 @@
+package oauth
+
+import (
+	"net/http"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/openapi"
+)
+
+// OpenAPIRoutes describes the authorization server routes. The token,
+// introspection and revocation endpoints authenticate the client with HTTP
+// Basic or the client_id and client_secret fields of their form.
+func OpenAPIRoutes() []openapi.Route {
+	return []openapi.Route{{
+		Method:      http.MethodPost,
+		Path:        "/oauth/token",
+		OperationID: "createOAuthToken",
+		Summary:     "Exchange an authorization code or a refresh token for tokens",
+		Tag:         "oauth",
+		Request:     tokenRequest{},
+		Form:        true,
+		Response:    tokenResponse{},
+		Description: "The issued tokens",
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized},
+	}, {
+		Method:      http.MethodPost,
+		Path:        "/oauth/introspect",
+		OperationID: "introspectOAuthToken",
+		Summary:     "Introspect a token (RFC 7662)",
+		Tag:         "oauth",
+		Request:     tokenInspectionRequest{},
+		Form:        true,
+		Response:    introspectionResponse{},
+		Description: "The token, with active false when it is not valid",
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized},
+	}, {
+		Method:      http.MethodPost,
+		Path:        "/oauth/revoke",
+		OperationID: "revokeOAuthToken",
+		Summary:     "Revoke a token (RFC 7009)",
+		Tag:         "oauth",
+		Request:     tokenInspectionRequest{},
+		Form:        true,
+		Description: "The token is revoked, or was not valid",
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden},
+	}, {
+		Method:      http.MethodPost,
+		Path:        "/oauth/clients",
+		OperationID: "createOAuthClient",
+		Summary:     "Register a third-party application",
+		Tag:         "oauth",
+		Auth:        true,
+		Request:     registerClientRequest{},
+		Status:      http.StatusCreated,
+		Response: struct {
+			Client       Client `json:"client"`
+			ClientSecret string `json:"clientSecret,omitempty"`
+		}{},
+		Description: "The client, with its secret when it is confidential",
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden},
+	}, {
+		Method:      http.MethodGet,
+		Path:        "/oauth/authorize",
+		OperationID: "readOAuthAuthorization",
+		Summary:     "Describe an authorization request for the consent screen",
+		Tag:         "oauth",
+		Auth:        true,
+		Query:       authorizationRequest{},
+		Response: struct {
+			Client struct {
+				ClientID string `json:"clientId"`
+				Name     string `json:"name"`
+			} `json:"client"`
+			Scopes    []string `json:"scopes"`
+			Consented bool     `json:"consented"`
+		}{},
+		Description: "The client and the scopes requested",
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden},
+	}, {
+		Method:      http.MethodPost,
+		Path:        "/oauth/authorize",
+		OperationID: "createOAuthAuthorization",
+		Summary:     "Approve or deny an authorization request",
+		Tag:         "oauth",
+		Auth:        true,
+		Request:     authorizationRequest{},
+		Response: struct {
+			RedirectURI string `json:"redirectUri"`
+		}{},
+		Description: "The URI to redirect to, with the authorization code or an access_denied error",
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden},
+	}, {
+		Method:      http.MethodGet,
+		Path:        "/oauth/connections",
+		OperationID: "listOAuthConnections",
+		Summary:     "List the applications the user granted access to",
+		Tag:         "oauth",
+		Auth:        true,
+		Response: struct {
+			Items []Connection `json:"items"`
+		}{},
+		Description: "The connections",
+	}, {
+		Method:      http.MethodDelete,
+		Path:        "/oauth/connections/{clientId}",
+		OperationID: "deleteOAuthConnection",
+		Summary:     "Revoke the access of an application",
+		Tag:         "oauth",
+		Auth:        true,
+		Status:      http.StatusNoContent,
+		Description: "The access is revoked",
+		Errors:      []int{http.StatusNotFound},
+	}}
+}
@@ -0,0 +1,647 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+)
+
+const (
+	// AuthorizationCodeTTL is how long an authorization code can be exchanged.
+	AuthorizationCodeTTL = 10 * time.Minute
+	// RefreshTokenTTL is how long a refresh token issued to a client is valid.
+	RefreshTokenTTL = 30 * 24 * time.Hour
+
+	// Grant types of the token endpoint.
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+)
+
+// Error codes defined by RFC 6749.
+const (
+	errInvalidRequest       = "invalid_request"
+	errInvalidClient        = "invalid_client"
+	errInvalidGrant         = "invalid_grant"
+	errInvalidScope         = "invalid_scope"
+	errUnsupportedGrantType = "unsupported_grant_type"
+	errUnsupportedResponse  = "unsupported_response_type"
+	errAccessDenied         = "access_denied"
+	errServerError          = "server_error"
+)
+
+// Server is an OAuth2 authorization server letting third-party applications act
+// on behalf of users through the authorization-code flow with PKCE.
+type Server struct {
+	store       Store
+	jwtManager  *auth.JWTManager
+	revocations auth.RevocationStore
+	loginHooks  []LoginHook
+	now         func() time.Time
+}
+
+// LoginHook is called after an exchange of an authorization code or a refresh
+// token of userID, with the grant type and whether tokens were issued.
+// Exchanges of unknown codes and tokens, and server errors, are not logins.
+type LoginHook func(c *gin.Context, userID string, grantType string, succeeded bool)
+
+// NewServer creates an authorization server issuing tokens with jwtManager.
+// Access tokens revoked through the revocation endpoint are recorded in
+// revocations.
+func NewServer(store Store, jwtManager *auth.JWTManager, revocations auth.RevocationStore) *Server {
+	return &Server{
+		store:       store,
+		jwtManager:  jwtManager,
+		revocations: revocations,
+		now:         time.Now,
+	}
+}
+
+// OnLogin adds a hook called after every login through the token endpoint.
+// Hooks must be added before the routes are served.
+func (s *Server) OnLogin(hook LoginHook) {
+	s.loginHooks = append(s.loginHooks, hook)
+}
+
+// RegisterRoutes adds the authorization server endpoints to router. The token,
+// introspection and revocation endpoints authenticate clients themselves; the
+// other endpoints act for the user authenticated by authMiddleware.
+func (s *Server) RegisterRoutes(router gin.IRouter, authMiddleware gin.HandlerFunc) {
+	router.POST("/oauth/token", s.Token)
+	router.POST("/oauth/introspect", s.Introspect)
+	router.POST("/oauth/revoke", s.Revoke)
+
+	user := router.Group("/oauth", authMiddleware)
+	{
+		user.POST("/clients", auth.ScopeMiddleware(auth.ScopeUsersAdminister), s.RegisterClient)
+		user.GET("/authorize", s.GetAuthorization)
+		user.POST("/authorize", s.Authorize)
+		user.GET("/connections", s.ListConnections)
+		user.DELETE("/connections/:clientId", s.RevokeConnection)
+	}
+}
+
+// oauthError aborts the request with an RFC 6749 error response.
+func oauthError(c *gin.Context, status int, code string, description string) {
+	c.AbortWithStatusJSON(status, gin.H{"error": code, "error_description": description})
+}
+
+// serverError logs err and aborts the request with a generic error.
+func serverError(c *gin.Context, err error) {
+	glog.Errorf("oauth: %s %s failed: %v", c.Request.Method, c.FullPath(), err)
+	oauthError(c, http.StatusInternalServerError, errServerError, "Internal error")
+}
+
+type registerClientRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	RedirectURIs []string `json:"redirectUris" binding:"required_unless=Internal true,dive,url"`
+	Scopes       []string `json:"scopes" binding:"required,min=1"`
+	Confidential bool     `json:"confidential"`
+	Internal     bool     `json:"internal"`
+}
+
+// RegisterClient registers a third-party application. The client secret of a
+// confidential client is only returned in this response.
+func (s *Server) RegisterClient(c *gin.Context) {
+	claims, _ := auth.ClaimsFromContext(c)
+
+	var req registerClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		oauthError(c, http.StatusBadRequest, errInvalidRequest, err.Error())
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !knownScope(scope) {
+			oauthError(c, http.StatusBadRequest, errInvalidScope, "Unknown scope "+scope)
+			return
+		}
+	}
+
+	clientID, err := randomToken(16)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	client := &Client{
+		ID:           clientID,
+		Name:         req.Name,
+		OwnerID:      claims.UserID,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       req.Scopes,
+		Confidential: req.Confidential || req.Internal,
+		Internal:     req.Internal,
+		CreatedAt:    s.now(),
+	}
+
+	var secret string
+	if client.Confidential {
+		if secret, err = randomToken(32); err != nil {
+			serverError(c, err)
+			return
+		}
+		client.SecretHash = hashToken(secret)
+	}
+
+	if err := s.store.CreateClient(c.Request.Context(), client); err != nil {
+		serverError(c, err)
+		return
+	}
+
+	response := gin.H{"client": client}
+	if secret != "" {
+		response["clientSecret"] = secret
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// knownScope reports whether any role can be granted scope.
+func knownScope(scope string) bool {
+	for _, scopes := range auth.RoleScopes {
+		if containsAll(scopes, []string{scope}) {
+			return true
+		}
+	}
+	return false
+}
+
+type authorizationRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type" binding:"required"`
+	ClientID            string `form:"client_id" json:"client_id" binding:"required"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri" binding:"required"`
+	Scope               string `form:"scope" json:"scope" binding:"required"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge" binding:"required"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method" binding:"required"`
+	Approve             bool   `form:"approve" json:"approve"`
+}
+
+// validateAuthorization checks an authorization request and returns the client
+// and the requested scopes. It aborts the request when it is invalid.
+func (s *Server) validateAuthorization(c *gin.Context, req *authorizationRequest, claims *auth.JWTClaims) (*Client, []string, bool) {
+	if claims.ClientID != "" {
+		oauthError(c, http.StatusForbidden, errAccessDenied, "Applications cannot authorize other applications")
+		return nil, nil, false
+	}
+	if req.ResponseType != "code" {
+		oauthError(c, http.StatusBadRequest, errUnsupportedResponse, "Only the code response type is supported")
+		return nil, nil, false
+	}
+	if req.CodeChallengeMethod != codeChallengeMethodS256 {
+		oauthError(c, http.StatusBadRequest, errInvalidRequest, "code_challenge_method must be S256")
+		return nil, nil, false
+	}
+
+	client, err := s.store.GetClient(c.Request.Context(), req.ClientID)
+	if errors.Is(err, ErrNotFound) {
+		oauthError(c, http.StatusBadRequest, errInvalidClient, "Unknown client")
+		return nil, nil, false
+	}
+	if err != nil {
+		serverError(c, err)
+		return nil, nil, false
+	}
+	if !client.AllowsRedirectURI(req.RedirectURI) {
+		oauthError(c, http.StatusBadRequest, errInvalidRequest, "redirect_uri is not registered for the client")
+		return nil, nil, false
+	}
+
+	scopes := parseScope(req.Scope)
+	if len(scopes) == 0 || !containsAll(client.Scopes, scopes) {
+		oauthError(c, http.StatusBadRequest, errInvalidScope, "The client may not request these scopes")
+		return nil, nil, false
+	}
+	if _, err := auth.ResolveScopes(claims.Role, scopes); err != nil {
+		oauthError(c, http.StatusForbidden, errInvalidScope, err.Error())
+		return nil, nil, false
+	}
+
+	return client, scopes, true
+}
+
+// GetAuthorization describes an authorization request so that the app can show
+// the consent screen.
+func (s *Server) GetAuthorization(c *gin.Context) {
+	claims, _ := auth.ClaimsFromContext(c)
+
+	var req authorizationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		oauthError(c, http.StatusBadRequest, errInvalidRequest, err.Error())
+		return
+	}
+	client, scopes, ok := s.validateAuthorization(c, &req, claims)
+	if !ok {
+		return
+	}
+
+	consented := false
+	consent, err := s.store.GetConsent(c.Request.Context(), claims.UserID, client.ID)
+	switch {
+	case err == nil:
+		consented = containsAll(consent.Scopes, scopes)
+	case !errors.Is(err, ErrNotFound):
+		serverError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"client":    gin.H{"clientId": client.ID, "name": client.Name},
+		"scopes":    scopes,
+		"consented": consented,
+	})
+}
+
+// Authorize records the decision of the user and returns the URI the app must
+// redirect to, carrying either an authorization code or an access_denied error.
+func (s *Server) Authorize(c *gin.Context) {
+	claims, _ := auth.ClaimsFromContext(c)
+
+	var req authorizationRequest
+	if err := c.ShouldBind(&req); err != nil {
+		oauthError(c, http.StatusBadRequest, errInvalidRequest, err.Error())
+		return
+	}
+	client, scopes, ok := s.validateAuthorization(c, &req, claims)
+	if !ok {
+		return
+	}
+
+	redirect := url.Values{}
+	if req.State != "" {
+		redirect.Set("state", req.State)
+	}
+	if !req.Approve {
+		redirect.Set("error", errAccessDenied)
+		c.JSON(http.StatusOK, gin.H{"redirectUri": withQuery(req.RedirectURI, redirect)})
+		return
+	}
+
+	ctx := c.Request.Context()
+	now := s.now()
+	consent, err := s.store.GetConsent(ctx, claims.UserID, client.ID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		consent = &Consent{UserID: claims.UserID, ClientID: client.ID, CreatedAt: now}
+	case err != nil:
+		serverError(c, err)
+		return
+	}
+	consent.Scopes = mergeScopes(consent.Scopes, scopes)
+	consent.UpdatedAt = now
+	if err := s.store.SaveConsent(ctx, consent); err != nil {
+		serverError(c, err)
+		return
+	}
+
+	code, err := randomToken(32)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	err = s.store.SaveAuthorizationCode(ctx, &AuthorizationCode{
+		CodeHash:            hashToken(code),
+		ClientID:            client.ID,
+		UserID:              claims.UserID,
+		Role:                claims.Role,
+		RedirectURI:         req.RedirectURI,
+		Scopes:              scopes,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		ExpiresAt:           now.Add(AuthorizationCodeTTL),
+	})
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	redirect.Set("code", code)
+	c.JSON(http.StatusOK, gin.H{"redirectUri": withQuery(req.RedirectURI, redirect)})
+}
+
+// withQuery appends values to the query of uri.
+func withQuery(uri string, values url.Values) string {
+	separator := "?"
+	if strings.Contains(uri, "?") {
+		separator = "&"
+	}
+	return uri + separator + values.Encode()
+}
+
+type tokenRequest struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// Token exchanges an authorization code or a refresh token for new tokens.
+func (s *Server) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	var req tokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		oauthError(c, http.StatusBadRequest, errInvalidRequest, err.Error())
+		return
+	}
+
+	client, ok := s.authenticateClient(c, req.ClientID, req.ClientSecret)
+	if !ok {
+		return
+	}
+
+	var userID string
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode:
+		userID = s.exchangeAuthorizationCode(c, client, &req)
+	case GrantTypeRefreshToken:
+		userID = s.exchangeRefreshToken(c, client, &req)
+	default:
+		oauthError(c, http.StatusBadRequest, errUnsupportedGrantType, "Unsupported grant_type")
+	}
+
+	if userID == "" || c.Writer.Status() >= http.StatusInternalServerError {
+		return
+	}
+	for _, hook := range s.loginHooks {
+		hook(c, userID, req.GrantType, c.Writer.Status() == http.StatusOK)
+	}
+}
+
+// authenticateClient identifies the client of a token request. Confidential
+// clients must present their secret, with HTTP Basic or in the form.
+func (s *Server) authenticateClient(c *gin.Context, formClientID string, formSecret string) (*Client, bool) {
+	clientID, secret, basic := c.Request.BasicAuth()
+	if !basic {
+		clientID, secret = formClientID, formSecret
+	}
+	if clientID == "" {
+		oauthError(c, http.StatusUnauthorized, errInvalidClient, "Client authentication is required")
+		return nil, false
+	}
+
+	client, err := s.store.GetClient(c.Request.Context(), clientID)
+	if errors.Is(err, ErrNotFound) {
+		oauthError(c, http.StatusUnauthorized, errInvalidClient, "Client authentication failed")
+		return nil, false
+	}
+	if err != nil {
+		serverError(c, err)
+		return nil, false
+	}
+	if client.Confidential && !verifySecret(secret, client.SecretHash) {
+		oauthError(c, http.StatusUnauthorized, errInvalidClient, "Client authentication failed")
+		return nil, false
+	}
+
+	return client, true
+}
+
+// exchangeAuthorizationCode answers the exchange of an authorization code and
+// returns the user of the code, empty for unknown codes.
+func (s *Server) exchangeAuthorizationCode(c *gin.Context, client *Client, req *tokenRequest) string {
+	ctx := c.Request.Context()
+
+	// As for refresh tokens, the code is only consumed once presented by its
+	// client: consuming it for another client would make the exchange of the
+	// right one look like a replay.
+	codeHash := hashToken(req.Code)
+	code, err := s.store.GetAuthorizationCode(ctx, codeHash)
+	if errors.Is(err, ErrNotFound) {
+		oauthError(c, http.StatusBadRequest, errInvalidGrant, "Invalid authorization code")
+		return ""
+	}
+	if err != nil {
+		serverError(c, err)
+		return ""
+	}
+	userID := code.UserID
+	if code.ClientID != client.ID || code.RedirectURI != req.RedirectURI || s.now().After(code.ExpiresAt) {
+		oauthError(c, http.StatusBadRequest, errInvalidGrant, "Invalid authorization code")
+		return userID
+	}
+
+	if !code.Used {
+		// Consuming tells whether a concurrent request used the code first.
+		code, err = s.store.ConsumeAuthorizationCode(ctx, codeHash)
+		if errors.Is(err, ErrNotFound) {
+			oauthError(c, http.StatusBadRequest, errInvalidGrant, "Invalid authorization code")
+			return userID
+		}
+		if err != nil {
+			serverError(c, err)
+			return userID
+		}
+	}
+	if code.Used {
+		// A replayed code may have been stolen: revoke what was issued with it.
+		if err := s.store.RevokeRefreshTokens(ctx, code.UserID, code.ClientID); err != nil {
+			glog.Errorf("oauth: failed to revoke tokens after code replay: %v", err)
+		}
+		oauthError(c, http.StatusBadRequest, errInvalidGrant, "Authorization code was already used")
+		return userID
+	}
+	if !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge, code.CodeChallengeMethod) {
+		oauthError(c, http.StatusBadRequest, errInvalidGrant, "Invalid code_verifier")
+		return userID
+	}
+
+	s.issueTokens(c, client, code.UserID, code.Role, code.Scopes, nil)
+	return userID
+}
+
+// exchangeRefreshToken answers the exchange of a refresh token and returns the
+// user of the token, empty for unknown tokens.
+func (s *Server) exchangeRefreshToken(c *gin.Context, client *Client, req *tokenRequest) string {
+	ctx := c.Request.Context()
+
+	// The token is only consumed once presented by its client: consuming the
+	// leaked token of another client would make the next refresh of that
+	// client look like a reuse, revoking all its tokens.
+	tokenHash := hashToken(req.RefreshToken)
+	token, err := s.store.GetRefreshToken(ctx, tokenHash)
+	if errors.Is(err, ErrNotFound) {
+		oauthError(c, http.StatusBadRequest, errInvalidGrant, "Invalid refresh token")
+		return ""
+	}
+	if err != nil {
+		serverError(c, err)
+		return ""
+	}
+	userID := token.UserID
+	if token.ClientID != client.ID || s.now().After(token.ExpiresAt) {
+		oauthError(c, http.StatusBadRequest, errInvalidGrant, "Invalid refresh token")
+		return userID
+	}
+
+	if !token.Revoked {
+		// Consuming tells whether a concurrent request used the token first.
+		token, err = s.store.ConsumeRefreshToken(ctx, tokenHash)
+		if errors.Is(err, ErrNotFound) {
+			oauthError(c, http.StatusBadRequest, errInvalidGrant, "Invalid refresh token")
+			return userID
+		}
+		if err != nil {
+			serverError(c, err)
+			return userID
+		}
+	}
+	if token.Revoked {
+		// Refresh tokens rotate on every use, so reuse means one leaked.
+		if err := s.store.RevokeRefreshTokens(ctx, token.UserID, token.ClientID); err != nil {
+			glog.Errorf("oauth: failed to revoke tokens after refresh token reuse: %v", err)
+		}
+		oauthError(c, http.StatusBadRequest, errInvalidGrant, "Invalid refresh token")
+		return userID
+	}
+
+	accessScopes := token.Scopes
+	if requested := parseScope(req.Scope); len(requested) > 0 {
+		if !containsAll(token.Scopes, requested) {
+			oauthError(c, http.StatusBadRequest, errInvalidScope, "Requested scopes exceed the original grant")
+			return userID
+		}
+		accessScopes = requested
+	}
+
+	s.issueTokens(c, client, token.UserID, token.Role, token.Scopes, accessScopes)
+	return userID
+}
+
+// tokenResponse is the response of the token endpoint, as defined by RFC 6749.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// issueTokens responds with an access token and a new refresh token, provided
+// the user has not withdrawn consent. The access token is limited to
+// accessScopes when given.
+func (s *Server) issueTokens(c *gin.Context, client *Client, userID string, role string, scopes []string, accessScopes []string) {
+	ctx := c.Request.Context()
+
+	consent, err := s.store.GetConsent(ctx, userID, client.ID)
+	if errors.Is(err, ErrNotFound) {
+		oauthError(c, http.StatusBadRequest, errInvalidGrant, "The user revoked access for this client")
+		return
+	}
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	if !containsAll(consent.Scopes, scopes) {
+		oauthError(c, http.StatusBadRequest, errInvalidGrant, "The user revoked some of the granted scopes")
+		return
+	}
+	if accessScopes == nil {
+		accessScopes = scopes
+	}
+
+	accessToken, err := s.jwtManager.GenerateDelegatedAccessToken(userID, role, client.ID, accessScopes...)
+	if err != nil {
+		oauthError(c, http.StatusBadRequest, errInvalidScope, err.Error())
+		return
+	}
+
+	refreshToken, err := s.newRefreshToken(ctx, client.ID, userID, role, scopes)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.jwtManager.AccessTokenTTL().Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        strings.Join(accessScopes, " "),
+	})
+}
+
+func (s *Server) newRefreshToken(ctx context.Context, clientID string, userID string, role string, scopes []string) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	err = s.store.SaveRefreshToken(ctx, &RefreshToken{
+		TokenHash: hashToken(token),
+		ClientID:  clientID,
+		UserID:    userID,
+		Role:      role,
+		Scopes:    scopes,
+		ExpiresAt: s.now().Add(RefreshTokenTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ListConnections lists the applications the user granted access to.
+func (s *Server) ListConnections(c *gin.Context) {
+	claims, _ := auth.ClaimsFromContext(c)
+	ctx := c.Request.Context()
+
+	consents, err := s.store.ListConsents(ctx, claims.UserID)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	connections := make([]Connection, 0, len(consents))
+	for _, consent := range consents {
+		connection := Connection{
+			ClientID:  consent.ClientID,
+			Scopes:    consent.Scopes,
+			GrantedAt: consent.CreatedAt,
+			UpdatedAt: consent.UpdatedAt,
+		}
+		client, err := s.store.GetClient(ctx, consent.ClientID)
+		switch {
+		case err == nil:
+			connection.Name = client.Name
+		case !errors.Is(err, ErrNotFound):
+			serverError(c, err)
+			return
+		}
+		connections = append(connections, connection)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": connections})
+}
+
+// RevokeConnection withdraws the consent of the user for an application and
+// revokes its refresh tokens. Access tokens already issued expire on their own.
+func (s *Server) RevokeConnection(c *gin.Context) {
+	claims, _ := auth.ClaimsFromContext(c)
+	ctx := c.Request.Context()
+	clientID := c.Param("clientId")
+
+	err := s.store.DeleteConsent(ctx, claims.UserID, clientID)
+	if errors.Is(err, ErrNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
+		return
+	}
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	if err := s.store.RevokeRefreshTokens(ctx, claims.UserID, clientID); err != nil {
+		serverError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
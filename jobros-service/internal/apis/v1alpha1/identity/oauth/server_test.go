@@ -0,0 +1,299 @@
+package oauth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRedirectURI  = "https://scheduler.example.com/callback"
+	testCodeVerifier = "dBjftJeZ4CVP-mJ92K9b2WqyWL0_E3rMM1O-Ntq8zQc"
+)
+
+type testServer struct {
+	t          *testing.T
+	router     *gin.Engine
+	jwtManager *auth.JWTManager
+	store      *MemoryStore
+}
+
+func setupTest(t *testing.T) *testServer {
+	gin.SetMode(gin.TestMode)
+	jwtManager, err := auth.NewJWTManager(auth.WithSecret([]byte("test-secret-key")))
+	require.NoError(t, err)
+
+	store := NewMemoryStore()
+	router := gin.New()
+	NewServer(store, jwtManager, auth.NewMemoryRevocationStore()).RegisterRoutes(router, auth.AuthMiddleware(jwtManager))
+
+	return &testServer{t: t, router: router, jwtManager: jwtManager, store: store}
+}
+
+func (s *testServer) do(req *http.Request, userID string, role string) *httptest.ResponseRecorder {
+	if userID != "" {
+		token, err := s.jwtManager.GenerateAccessToken(userID, role)
+		require.NoError(s.t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *testServer) registerClient(confidential bool) (string, string) {
+	body, _ := json.Marshal(gin.H{
+		"name":         "Scheduler",
+		"redirectUris": []string{testRedirectURI},
+		"scopes":       []string{auth.ScopeBookingsRead, auth.ScopeServicesRead},
+		"confidential": confidential,
+	})
+	w := s.do(httptest.NewRequest("POST", "/oauth/clients", bytes.NewReader(body)), "admin1", auth.RoleAdmin)
+	require.Equal(s.t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp struct {
+		Client       Client `json:"client"`
+		ClientSecret string `json:"clientSecret"`
+	}
+	require.NoError(s.t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Client.ID, resp.ClientSecret
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (s *testServer) authorize(clientID string, scope string) string {
+	body, _ := json.Marshal(gin.H{
+		"response_type":         "code",
+		"client_id":             clientID,
+		"redirect_uri":          testRedirectURI,
+		"scope":                 scope,
+		"state":                 "xyz",
+		"code_challenge":        codeChallenge(testCodeVerifier),
+		"code_challenge_method": "S256",
+		"approve":               true,
+	})
+	req := httptest.NewRequest("POST", "/oauth/authorize", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := s.do(req, "provider1", auth.RoleProvider)
+	require.Equal(s.t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		RedirectURI string `json:"redirectUri"`
+	}
+	require.NoError(s.t, json.Unmarshal(w.Body.Bytes(), &resp))
+	redirect, err := url.Parse(resp.RedirectURI)
+	require.NoError(s.t, err)
+	assert.Equal(s.t, "xyz", redirect.Query().Get("state"))
+	return redirect.Query().Get("code")
+}
+
+func (s *testServer) token(form url.Values) (*httptest.ResponseRecorder, map[string]interface{}) {
+	req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := s.do(req, "", "")
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestServer_AuthorizationCodeFlow(t *testing.T) {
+	s := setupTest(t)
+	clientID, _ := s.registerClient(false)
+	code := s.authorize(clientID, auth.ScopeBookingsRead)
+
+	// A wrong verifier is rejected, and burns the code
+	w, resp := s.token(url.Values{
+		"grant_type": {"authorization_code"}, "client_id": {clientID}, "code": {code},
+		"redirect_uri": {testRedirectURI}, "code_verifier": {"wrong"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, errInvalidGrant, resp["error"])
+
+	code = s.authorize(clientID, auth.ScopeBookingsRead)
+	form := url.Values{
+		"grant_type": {"authorization_code"}, "client_id": {clientID}, "code": {code},
+		"redirect_uri": {testRedirectURI}, "code_verifier": {testCodeVerifier},
+	}
+	w, resp = s.token(form)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, auth.ScopeBookingsRead, resp["scope"])
+
+	claims, err := s.jwtManager.GetTokenClaims(resp["access_token"].(string), auth.TokenTypeAccess)
+	require.NoError(t, err)
+	assert.Equal(t, "provider1", claims.UserID)
+	assert.Equal(t, clientID, claims.ClientID)
+	assert.Equal(t, []string{auth.ScopeBookingsRead}, claims.Scopes)
+
+	// Codes are single use
+	w, _ = s.token(form)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServer_RefreshTokenRotation(t *testing.T) {
+	s := setupTest(t)
+	clientID, secret := s.registerClient(true)
+	code := s.authorize(clientID, auth.ScopeBookingsRead+" "+auth.ScopeServicesRead)
+
+	exchange := url.Values{
+		"grant_type": {"authorization_code"}, "client_id": {clientID}, "code": {code},
+		"redirect_uri": {testRedirectURI}, "code_verifier": {testCodeVerifier},
+	}
+	// Confidential clients must authenticate
+	w, resp := s.token(exchange)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, errInvalidClient, resp["error"])
+
+	exchange.Set("client_secret", secret)
+	w, resp = s.token(exchange)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	refreshToken := resp["refresh_token"].(string)
+
+	refresh := url.Values{
+		"grant_type": {"refresh_token"}, "client_id": {clientID}, "client_secret": {secret},
+		"refresh_token": {refreshToken}, "scope": {auth.ScopeServicesRead},
+	}
+	w, resp = s.token(refresh)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, auth.ScopeServicesRead, resp["scope"])
+	rotated := resp["refresh_token"].(string)
+	assert.NotEqual(t, refreshToken, rotated)
+
+	// Reusing a rotated refresh token revokes the whole grant
+	w, _ = s.token(refresh)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	refresh.Set("refresh_token", rotated)
+	w, _ = s.token(refresh)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServer_RefreshToken_OtherClient(t *testing.T) {
+	s := setupTest(t)
+	clientID, secret := s.registerClient(true)
+	otherID, otherSecret := s.registerClient(true)
+	code := s.authorize(clientID, auth.ScopeBookingsRead)
+
+	w, resp := s.token(url.Values{
+		"grant_type": {"authorization_code"}, "client_id": {clientID}, "client_secret": {secret}, "code": {code},
+		"redirect_uri": {testRedirectURI}, "code_verifier": {testCodeVerifier},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	refreshToken := resp["refresh_token"].(string)
+
+	// Another client holding the leaked token is refused without burning it
+	w, _ = s.token(url.Values{
+		"grant_type": {"refresh_token"}, "client_id": {otherID}, "client_secret": {otherSecret},
+		"refresh_token": {refreshToken},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = s.token(url.Values{
+		"grant_type": {"refresh_token"}, "client_id": {clientID}, "client_secret": {secret},
+		"refresh_token": {refreshToken},
+	})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestServer_AuthorizationCode_OtherClient(t *testing.T) {
+	s := setupTest(t)
+	clientID, secret := s.registerClient(true)
+	otherID, otherSecret := s.registerClient(true)
+	code := s.authorize(clientID, auth.ScopeBookingsRead)
+
+	// Another client holding the intercepted code is refused without burning it
+	w, resp := s.token(url.Values{
+		"grant_type": {"authorization_code"}, "client_id": {otherID}, "client_secret": {otherSecret}, "code": {code},
+		"redirect_uri": {testRedirectURI}, "code_verifier": {testCodeVerifier},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, errInvalidGrant, resp["error"])
+
+	w, resp = s.token(url.Values{
+		"grant_type": {"authorization_code"}, "client_id": {clientID}, "client_secret": {secret}, "code": {code},
+		"redirect_uri": {testRedirectURI}, "code_verifier": {testCodeVerifier},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Nothing was revoked as a replay
+	w, _ = s.token(url.Values{
+		"grant_type": {"refresh_token"}, "client_id": {clientID}, "client_secret": {secret},
+		"refresh_token": {resp["refresh_token"].(string)},
+	})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestServer_Authorize_InvalidRequests(t *testing.T) {
+	s := setupTest(t)
+	clientID, _ := s.registerClient(false)
+
+	query := func(overrides map[string]string) string {
+		values := url.Values{
+			"response_type": {"code"}, "client_id": {clientID}, "redirect_uri": {testRedirectURI},
+			"scope": {auth.ScopeBookingsRead}, "code_challenge": {codeChallenge(testCodeVerifier)},
+			"code_challenge_method": {"S256"},
+		}
+		for k, v := range overrides {
+			values.Set(k, v)
+		}
+		return "/oauth/authorize?" + values.Encode()
+	}
+
+	w := s.do(httptest.NewRequest("GET", query(nil), nil), "provider1", auth.RoleProvider)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"consented":false`)
+
+	tests := map[string]map[string]string{
+		"unregistered redirect": {"redirect_uri": "https://evil.example.com/callback"},
+		"scope not registered":  {"scope": auth.ScopePayoutsRead},
+		"plain PKCE":            {"code_challenge_method": "plain"},
+		"unknown client":        {"client_id": "unknown"},
+	}
+	for name, overrides := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := s.do(httptest.NewRequest("GET", query(overrides), nil), "provider1", auth.RoleProvider)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+
+	// Only admins can register clients
+	w = s.do(httptest.NewRequest("POST", "/oauth/clients", strings.NewReader(`{}`)), "provider1", auth.RoleProvider)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestServer_Connections(t *testing.T) {
+	s := setupTest(t)
+	clientID, _ := s.registerClient(false)
+	code := s.authorize(clientID, auth.ScopeBookingsRead)
+
+	w := s.do(httptest.NewRequest("GET", "/oauth/connections", nil), "provider1", auth.RoleProvider)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Scheduler"`)
+
+	w = s.do(httptest.NewRequest("DELETE", fmt.Sprintf("/oauth/connections/%s", clientID), nil), "provider1", auth.RoleProvider)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// Codes issued before the consent was withdrawn can no longer be exchanged
+	w, _ = s.token(url.Values{
+		"grant_type": {"authorization_code"}, "client_id": {clientID}, "code": {code},
+		"redirect_uri": {testRedirectURI}, "code_verifier": {testCodeVerifier},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = s.do(httptest.NewRequest("DELETE", fmt.Sprintf("/oauth/connections/%s", clientID), nil), "provider1", auth.RoleProvider)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,188 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNotFound is returned by a Store when the requested record does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Store persists the state of the authorization server.
+type Store interface {
+	CreateClient(ctx context.Context, client *Client) error
+	GetClient(ctx context.Context, clientID string) (*Client, error)
+
+	SaveAuthorizationCode(ctx context.Context, code *AuthorizationCode) error
+	GetAuthorizationCode(ctx context.Context, codeHash string) (*AuthorizationCode, error)
+	// ConsumeAuthorizationCode marks the code as used and returns it as it was
+	// before, so that a replayed code can be detected.
+	ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*AuthorizationCode, error)
+
+	// SaveConsent creates or replaces the consent of a user for a client.
+	SaveConsent(ctx context.Context, consent *Consent) error
+	GetConsent(ctx context.Context, userID string, clientID string) (*Consent, error)
+	ListConsents(ctx context.Context, userID string) ([]Consent, error)
+	DeleteConsent(ctx context.Context, userID string, clientID string) error
+
+	SaveRefreshToken(ctx context.Context, token *RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// ConsumeRefreshToken revokes the token and returns it as it was before.
+	ConsumeRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// RevokeRefreshTokens revokes every refresh token of a user for a client.
+	RevokeRefreshTokens(ctx context.Context, userID string, clientID string) error
+}
+
+// MemoryStore is a Store keeping everything in memory, for tests and local
+// development.
+type MemoryStore struct {
+	mu            sync.Mutex
+	clients       map[string]Client
+	codes         map[string]AuthorizationCode
+	consents      map[string]Consent
+	refreshTokens map[string]RefreshToken
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		clients:       make(map[string]Client),
+		codes:         make(map[string]AuthorizationCode),
+		consents:      make(map[string]Consent),
+		refreshTokens: make(map[string]RefreshToken),
+	}
+}
+
+func consentKey(userID string, clientID string) string {
+	return userID + "/" + clientID
+}
+
+func (s *MemoryStore) CreateClient(_ context.Context, client *Client) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[client.ID] = *client
+	return nil
+}
+
+func (s *MemoryStore) GetClient(_ context.Context, clientID string) (*Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	client, ok := s.clients[clientID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &client, nil
+}
+
+func (s *MemoryStore) SaveAuthorizationCode(_ context.Context, code *AuthorizationCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes[code.CodeHash] = *code
+	return nil
+}
+
+func (s *MemoryStore) GetAuthorizationCode(_ context.Context, codeHash string) (*AuthorizationCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code, ok := s.codes[codeHash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &code, nil
+}
+
+func (s *MemoryStore) ConsumeAuthorizationCode(_ context.Context, codeHash string) (*AuthorizationCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code, ok := s.codes[codeHash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	used := code
+	used.Used = true
+	s.codes[codeHash] = used
+	return &code, nil
+}
+
+func (s *MemoryStore) SaveConsent(_ context.Context, consent *Consent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.consents[consentKey(consent.UserID, consent.ClientID)] = *consent
+	return nil
+}
+
+func (s *MemoryStore) GetConsent(_ context.Context, userID string, clientID string) (*Consent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	consent, ok := s.consents[consentKey(userID, clientID)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &consent, nil
+}
+
+func (s *MemoryStore) ListConsents(_ context.Context, userID string) ([]Consent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var consents []Consent
+	for _, consent := range s.consents {
+		if consent.UserID == userID {
+			consents = append(consents, consent)
+		}
+	}
+	return consents, nil
+}
+
+func (s *MemoryStore) DeleteConsent(_ context.Context, userID string, clientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := consentKey(userID, clientID)
+	if _, ok := s.consents[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.consents, key)
+	return nil
+}
+
+func (s *MemoryStore) SaveRefreshToken(_ context.Context, token *RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshTokens[token.TokenHash] = *token
+	return nil
+}
+
+func (s *MemoryStore) GetRefreshToken(_ context.Context, tokenHash string) (*RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.refreshTokens[tokenHash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &token, nil
+}
+
+func (s *MemoryStore) ConsumeRefreshToken(_ context.Context, tokenHash string) (*RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.refreshTokens[tokenHash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	revoked := token
+	revoked.Revoked = true
+	s.refreshTokens[tokenHash] = revoked
+	return &token, nil
+}
+
+func (s *MemoryStore) RevokeRefreshTokens(_ context.Context, userID string, clientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, token := range s.refreshTokens {
+		if token.UserID == userID && token.ClientID == clientID {
+			token.Revoked = true
+			s.refreshTokens[hash] = token
+		}
+	}
+	return nil
+}
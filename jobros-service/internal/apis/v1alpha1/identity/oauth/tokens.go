@@ -0,0 +1,77 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const codeChallengeMethodS256 = "S256"
+
+// randomToken returns a URL-safe random string carrying n bytes of entropy.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hash under which codes, refresh tokens and client
+// secrets are stored. They are random, so a fast hash is sufficient.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// verifySecret compares a presented secret with a stored hash in constant time.
+func verifySecret(secret string, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(hash)) == 1
+}
+
+// verifyCodeChallenge checks a PKCE code verifier against the challenge sent
+// with the authorization request. Only the S256 method is supported.
+func verifyCodeChallenge(verifier string, challenge string, method string) bool {
+	if method != codeChallengeMethodS256 || verifier == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// parseScope splits a space-delimited scope parameter.
+func parseScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// containsAll reports whether every scope of subset is in set.
+func containsAll(set []string, subset []string) bool {
+	allowed := make(map[string]bool, len(set))
+	for _, scope := range set {
+		allowed[scope] = true
+	}
+	for _, scope := range subset {
+		if !allowed[scope] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeScopes returns the union of a and b, keeping the order of first appearance.
+func mergeScopes(a []string, b []string) []string {
+	seen := make(map[string]bool)
+	var merged []string
+	for _, scope := range append(append([]string(nil), a...), b...) {
+		if !seen[scope] {
+			seen[scope] = true
+			merged = append(merged, scope)
+		}
+	}
+	return merged
+}
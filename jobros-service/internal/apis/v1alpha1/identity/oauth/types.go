@@ -0,0 +1,77 @@
+package oauth
+
+import (
+	"time"
+)
+
+// Client is a third-party application registered to act on behalf of users.
+type Client struct {
+	ID           string   `json:"clientId" bson:"_id"`
+	Name         string   `json:"name" bson:"name"`
+	OwnerID      string   `json:"ownerId" bson:"ownerId"`
+	RedirectURIs []string `json:"redirectUris" bson:"redirectUris"`
+	Scopes       []string `json:"scopes" bson:"scopes"`
+	Confidential bool     `json:"confidential" bson:"confidential"`
+	// Internal clients are other Jobros services, allowed to introspect and
+	// revoke tokens issued to anyone.
+	Internal   bool      `json:"internal" bson:"internal"`
+	SecretHash string    `json:"-" bson:"secretHash,omitempty"`
+	CreatedAt  time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// AllowsRedirectURI reports whether uri is one of the registered redirect URIs.
+// Redirect URIs are compared exactly, as required for the authorization-code flow.
+func (c *Client) AllowsRedirectURI(uri string) bool {
+	for _, registered := range c.RedirectURIs {
+		if registered == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizationCode is a short-lived code exchanged by a client for tokens. Only
+// the hash of the code is stored.
+type AuthorizationCode struct {
+	CodeHash            string    `bson:"_id"`
+	ClientID            string    `bson:"clientId"`
+	UserID              string    `bson:"userId"`
+	Role                string    `bson:"role"`
+	RedirectURI         string    `bson:"redirectUri"`
+	Scopes              []string  `bson:"scopes"`
+	CodeChallenge       string    `bson:"codeChallenge"`
+	CodeChallengeMethod string    `bson:"codeChallengeMethod"`
+	ExpiresAt           time.Time `bson:"expiresAt"`
+	Used                bool      `bson:"used"`
+}
+
+// Consent records the scopes a user granted to a client.
+type Consent struct {
+	UserID    string    `json:"userId" bson:"userId"`
+	ClientID  string    `json:"clientId" bson:"clientId"`
+	Scopes    []string  `json:"scopes" bson:"scopes"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// RefreshToken is an opaque token letting a client obtain new access tokens.
+// Only the hash of the token is stored and every use rotates it.
+type RefreshToken struct {
+	TokenHash string    `bson:"_id"`
+	ClientID  string    `bson:"clientId"`
+	UserID    string    `bson:"userId"`
+	Role      string    `bson:"role"`
+	Scopes    []string  `bson:"scopes"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+	Revoked   bool      `bson:"revoked"`
+}
+
+// Connection is a client the user consented to, as shown on the user's
+// connected apps page.
+type Connection struct {
+	ClientID  string    `json:"clientId"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	GrantedAt time.Time `json:"grantedAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,204 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// hashPrefixLength is the length of the SHA-1 prefix used for range lookups.
+const hashPrefixLength = 5
+
+// BreachedChecker reports whether a password appears in known data breaches.
+type BreachedChecker interface {
+	IsBreached(ctx context.Context, password string) (bool, error)
+}
+
+// BreachedCorpus is a local corpus of breached password hashes queried by hash
+// prefix, following the k-anonymity model of the Pwned Passwords range API: a
+// lookup only needs the first five characters of the SHA-1 of the password.
+//
+// The corpus file holds one uppercase SHA-1 hash and its breach count per line,
+// sorted by hash, in the format of the Pwned Passwords downloads ordered by
+// hash:
+//
+//	5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:10434004
+//
+// The file is not loaded: lookups binary search it on disk, so that corpora of
+// tens of gigabytes need no memory.
+type BreachedCorpus struct {
+	file     io.ReaderAt
+	size     int64
+	minCount int
+	closer   io.Closer
+}
+
+// OpenBreachedCorpus opens the breached password corpus at path. Hashes seen
+// fewer than minCount times are not reported as breached.
+func OpenBreachedCorpus(path string, minCount int) (*BreachedCorpus, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password corpus: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open breached password corpus: %w", err)
+	}
+
+	corpus := NewBreachedCorpus(file, info.Size(), minCount)
+	corpus.closer = file
+	// Catch files in another format now rather than at the first lookup.
+	if _, _, err := corpus.readLine(0); err != nil && err != io.EOF {
+		file.Close()
+		return nil, err
+	}
+	return corpus, nil
+}
+
+// NewBreachedCorpus returns the corpus of size bytes read from r, in the
+// format of OpenBreachedCorpus.
+func NewBreachedCorpus(r io.ReaderAt, size int64, minCount int) *BreachedCorpus {
+	return &BreachedCorpus{file: r, size: size, minCount: minCount}
+}
+
+// Close closes the corpus file opened by OpenBreachedCorpus.
+func (c *BreachedCorpus) Close() error {
+	if c.closer == nil {
+		return nil
+	}
+	return c.closer.Close()
+}
+
+// corpusEntry is a line of the corpus.
+type corpusEntry struct {
+	hash  string
+	count int
+}
+
+// readLine reads the line starting at offset, returning the offset of the
+// next line. It returns io.EOF at the end of the file.
+func (c *BreachedCorpus) readLine(offset int64) (corpusEntry, int64, error) {
+	if offset >= c.size {
+		return corpusEntry{}, c.size, io.EOF
+	}
+	// Lines are a hash, a colon and a count: a buffer of 64 bytes holds one.
+	reader := bufio.NewReaderSize(io.NewSectionReader(c.file, offset, c.size-offset), 64)
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return corpusEntry{}, 0, fmt.Errorf("failed to read breached password corpus: %w", err)
+	}
+	next := offset + int64(len(line))
+
+	text := strings.TrimSpace(line)
+	if text == "" {
+		return corpusEntry{}, next, nil
+	}
+	hash, countText, _ := strings.Cut(text, ":")
+	entry := corpusEntry{hash: strings.ToUpper(hash), count: -1}
+	if len(entry.hash) != sha1.Size*2 {
+		return corpusEntry{}, 0, fmt.Errorf("invalid breached password corpus line at byte %d: expected a SHA-1 hash", offset)
+	}
+	if countText != "" {
+		entry.count, err = strconv.Atoi(countText)
+		if err != nil {
+			return corpusEntry{}, 0, fmt.Errorf("invalid breached password corpus line at byte %d: %w", offset, err)
+		}
+	}
+	return entry, next, nil
+}
+
+// nextLine returns the offset of the first line starting at or after offset.
+func (c *BreachedCorpus) nextLine(offset int64) (int64, error) {
+	if offset == 0 {
+		return 0, nil
+	}
+	reader := bufio.NewReader(io.NewSectionReader(c.file, offset-1, c.size-offset+1))
+	skipped, err := reader.ReadString('\n')
+	if err == io.EOF {
+		return c.size, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read breached password corpus: %w", err)
+	}
+	return offset - 1 + int64(len(skipped)), nil
+}
+
+// search calls fn with the lines of the corpus from the first one whose hash is
+// not less than target, until fn returns false or the file ends.
+func (c *BreachedCorpus) search(target string, fn func(corpusEntry) bool) error {
+	// The first line not less than target starts in [lo, hi]. lo is the start
+	// of a line, hi that of a line or the end of the file.
+	lo, hi := int64(0), c.size
+	for lo < hi {
+		mid, err := c.nextLine(lo + (hi-lo)/2)
+		if err != nil {
+			return err
+		}
+		if mid >= hi {
+			// Only the lines from lo remain; they are read in turn below.
+			break
+		}
+		entry, next, err := c.readLine(mid)
+		if err != nil {
+			return err
+		}
+		if entry.hash != "" && entry.hash < target {
+			lo = next
+		} else {
+			hi = mid
+		}
+	}
+
+	for offset := lo; ; {
+		entry, next, err := c.readLine(offset)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		offset = next
+		if entry.hash == "" || entry.hash < target {
+			continue
+		}
+		if !fn(entry) {
+			return nil
+		}
+	}
+}
+
+// Range returns the hash suffixes of breached passwords whose SHA-1 starts with prefix.
+func (c *BreachedCorpus) Range(prefix string) ([]string, error) {
+	prefix = strings.ToUpper(prefix)
+	var suffixes []string
+	err := c.search(prefix, func(entry corpusEntry) bool {
+		if !strings.HasPrefix(entry.hash, prefix) {
+			return false
+		}
+		if entry.count < 0 || entry.count >= c.minCount {
+			suffixes = append(suffixes, entry.hash[len(prefix):])
+		}
+		return true
+	})
+	return suffixes, err
+}
+
+func (c *BreachedCorpus) IsBreached(_ context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := c.Range(hash[:hashPrefixLength])
+	if err != nil {
+		return false, err
+	}
+	i := sort.SearchStrings(suffixes, hash[hashPrefixLength:])
+	return i < len(suffixes) && suffixes[i] == hash[hashPrefixLength:], nil
+}
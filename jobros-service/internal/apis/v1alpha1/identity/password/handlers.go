@@ -0,0 +1,51 @@
+package password
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AbortWithValidationErrors answers with 422 Unprocessable Entity listing the
+// policy violations in err, and reports whether err held any. Register, reset
+// and change password handlers use it after Policy.Validate.
+func AbortWithValidationErrors(c *gin.Context, err error) bool {
+	violations, ok := AsValidationErrors(err)
+	if !ok {
+		return false
+	}
+	c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+		"error":  "Password does not meet the password policy",
+		"errors": violations,
+	})
+	return true
+}
+
+type checkRequest struct {
+	Password string `json:"password" binding:"required"`
+	Email    string `json:"email"`
+	Phone    string `json:"phoneNumber"`
+}
+
+// CheckHandler validates a password against the policy without storing it, so
+// that the app can show violations while the user types.
+func CheckHandler(policy *Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req checkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err := policy.Validate(c.Request.Context(), "password", req.Password, req.Email, req.Phone)
+		if AbortWithValidationErrors(c, err) {
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check password"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"valid": true})
+	}
+}
@@ -0,0 +1,24 @@
+package password
+
+import (
+	"net/http"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/openapi"
+)
+
+// OpenAPIRoutes describes the password routes.
+func OpenAPIRoutes() []openapi.Route {
+	return []openapi.Route{{
+		Method:      http.MethodPost,
+		Path:        "/auth/password/check",
+		OperationID: "checkPassword",
+		Summary:     "Check a password against the password policy without storing it",
+		Tag:         "auth",
+		Request:     checkRequest{},
+		Response: struct {
+			Valid bool `json:"valid"`
+		}{},
+		Description: "The password meets the policy",
+		Errors:      []int{http.StatusBadRequest, http.StatusUnprocessableEntity},
+	}}
+}
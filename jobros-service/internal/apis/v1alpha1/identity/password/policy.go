@@ -0,0 +1,161 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/app"
+)
+
+// Codes of the policy violations, stable so that the app can translate them.
+const (
+	CodeTooShort        = "too_short"
+	CodeTooLong         = "too_long"
+	CodeCommon          = "common"
+	CodeBreached        = "breached"
+	CodeMatchesIdentity = "matches_identity"
+)
+
+// commonPasswords are rejected even without a breached password corpus.
+var commonPasswords = []string{
+	"123456", "12345678", "123456789", "1234567890", "password", "password1",
+	"qwerty", "qwerty123", "azerty", "111111", "abc123", "iloveyou", "admin",
+	"welcome", "letmein", "monkey", "dragon", "football", "sunshine", "princess",
+	"passw0rd", "starwars", "whatever", "trustno1", "jobros", "jobros123",
+}
+
+// ValidationError is a policy violation, shown next to the field by the app.
+type ValidationError struct {
+	Field   string                 `json:"field"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
+// ValidationErrors are all the policy violations of a password.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Policy is the server-side password policy applied at registration, reset and
+// change of a password.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	banned    map[string]bool
+	breached  BreachedChecker
+}
+
+// NewPolicy creates the policy described by config, opening the breached
+// password corpus if one is configured.
+func NewPolicy(config app.PasswordConfig) (*Policy, error) {
+	policy := &Policy{
+		MinLength: config.MinLength,
+		MaxLength: config.MaxLength,
+		banned:    make(map[string]bool),
+	}
+	for _, password := range commonPasswords {
+		policy.banned[password] = true
+	}
+	for _, password := range config.BannedPasswords {
+		policy.banned[strings.ToLower(password)] = true
+	}
+
+	if config.BreachedCorpusPath != "" {
+		corpus, err := OpenBreachedCorpus(config.BreachedCorpusPath, config.BreachedMinCount)
+		if err != nil {
+			return nil, err
+		}
+		policy.breached = corpus
+	}
+
+	return policy, nil
+}
+
+// WithBreachedChecker returns a copy of the policy checking passwords against checker.
+func (p *Policy) WithBreachedChecker(checker BreachedChecker) *Policy {
+	copied := *p
+	copied.breached = checker
+	return &copied
+}
+
+// Validate checks password against the policy for the given field. Identity
+// values such as the email or phone number of the user may not be used as the
+// password. It returns ValidationErrors listing every violation.
+func (p *Policy) Validate(ctx context.Context, field string, password string, identity ...string) error {
+	var violations ValidationErrors
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, ValidationError{
+			Field:   field,
+			Code:    CodeTooShort,
+			Message: fmt.Sprintf("Password must be at least %d characters long", p.MinLength),
+			Params:  map[string]interface{}{"min": p.MinLength},
+		})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, ValidationError{
+			Field:   field,
+			Code:    CodeTooLong,
+			Message: fmt.Sprintf("Password must be at most %d characters long", p.MaxLength),
+			Params:  map[string]interface{}{"max": p.MaxLength},
+		})
+	}
+
+	normalized := strings.ToLower(password)
+	if p.banned[normalized] {
+		violations = append(violations, ValidationError{
+			Field:   field,
+			Code:    CodeCommon,
+			Message: "Password is too common",
+		})
+	}
+	for _, value := range identity {
+		value = strings.ToLower(strings.TrimSpace(value))
+		local, _, _ := strings.Cut(value, "@")
+		if value != "" && (normalized == value || normalized == local) {
+			violations = append(violations, ValidationError{
+				Field:   field,
+				Code:    CodeMatchesIdentity,
+				Message: "Password must not be your email address or phone number",
+			})
+			break
+		}
+	}
+
+	if p.breached != nil && !p.banned[normalized] {
+		breached, err := p.breached.IsBreached(ctx, password)
+		if err != nil {
+			return fmt.Errorf("failed to check breached passwords: %w", err)
+		}
+		if breached {
+			violations = append(violations, ValidationError{
+				Field:   field,
+				Code:    CodeBreached,
+				Message: "Password appeared in a data breach, choose another one",
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
+
+// AsValidationErrors returns the policy violations held by err, if any.
+func AsValidationErrors(err error) (ValidationErrors, bool) {
+	var violations ValidationErrors
+	ok := errors.As(err, &violations)
+	return violations, ok
+}
@@ -0,0 +1,162 @@
+package password
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// newCorpus returns a corpus of the lines, which it sorts.
+func newCorpus(minCount int, lines ...string) *BreachedCorpus {
+	sort.Strings(lines)
+	content := strings.Join(lines, "\n") + "\n"
+	return NewBreachedCorpus(strings.NewReader(content), int64(len(content)), minCount)
+}
+
+func newTestPolicy(t *testing.T) *Policy {
+	corpus := newCorpus(2,
+		sha1Hex("correct horse battery staple")+":3",
+		sha1Hex("rarely breached passphrase")+":1",
+	)
+
+	policy, err := NewPolicy(app.PasswordConfig{MinLength: 12, MaxLength: 64, BannedPasswords: []string{"Summer2024!!!"}})
+	require.NoError(t, err)
+	return policy.WithBreachedChecker(corpus)
+}
+
+func codes(err error) []string {
+	violations, _ := AsValidationErrors(err)
+	var codes []string
+	for _, violation := range violations {
+		codes = append(codes, violation.Code)
+	}
+	return codes
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	policy := newTestPolicy(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		password string
+		codes    []string
+	}{
+		{"valid", "plum tree on the hill", nil},
+		{"too short", "short", []string{CodeTooShort}},
+		{"too long", strings.Repeat("a", 65), []string{CodeTooLong}},
+		{"common", "password", []string{CodeTooShort, CodeCommon}},
+		{"configured ban", "summer2024!!!", []string{CodeCommon}},
+		{"breached", "correct horse battery staple", []string{CodeBreached}},
+		{"below breach count threshold", "rarely breached passphrase", nil},
+		{"matches email", "jane.doe.1984", []string{CodeMatchesIdentity}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(ctx, "password", tt.password, "Jane.Doe.1984@example.com", "+15550100")
+			if tt.codes == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.codes, codes(err))
+		})
+	}
+}
+
+func TestBreachedCorpus_Range(t *testing.T) {
+	corpus := newCorpus(0, "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:10434004")
+
+	suffixes, err := corpus.Range("5baa6")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1E4C9B93F3F0682250B6CF8331B7EE68FD8"}, suffixes)
+	breached, _ := corpus.IsBreached(context.Background(), "password")
+	assert.True(t, breached)
+
+	_, err = newCorpus(0, "not-a-hash:1").IsBreached(context.Background(), "password")
+	assert.Error(t, err)
+}
+
+func TestBreachedCorpus_Search(t *testing.T) {
+	// Enough lines for the lookups to binary search, with lines of varying
+	// lengths and without counts.
+	var lines []string
+	var passwords []string
+	for i := 0; i < 1000; i++ {
+		password := fmt.Sprintf("breached-%d", i)
+		passwords = append(passwords, password)
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(password), i*i))
+	}
+	lines = append(lines, sha1Hex("without count"))
+	corpus := newCorpus(10, lines...)
+	ctx := context.Background()
+
+	for i, password := range passwords {
+		breached, err := corpus.IsBreached(ctx, password)
+		require.NoError(t, err)
+		// Passwords seen fewer than 10 times are left out
+		assert.Equal(t, i*i >= 10, breached, password)
+	}
+	breached, err := corpus.IsBreached(ctx, "without count")
+	require.NoError(t, err)
+	assert.True(t, breached)
+	for i := 0; i < 100; i++ {
+		breached, err := corpus.IsBreached(ctx, fmt.Sprintf("never breached %d", i))
+		require.NoError(t, err)
+		assert.False(t, breached)
+	}
+}
+
+func TestOpenBreachedCorpus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	content := "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:10434004\r\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	corpus, err := OpenBreachedCorpus(path, 1)
+	require.NoError(t, err)
+	defer corpus.Close()
+	breached, err := corpus.IsBreached(context.Background(), "password")
+	require.NoError(t, err)
+	assert.True(t, breached)
+
+	require.NoError(t, os.WriteFile(path, []byte("password:1\n"), 0o600))
+	_, err = OpenBreachedCorpus(path, 1)
+	assert.Error(t, err)
+}
+
+func TestCheckHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/auth/password/check", CheckHandler(newTestPolicy(t)))
+
+	check := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/auth/password/check", strings.NewReader(body)))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, check(`{"password":"plum tree on the hill"}`).Code)
+
+	w := check(`{"password":"qwerty"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"too_short"`)
+	assert.Contains(t, w.Body.String(), `"field":"password"`)
+}
@@ -0,0 +1,89 @@
+package user
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/registry"
+)
+
+var (
+	admissionBuilder = registry.NewAdmissionBuilder(addAdmissionHooks)
+	// AddToAdmission adds the admission hooks of users.
+	AddToAdmission = admissionBuilder.AddToAdmission
+)
+
+func addAdmissionHooks(a *registry.Admission) error {
+	gvk := SchemeGroupVersion.WithKind("User")
+	a.AddMutatingHook(gvk, normalizeHook{})
+	a.AddValidatingHook(gvk, phoneHook{})
+	a.AddValidatingHook(gvk, selfLockoutHook{})
+	return nil
+}
+
+// phoneSeparators are the characters people write phone numbers with.
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+
+// normalizeHook stores emails in lower case, phone numbers without
+// separators, and each role once.
+type normalizeHook struct{}
+
+func (normalizeHook) Name() string { return "user-normalize" }
+
+func (normalizeHook) Mutate(_ context.Context, a *registry.Attributes) error {
+	u := a.Object.(*User)
+	u.Email = strings.ToLower(strings.TrimSpace(u.Email))
+	u.Phone = phoneSeparators.Replace(strings.TrimSpace(u.Phone))
+
+	seen := make(map[string]bool, len(u.Roles))
+	roles := u.Roles[:0]
+	for _, role := range u.Roles {
+		if !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+	u.Roles = roles
+	return nil
+}
+
+// e164Pattern matches phone numbers in the international E.164 format, which
+// SMS verification requires.
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// phoneHook requires phone numbers in the E.164 format.
+type phoneHook struct{}
+
+func (phoneHook) Name() string { return "user-phone" }
+
+func (phoneHook) Validate(_ context.Context, a *registry.Attributes) error {
+	if a.Operation == registry.Delete {
+		return nil
+	}
+	u := a.Object.(*User)
+	if u.Phone != "" && !e164Pattern.MatchString(u.Phone) {
+		return registry.FieldErrors{{Field: "phoneNumber", Message: "must be an international number, such as +33612345678"}}
+	}
+	return nil
+}
+
+// selfLockoutHook keeps administrators from deleting their own account or
+// removing their own admin role, which would leave them locked out.
+type selfLockoutHook struct{}
+
+func (selfLockoutHook) Name() string { return "user-self-lockout" }
+
+func (selfLockoutHook) Validate(_ context.Context, a *registry.Attributes) error {
+	if a.OldObject == nil || a.User == nil || a.OldObject.(*User).UID != a.User.UserID {
+		return nil
+	}
+	if a.Operation == registry.Delete {
+		return registry.Denied("administrators cannot delete their own account")
+	}
+	if a.OldObject.(*User).HasRole(auth.RoleAdmin) && !a.Object.(*User).HasRole(auth.RoleAdmin) {
+		return registry.FieldErrors{{Field: "roleRefs", Message: "administrators cannot remove their own admin role"}}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmission(t *testing.T) {
+	api := newUserAPI(t)
+
+	w := api.do("admin1", "POST", "/users", `{"email":" Jane@Example.com","phoneNumber":"+33 6 00-00.00.00","roleRefs":["client","client"],"status":"pending"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "jane@example.com", created.Email)
+	assert.Equal(t, "+33600000000", created.Phone)
+	assert.Equal(t, []string{"client"}, created.Roles)
+
+	w = api.do("admin1", "POST", "/users", `{"email":"john@example.com","phoneNumber":"0600000000","roleRefs":["pilot"],"status":"pending"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{"error":"User is invalid","errors":[
+		{"field":"roleRefs[0]","message":"unknown role \"pilot\""},
+		{"field":"phoneNumber","message":"must be an international number, such as +33612345678"}
+	]}`, w.Body.String())
+}
+
+func TestAdmission_SelfLockout(t *testing.T) {
+	api := newUserAPI(t)
+	w := api.do("admin1", "POST", "/users", `{"email":"admin@example.com","phoneNumber":"+33600000000","roleRefs":["admin"],"status":"pending"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var admin User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &admin))
+	api.login(admin.UID, auth.RoleAdmin)
+
+	w = api.do(admin.UID, "PUT", "/users/"+admin.UID, `{"email":"admin@example.com","phoneNumber":"+33600000000","roleRefs":["client"],"status":"pending"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "administrators cannot remove their own admin role")
+
+	w = api.do(admin.UID, "DELETE", "/users/"+admin.UID, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"error":"administrators cannot delete their own account"}`, w.Body.String())
+}
@@ -0,0 +1,89 @@
+package user
+
+import (
+	internaluser "github.com/maxime-joseph/Jobros/jobros-service/internal/apis/identity/user"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+// addConversionFuncs registers the conversions between the v1alpha1 and the
+// internal user types.
+func addConversionFuncs(scheme *runtime.Scheme) error {
+	err := scheme.AddConversionFunc((*User)(nil), (*internaluser.User)(nil), func(in, out runtime.Object) error {
+		convertUserToInternal(in.(*User), out.(*internaluser.User))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return scheme.AddConversionFunc((*internaluser.User)(nil), (*User)(nil), func(in, out runtime.Object) error {
+		convertUserFromInternal(in.(*internaluser.User), out.(*User))
+		return nil
+	})
+}
+
+func convertUserToInternal(in *User, out *internaluser.User) {
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Email = in.Email
+	out.Phone = in.Phone
+	out.Roles = copyStrings(in.Roles)
+	out.Status = internaluser.Status(in.Status)
+	out.StatusHistory = nil
+	if in.StatusHistory != nil {
+		out.StatusHistory = make([]internaluser.StatusChange, len(in.StatusHistory))
+		for i, change := range in.StatusHistory {
+			out.StatusHistory[i] = internaluser.StatusChange{
+				From:   internaluser.Status(change.From),
+				To:     internaluser.Status(change.To),
+				Reason: change.Reason,
+				Actor:  change.Actor,
+				At:     change.At,
+			}
+		}
+	}
+	out.UpdatedAt = in.UpdatedAt
+	out.Verification = internaluser.Verification(in.Verification)
+	out.Security = internaluser.Security(in.Security)
+}
+
+func convertUserFromInternal(in *internaluser.User, out *User) {
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Email = in.Email
+	out.Phone = in.Phone
+	out.Roles = copyStrings(in.Roles)
+	out.Status = Status(in.Status)
+	out.StatusHistory = nil
+	if in.StatusHistory != nil {
+		out.StatusHistory = make([]StatusChange, len(in.StatusHistory))
+		for i, change := range in.StatusHistory {
+			out.StatusHistory[i] = StatusChange{
+				From:   Status(change.From),
+				To:     Status(change.To),
+				Reason: change.Reason,
+				Actor:  change.Actor,
+				At:     change.At,
+			}
+		}
+	}
+	out.UpdatedAt = in.UpdatedAt
+	// The v1alpha1 verification and security are anonymous structs, assigned
+	// field by field.
+	out.Verification.Email = in.Verification.Email
+	out.Verification.Phone = in.Verification.Phone
+	out.Verification.Identity = in.Verification.Identity
+	out.Verification.Professional = in.Verification.Professional
+	out.Security.MFAEnabled = in.Security.MFAEnabled
+	out.Security.LoginAttempts = in.Security.LoginAttempts
+	out.Security.LastLogin = in.Security.LastLogin
+	out.Security.LastUpdated = in.Security.LastUpdated
+	out.Security.PasswordChanged = in.Security.PasswordChanged
+}
+
+// copyStrings copies in, keeping nil and empty slices apart.
+func copyStrings(in []string) []string {
+	if in == nil {
+		return nil
+	}
+	out := make([]string, len(in))
+	copy(out, in)
+	return out
+}
@@ -0,0 +1,75 @@
+package user
+
+import (
+	"math/rand"
+	"testing"
+
+	internaluser "github.com/maxime-joseph/Jobros/jobros-service/internal/apis/identity/user"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/testutils"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConversionScheme(t testing.TB) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+	require.NoError(t, internaluser.AddToScheme(scheme))
+	return scheme
+}
+
+func FuzzUserRoundTrip(f *testing.F) {
+	for seed := int64(0); seed < 50; seed++ {
+		f.Add(seed)
+	}
+	scheme := newConversionScheme(f)
+
+	f.Fuzz(func(t *testing.T, seed int64) {
+		u := &User{}
+		testutils.Fill(u, rand.New(rand.NewSource(seed)))
+		u.SetGroupVersionKind(u.GetGroupVersionKind())
+
+		hub, err := scheme.ConvertToVersion(u, internaluser.SchemeGroupVersion)
+		require.NoError(t, err)
+		back, err := scheme.ConvertToVersion(hub, SchemeGroupVersion)
+		require.NoError(t, err)
+		assert.Equal(t, u, back)
+	})
+}
+
+func FuzzInternalUserRoundTrip(f *testing.F) {
+	for seed := int64(0); seed < 50; seed++ {
+		f.Add(seed)
+	}
+	scheme := newConversionScheme(f)
+
+	f.Fuzz(func(t *testing.T, seed int64) {
+		hub := &internaluser.User{}
+		testutils.Fill(hub, rand.New(rand.NewSource(seed)))
+		hub.TypeMeta = runtime.TypeMeta{}
+
+		u, err := scheme.ConvertToVersion(hub, SchemeGroupVersion)
+		require.NoError(t, err)
+		back, err := scheme.ConvertToVersion(u, internaluser.SchemeGroupVersion)
+		require.NoError(t, err)
+		assert.Equal(t, hub, back)
+	})
+}
+
+func TestConvertToInternal(t *testing.T) {
+	scheme := newConversionScheme(t)
+	u := newTestUser()
+
+	converted, err := scheme.ConvertToVersion(u, internaluser.SchemeGroupVersion)
+	require.NoError(t, err)
+	hub := converted.(*internaluser.User)
+	assert.Empty(t, hub.APIVersion)
+	assert.Equal(t, u.ObjectMeta, hub.ObjectMeta)
+	assert.Equal(t, internaluser.Status("active"), hub.Status)
+	assert.Equal(t, "verified", hub.StatusHistory[0].Reason)
+
+	hub.Roles[0] = "admin"
+	hub.Labels["city"] = "paris"
+	assert.Equal(t, "client", u.Roles[0])
+	assert.Equal(t, "lyon", u.Labels["city"])
+}
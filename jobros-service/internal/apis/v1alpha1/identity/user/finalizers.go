@@ -0,0 +1,24 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/controller"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+// FinalizerRevokeTokens holds the deletion of a user until every token issued
+// to them is revoked. Services keeping data about users, such as bookings and
+// payouts, add their own finalizer to users with an admission hook.
+const FinalizerRevokeTokens = "identity.jobros.io/revoke-tokens"
+
+// NewTokensFinalizer creates the controller revoking the tokens of deleted
+// users.
+func NewTokensFinalizer(users storage.Interface, revocations auth.RevocationStore) *controller.Finalizer {
+	return controller.NewFinalizer(FinalizerRevokeTokens, users, func(ctx context.Context, obj runtime.Object) error {
+		return revocations.RevokeUserTokens(ctx, obj.(*User).UID, time.Now())
+	})
+}
@@ -0,0 +1,35 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokensFinalizer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	users := storage.NewMemoryStore()
+	revocations := auth.NewMemoryRevocationStore()
+	go NewTokensFinalizer(users, revocations).Run(ctx)
+
+	u := &User{Email: "jane@example.com", Roles: []string{auth.RoleClient}}
+	Strategy{}.PrepareForCreate(ctx, u)
+	assert.Equal(t, []string{FinalizerRevokeTokens}, u.Finalizers)
+	require.NoError(t, users.Create(ctx, u))
+	require.NoError(t, users.Delete(ctx, u.UID, ""))
+
+	assert.Eventually(t, func() bool {
+		_, err := users.Get(ctx, u.UID)
+		return errors.Is(err, storage.ErrNotFound)
+	}, time.Second, 5*time.Millisecond)
+	revokedBefore, err := revocations.UserTokensRevokedBefore(ctx, u.UID)
+	require.NoError(t, err)
+	assert.False(t, revokedBefore.IsZero())
+}
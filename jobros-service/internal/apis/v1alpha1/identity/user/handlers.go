@@ -0,0 +1,69 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/server"
+)
+
+type updateStatusRequest struct {
+	Status Status `json:"status" binding:"required,oneof=pending active suspended deactivated deleted"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+// UpdateStatusHandler changes the status of the user identified by the uid path
+// parameter, as in PUT /users/:uid/status. Tokens with the users:admin scope
+// may apply any allowed transition; users may only deactivate or delete their
+// own account, and not through the token of an application. An
+// If-Match header makes the change conditional on the version of the user,
+// returned in the ETag header. It must run after AuthMiddleware.
+func UpdateStatusHandler(service *StatusService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := auth.ClaimsFromContext(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication is required"})
+			return
+		}
+
+		id := c.Param("uid")
+		resourceVersion, err := server.IfMatch(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var req updateStatusRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Applications acting for a user may not close the account, and only
+		// tokens with the users:admin scope act as admins.
+		selfService := claims.UserID == id && claims.ClientID == "" &&
+			(req.Status == StatusDeactivated || req.Status == StatusDeleted)
+		if !claims.HasScopes(auth.ScopeUsersAdminister) && !selfService {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Not allowed to change the status of this user"})
+			return
+		}
+
+		u, err := service.Transition(c.Request.Context(), id, req.Status, req.Reason, claims.UserID, resourceVersion)
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		case errors.Is(err, ErrInvalidTransition):
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			server.AbortWithStorageError(c, err)
+			return
+		}
+
+		server.SetETag(c, u)
+		c.JSON(http.StatusOK, u)
+	}
+}
@@ -0,0 +1,34 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/openapi"
+)
+
+// OpenAPIRoutes describes the user routes served besides the registry.
+func OpenAPIRoutes() []openapi.Route {
+	return []openapi.Route{{
+		Method:      http.MethodPut,
+		Path:        "/users/{uid}/status",
+		OperationID: "updateUserStatus",
+		Summary:     "Change the status of a user",
+		Tag:         usersCollection,
+		Auth:        true,
+		Request:     updateStatusRequest{},
+		Response:    User{},
+		Description: "The user with its new status; the ETag header holds its version",
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed},
+	}, {
+		Method:      http.MethodPost,
+		Path:        "/register",
+		OperationID: "register",
+		Summary:     "Create a pending account, with an invite code unless registration is open",
+		Tag:         "registration",
+		Request:     registerRequest{},
+		Status:      http.StatusCreated,
+		Response:    User{},
+		Description: "The new user",
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusUnprocessableEntity},
+	}}
+}
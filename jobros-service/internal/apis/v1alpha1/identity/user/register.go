@@ -0,0 +1,16 @@
+package user
+
+//go:generate go run github.com/maxime-joseph/Jobros/jobros-service/cmd/deepcopy-gen
+
+import "github.com/maxime-joseph/Jobros/jobros-service/runtime"
+
+var (
+	// SchemeBuilder registers the user types.
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes, addConversionFuncs)
+	// AddToScheme adds the user types to a scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+func addKnownTypes(scheme *runtime.Scheme) error {
+	return scheme.AddKnownTypes(&User{})
+}
@@ -0,0 +1,79 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/invite"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/registry"
+)
+
+// registrationManager manages the fields of the users created by registering.
+const registrationManager = "registration"
+
+type registerRequest struct {
+	Email      string `json:"email" binding:"required,email"`
+	Phone      string `json:"phoneNumber" binding:"required"`
+	Role       string `json:"role" binding:"required,oneof=client provider"`
+	InviteCode string `json:"inviteCode"`
+}
+
+// RegisterHandler creates a pending user with the role asked for, as in
+// POST /register. The registration mode of gate decides whether an invite
+// code is required, and the invite is redeemed once the user is created.
+func RegisterHandler(users *registry.REST, gate *invite.Gate) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var req registerRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		inv, err := gate.Check(ctx, req.InviteCode, req.Role)
+		if abortWithGateError(c, err) {
+			return
+		}
+
+		u := &User{Email: req.Email, Phone: req.Phone, Roles: []string{req.Role}}
+		u.SetGroupVersionKind(u.GetGroupVersionKind())
+		if err := users.CreateObject(ctx, u, nil, registrationManager); err != nil {
+			users.AbortWithError(c, err)
+			return
+		}
+
+		if inv != nil {
+			if _, err := gate.Redeem(ctx, inv.Code, u.UID); err != nil {
+				// The invite was used up since it was checked: the user must
+				// not stay registered without it.
+				if err := users.Store().Delete(ctx, u.UID, ""); err != nil {
+					glog.Errorf("failed to delete user %s registered without an invite: %v", u.UID, err)
+				}
+				abortWithGateError(c, err)
+				return
+			}
+		}
+
+		c.JSON(http.StatusCreated, u)
+	}
+}
+
+// abortWithGateError answers with the status of an error of the registration
+// gate, and reports whether there was one.
+func abortWithGateError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, invite.ErrRegistrationClosed), errors.Is(err, invite.ErrInviteRequired):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, invite.ErrInviteInvalid), errors.Is(err, invite.ErrInviteRoleMismatch):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		glog.Errorf("registration failed: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to register"})
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/invite"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/app"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/registry"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+	admission := registry.NewAdmission()
+	require.NoError(t, AddToAdmission(admission))
+	opts := RESTOptions()
+	opts.Admission = admission
+	users := registry.NewREST(scheme, storage.NewMemoryStore(), opts)
+
+	invites := invite.NewMemoryStore()
+	gate, err := invite.NewGate(app.RegistrationConfig{Mode: string(invite.ModeInviteOnly), InviteTTL: time.Hour}, invites)
+	require.NoError(t, err)
+	providerInvite, err := gate.CreateInvite(ctx, "admin1", auth.RoleProvider, 1, 0)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.POST("/register", RegisterHandler(users, gate))
+	register := func(role, code string) *httptest.ResponseRecorder {
+		body := `{"email":"Jane@Example.com","phoneNumber":"+33600000000","role":"` + role + `","inviteCode":"` + code + `"}`
+		req := httptest.NewRequest("POST", "/register", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, register(auth.RoleProvider, "").Code)
+	assert.Equal(t, http.StatusBadRequest, register(auth.RoleProvider, "UNKNOWN").Code)
+	assert.Equal(t, http.StatusBadRequest, register(auth.RoleClient, providerInvite.Code).Code)
+	assert.Equal(t, http.StatusBadRequest, register(auth.RoleAdmin, providerInvite.Code).Code)
+
+	w := register(auth.RoleProvider, providerInvite.Code)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "jane@example.com", created.Email)
+	assert.Equal(t, []string{auth.RoleProvider}, created.Roles)
+	assert.Equal(t, StatusPending, created.Status)
+
+	redeemed, err := invites.GetInvite(ctx, providerInvite.Code)
+	require.NoError(t, err)
+	require.Len(t, redeemed.Redemptions, 1)
+	assert.Equal(t, created.UID, redeemed.Redemptions[0].UserID)
+
+	// The invite allowed a single registration.
+	assert.Equal(t, http.StatusBadRequest, register(auth.RoleProvider, providerInvite.Code).Code)
+}
@@ -0,0 +1,187 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Status is a stage of the account lifecycle.
+type Status string
+
+const (
+	// StatusPending accounts have registered but not completed verification.
+	StatusPending Status = "pending"
+	// StatusActive accounts can use the platform.
+	StatusActive Status = "active"
+	// StatusSuspended accounts were blocked by an administrator.
+	StatusSuspended Status = "suspended"
+	// StatusDeactivated accounts were closed by their owner and can be reopened.
+	StatusDeactivated Status = "deactivated"
+	// StatusDeleted accounts are final.
+	StatusDeleted Status = "deleted"
+)
+
+const usersCollection = "users"
+
+// ErrInvalidTransition is returned when a status change is not allowed by the lifecycle.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// ErrUserNotFound is returned when the user does not exist.
+var ErrUserNotFound = errors.New("user not found")
+
+// statusTransitions lists the statuses each status can move to.
+var statusTransitions = map[Status][]Status{
+	StatusPending:     {StatusActive, StatusDeleted},
+	StatusActive:      {StatusSuspended, StatusDeactivated, StatusDeleted},
+	StatusSuspended:   {StatusActive, StatusDeactivated, StatusDeleted},
+	StatusDeactivated: {StatusActive, StatusDeleted},
+	StatusDeleted:     {},
+}
+
+// StatusChange records a transition of the account status.
+type StatusChange struct {
+	From   Status    `json:"from" bson:"from"`
+	To     Status    `json:"to" bson:"to"`
+	Reason string    `json:"reason" bson:"reason"`
+	Actor  string    `json:"actor" bson:"actor"`
+	At     time.Time `json:"at" bson:"at"`
+}
+
+// CanTransitionTo reports whether the lifecycle allows moving from s to status.
+func (s Status) CanTransitionTo(status Status) bool {
+	for _, allowed := range statusTransitions[s] {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionStatus moves the user to status and records the change in its
+// history. Reason and actor are required so that every change can be audited.
+func (u *User) TransitionStatus(status Status, reason string, actor string, at time.Time) (StatusChange, error) {
+	if reason == "" || actor == "" {
+		return StatusChange{}, errors.New("reason and actor are required to change the status")
+	}
+	if !u.Status.CanTransitionTo(status) {
+		return StatusChange{}, fmt.Errorf("%w: from %q to %q", ErrInvalidTransition, u.Status, status)
+	}
+
+	change := StatusChange{From: u.Status, To: status, Reason: reason, Actor: actor, At: at}
+	u.Status = status
+	u.StatusHistory = append(u.StatusHistory, change)
+	u.UpdatedAt = at
+	return change, nil
+}
+
+// StatusHook runs after a status change of a user has been stored.
+type StatusHook func(ctx context.Context, u *User, change StatusChange) error
+
+// RevokeTokensHook returns a StatusHook revoking every token issued to the user
+// so far, so that a suspended or deleted user is logged out everywhere.
+func RevokeTokensHook(revocations auth.RevocationStore) StatusHook {
+	return func(ctx context.Context, u *User, change StatusChange) error {
+		return revocations.RevokeUserTokens(ctx, u.UID, change.At)
+	}
+}
+
+// NewMongoStore creates the storage of users, in the users collection.
+func NewMongoStore(ctx context.Context, database *mongo.Database, opts ...storage.Option) (*storage.MongoStore, error) {
+	return storage.NewMongoStore(ctx, database, usersCollection, func() runtime.Object { return &User{} }, opts...)
+}
+
+// StatusService applies status transitions to stored users and runs the hooks
+// registered for the target status. It also tells AuthMiddleware whether a
+// user is active.
+type StatusService struct {
+	users storage.Interface
+	hooks map[Status][]StatusHook
+	now   func() time.Time
+}
+
+// NewStatusService creates a StatusService for the stored users.
+func NewStatusService(users storage.Interface) *StatusService {
+	return &StatusService{
+		users: users,
+		hooks: make(map[Status][]StatusHook),
+		now:   time.Now,
+	}
+}
+
+// OnTransition registers hook to run whenever a user moves to status.
+func (s *StatusService) OnTransition(status Status, hook StatusHook) {
+	s.hooks[status] = append(s.hooks[status], hook)
+}
+
+func (s *StatusService) getUser(ctx context.Context, userID string) (*User, error) {
+	obj, err := s.users.Get(ctx, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user: %w", err)
+	}
+	return obj.(*User), nil
+}
+
+// Transition moves the user to status. When resourceVersion is given, the user
+// must still be at that version; in any case the update fails with
+// storage.ErrConflict if the user changed concurrently. Hook failures are
+// logged, since the transition itself has already been stored.
+func (s *StatusService) Transition(ctx context.Context, userID string, status Status, reason string, actor string, resourceVersion string) (*User, error) {
+	u, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if resourceVersion != "" && u.ResourceVersion != resourceVersion {
+		return nil, fmt.Errorf("%w: user %s is no longer at resourceVersion %s", storage.ErrConflict, userID, resourceVersion)
+	}
+
+	change, err := u.TransitionStatus(status, reason, actor, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.users.Update(ctx, u); err != nil {
+		return nil, fmt.Errorf("failed to update user status: %w", err)
+	}
+
+	for _, hook := range s.hooks[status] {
+		if err := hook(ctx, u, change); err != nil {
+			glog.Errorf("status hook for user %s moving to %s failed: %v", userID, status, err)
+		}
+	}
+
+	return u, nil
+}
+
+// IsUserActive implements auth.UserStatusLookup.
+func (s *StatusService) IsUserActive(ctx context.Context, userID string) (bool, error) {
+	u, err := s.getUser(ctx, userID)
+	if errors.Is(err, ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return u.Status == StatusActive, nil
+}
+
+// UserRoles implements auth.UserRolesLookup.
+func (s *StatusService) UserRoles(ctx context.Context, userID string) ([]string, error) {
+	u, err := s.getUser(ctx, userID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u.Roles, nil
+}
@@ -0,0 +1,158 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from    Status
+		to      Status
+		allowed bool
+	}{
+		{StatusPending, StatusActive, true},
+		{StatusPending, StatusSuspended, false},
+		{StatusActive, StatusSuspended, true},
+		{StatusSuspended, StatusActive, true},
+		{StatusDeactivated, StatusActive, true},
+		{StatusDeactivated, StatusSuspended, false},
+		{StatusDeleted, StatusActive, false},
+		{StatusActive, StatusActive, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.allowed, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestUser_TransitionStatus(t *testing.T) {
+	now := time.Date(2024, 12, 20, 10, 0, 0, 0, time.UTC)
+	u := &User{Status: StatusActive}
+
+	change, err := u.TransitionStatus(StatusSuspended, "chargeback fraud", "admin1", now)
+	require.NoError(t, err)
+	assert.Equal(t, StatusChange{From: StatusActive, To: StatusSuspended, Reason: "chargeback fraud", Actor: "admin1", At: now}, change)
+	assert.Equal(t, StatusSuspended, u.Status)
+	assert.Equal(t, []StatusChange{change}, u.StatusHistory)
+	assert.Equal(t, now, u.UpdatedAt)
+
+	_, err = u.TransitionStatus(StatusPending, "retry verification", "admin1", now)
+	assert.True(t, errors.Is(err, ErrInvalidTransition))
+	assert.Equal(t, StatusSuspended, u.Status)
+
+	_, err = u.TransitionStatus(StatusActive, "", "admin1", now)
+	assert.Error(t, err)
+}
+
+func TestRevokeTokensHook(t *testing.T) {
+	revocations := auth.NewMemoryRevocationStore()
+	u := &User{ObjectMeta: runtime.ObjectMeta{UID: runtime.NewUID()}, Status: StatusActive}
+	at := time.Now().Add(time.Second)
+
+	change, err := u.TransitionStatus(StatusSuspended, "abuse", "admin1", at)
+	require.NoError(t, err)
+	require.NoError(t, RevokeTokensHook(revocations)(context.Background(), u, change))
+
+	issued := &auth.JWTClaims{
+		UserID:           u.UID,
+		RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(time.Now())},
+	}
+	err = auth.RevocationCheck(revocations)(context.Background(), issued)
+	assert.True(t, errors.Is(err, auth.ErrTokenRevoked))
+}
+
+func TestUpdateStatusHandler_IfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager, err := auth.NewJWTManager(auth.WithSecret([]byte("test-secret-key")))
+	require.NoError(t, err)
+	adminToken, err := jwtManager.GenerateAccessToken("admin1", auth.RoleAdmin)
+	require.NoError(t, err)
+
+	users := storage.NewMemoryStore()
+	u := &User{Roles: []string{auth.RoleProvider}, Status: StatusActive}
+	require.NoError(t, users.Create(context.Background(), u))
+
+	router := gin.New()
+	router.PUT("/users/:uid/status", auth.AuthMiddleware(jwtManager), UpdateStatusHandler(NewStatusService(users)))
+	updateStatus := func(status Status, ifMatch string) *httptest.ResponseRecorder {
+		body := strings.NewReader(`{"status":"` + string(status) + `","reason":"review"}`)
+		req := httptest.NewRequest("PUT", "/users/"+u.UID+"/status", body)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := updateStatus(StatusSuspended, `"`+u.ResourceVersion+`"`)
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEqual(t, `"`+u.ResourceVersion+`"`, etag)
+
+	// A second admin acting on the version read before the suspension.
+	w = updateStatus(StatusDeactivated, `"`+u.ResourceVersion+`"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = updateStatus(StatusActive, etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = updateStatus(StatusActive, "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestUpdateStatusHandler_Authorization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager, err := auth.NewJWTManager(auth.WithSecret([]byte("test-secret-key")))
+	require.NoError(t, err)
+
+	users := storage.NewMemoryStore()
+	u := &User{Roles: []string{auth.RoleClient}, Status: StatusActive}
+	require.NoError(t, users.Create(context.Background(), u))
+
+	router := gin.New()
+	router.PUT("/users/:uid/status", auth.AuthMiddleware(jwtManager), UpdateStatusHandler(NewStatusService(users)))
+	updateStatus := func(token string, status Status) int {
+		body := strings.NewReader(`{"status":"` + string(status) + `","reason":"review"}`)
+		req := httptest.NewRequest("PUT", "/users/"+u.UID+"/status", body)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// An admin token narrowed to other scopes does not act as an admin.
+	narrowed, err := jwtManager.GenerateAccessToken("admin1", auth.RoleAdmin, auth.ScopeProfileRead)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, updateStatus(narrowed, StatusSuspended))
+	delegated, err := jwtManager.GenerateDelegatedAccessToken("admin1", auth.RoleAdmin, "app", auth.ScopeProfileRead)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, updateStatus(delegated, StatusSuspended))
+
+	// Applications may not close the account of their user.
+	app, err := jwtManager.GenerateDelegatedAccessToken(u.UID, auth.RoleClient, "app", auth.ScopeProfileWrite)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, updateStatus(app, StatusDeactivated))
+	assert.Equal(t, http.StatusForbidden, updateStatus(app, StatusDeleted))
+
+	own, err := jwtManager.GenerateAccessToken(u.UID, auth.RoleClient)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, updateStatus(own, StatusDeactivated))
+}
@@ -0,0 +1,81 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/registry"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/selection"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Strategy serves users through the registry. The status is only changed
+// through the status endpoint, so that transitions are checked and audited,
+// and the verification and security state are owned by the server.
+type Strategy struct{}
+
+var _ registry.Strategy = Strategy{}
+
+// RESTOptions are the registry options of users, served to administrators.
+func RESTOptions() registry.Options {
+	return registry.Options{
+		Resource:    usersCollection,
+		Kind:        SchemeGroupVersion.WithKind("User"),
+		ShortNames:  []string{"usr"},
+		Strategy:    Strategy{},
+		ReadScopes:  []string{auth.ScopeUsersAdminister},
+		WriteScopes: []string{auth.ScopeUsersAdminister},
+		FieldSelectors: selection.Fields{
+			"email":    "email",
+			"status":   "status",
+			"roleRefs": "roleRefs",
+		},
+	}
+}
+
+// NewREST serves the users of database with the registry, running the hooks
+// of admission on writes.
+func NewREST(ctx context.Context, database *mongo.Database, scheme *runtime.Scheme, admission *registry.Admission, storeOpts ...storage.Option) (*registry.REST, error) {
+	opts := RESTOptions()
+	opts.Admission = admission
+	return registry.NewMongoREST(ctx, database, scheme, opts, storeOpts...)
+}
+
+func (Strategy) OwnerScoped() bool { return false }
+
+func (Strategy) PrepareForCreate(_ context.Context, obj runtime.Object) {
+	u := obj.(*User)
+	var zero User
+	u.Status = StatusPending
+	u.StatusHistory = nil
+	u.Verification = zero.Verification
+	u.Security = zero.Security
+	if !runtime.HasFinalizer(u, FinalizerRevokeTokens) {
+		u.Finalizers = append(u.Finalizers, FinalizerRevokeTokens)
+	}
+}
+
+func (Strategy) PrepareForUpdate(_ context.Context, obj, old runtime.Object) {
+	u, oldUser := obj.(*User), old.(*User)
+	u.Status = oldUser.Status
+	u.StatusHistory = oldUser.StatusHistory
+	u.Verification = oldUser.Verification
+	u.Security = oldUser.Security
+}
+
+func (Strategy) Validate(_ context.Context, obj runtime.Object) registry.FieldErrors {
+	var errs registry.FieldErrors
+	for i, role := range obj.(*User).Roles {
+		if _, ok := auth.RoleScopes[role]; !ok {
+			errs = append(errs, registry.FieldError{Field: fmt.Sprintf("roleRefs[%d]", i), Message: fmt.Sprintf("unknown role %q", role)})
+		}
+	}
+	return errs
+}
+
+func (s Strategy) ValidateUpdate(ctx context.Context, obj, _ runtime.Object) registry.FieldErrors {
+	return s.Validate(ctx, obj)
+}
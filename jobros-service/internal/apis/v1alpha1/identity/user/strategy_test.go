@@ -0,0 +1,85 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/registry"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// userAPI serves users from memory to test the strategy and admission.
+type userAPI struct {
+	t          *testing.T
+	router     *gin.Engine
+	jwtManager *auth.JWTManager
+	tokens     map[string]string
+}
+
+func newUserAPI(t *testing.T) *userAPI {
+	gin.SetMode(gin.TestMode)
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+	admission := registry.NewAdmission()
+	require.NoError(t, AddToAdmission(admission))
+	jwtManager, err := auth.NewJWTManager(auth.WithSecret([]byte("test-secret-key")))
+	require.NoError(t, err)
+
+	api := &userAPI{t: t, router: gin.New(), jwtManager: jwtManager, tokens: map[string]string{}}
+	opts := RESTOptions()
+	opts.Admission = admission
+	registry.NewREST(scheme, storage.NewMemoryStore(), opts).RegisterRoutes(api.router, auth.AuthMiddleware(jwtManager))
+	api.login("admin1", auth.RoleAdmin)
+	api.login("client1", auth.RoleClient)
+	return api
+}
+
+// login gives a token with the role to the user.
+func (api *userAPI) login(user, role string) {
+	token, err := api.jwtManager.GenerateAccessToken(user, role)
+	require.NoError(api.t, err)
+	api.tokens[user] = token
+}
+
+func (api *userAPI) do(user, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+api.tokens[user])
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	api.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestStrategy_REST(t *testing.T) {
+	api := newUserAPI(t)
+
+	body := `{"email":"jane@example.com","phoneNumber":"+33600000000","roleRefs":["client"],"status":"active","verificationStatus":{"identity":true}}`
+	assert.Equal(t, http.StatusForbidden, api.do("client1", "POST", "/users", body).Code)
+
+	w := api.do("admin1", "POST", "/users", body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, StatusPending, created.Status)
+	assert.False(t, created.Verification.Identity)
+
+	// The status is only changed through the status endpoint.
+	w = api.do("admin1", "PUT", "/users/"+created.UID, strings.Replace(body, "client", "provider", 1))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, []string{"provider"}, updated.Roles)
+	assert.Equal(t, StatusPending, updated.Status)
+
+	w = api.do("admin1", "POST", "/users", strings.Replace(body, `"client"`, `"pilot"`, 1))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "roleRefs[0]")
+}
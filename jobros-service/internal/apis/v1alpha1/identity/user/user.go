@@ -3,19 +3,26 @@ package user
 import (
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
 )
 
-// User represents a user in the system.
+// SchemeGroupVersion is the group version of the user types.
+var SchemeGroupVersion = runtime.GroupVersion{Group: apis.APIGroup, Version: apis.APIVersion}
+
+// User represents a user in the system. Users are stored under their UID.
+//
+// +jobros:deepcopy-gen:object=true
 type User struct {
-	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Email        string             `json:"email" bson:"email" binding:"required,email"`
-	Phone        string             `json:"phoneNumber" bson:"phoneNumber" binding:"required"`
-	Role         string             `json:"roleRef" bson:"roleRef" binding:"required"`
-	Status       string             `json:"status" bson:"status" binding:"required"`
-	CreatedAt    time.Time          `json:"createdAt" bson:"createdAt"`
-	UpdatedAt    time.Time          `json:"updatedAt" bson:"updatedAt"`
-	Verification struct {
+	runtime.TypeMeta   `bson:",inline"`
+	runtime.ObjectMeta `json:"metadata" bson:"metadata"`
+	Email              string         `json:"email" bson:"email" binding:"required,email"`
+	Phone              string         `json:"phoneNumber" bson:"phoneNumber" binding:"required"`
+	Roles              []string       `json:"roleRefs" bson:"roleRefs" binding:"required,min=1"`
+	Status             Status         `json:"status" bson:"status" binding:"required,oneof=pending active suspended deactivated deleted"`
+	StatusHistory      []StatusChange `json:"statusHistory" bson:"statusHistory"`
+	UpdatedAt          time.Time      `json:"updatedAt" bson:"updatedAt"`
+	Verification       struct {
 		Email        bool `json:"email" bson:"email"`
 		Phone        bool `json:"phone" bson:"phone"`
 		Identity     bool `json:"identity" bson:"identity"`
@@ -29,3 +36,17 @@ type User struct {
 		PasswordChanged time.Time `json:"lastPasswordChange" bson:"lastPasswordChange"`
 	} `json:"security" bson:"security"`
 }
+
+// HasRole reports whether the user holds role.
+func (u *User) HasRole(role string) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *User) GetGroupVersionKind() runtime.GroupVersionKind {
+	return SchemeGroupVersion.WithKind("User")
+}
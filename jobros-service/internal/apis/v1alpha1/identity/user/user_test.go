@@ -0,0 +1,65 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func newTestUser() *User {
+	return &User{
+		ObjectMeta: runtime.ObjectMeta{
+			Name:              "jane",
+			UID:               runtime.NewUID(),
+			Labels:            map[string]string{"city": "lyon"},
+			CreationTimestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		Email:         "jane@example.com",
+		Roles:         []string{"client", "provider"},
+		Status:        StatusActive,
+		StatusHistory: []StatusChange{{From: StatusPending, To: StatusActive, Reason: "verified", Actor: "system"}},
+	}
+}
+
+func TestUser_Scheme(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+
+	u := newTestUser()
+	data, err := scheme.EncodeJSON(u)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"apiVersion":"jobros.io/v1alpha1"`)
+	assert.Contains(t, string(data), `"metadata":{`)
+
+	decoded, err := scheme.DecodeJSON(data)
+	require.NoError(t, err)
+	assert.Equal(t, u.ObjectMeta, decoded.(*User).ObjectMeta)
+	assert.Equal(t, u.Roles, decoded.(*User).Roles)
+}
+
+func TestUser_DeepCopy(t *testing.T) {
+	u := newTestUser()
+	copied := u.DeepCopy()
+	assert.Equal(t, u, copied)
+
+	copied.Roles[0] = "admin"
+	copied.Labels["city"] = "paris"
+	copied.StatusHistory[0].Reason = "changed"
+	assert.Equal(t, "client", u.Roles[0])
+	assert.Equal(t, "lyon", u.Labels["city"])
+	assert.Equal(t, "verified", u.StatusHistory[0].Reason)
+}
+
+func TestUser_BSON(t *testing.T) {
+	data, err := bson.Marshal(newTestUser())
+	require.NoError(t, err)
+
+	var doc bson.M
+	require.NoError(t, bson.Unmarshal(data, &doc))
+	assert.Equal(t, "jane", doc["metadata"].(bson.M)["name"])
+	assert.NotContains(t, doc, "apiVersion")
+}
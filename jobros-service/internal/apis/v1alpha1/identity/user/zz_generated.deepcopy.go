@@ -0,0 +1,40 @@
+// Code generated by deepcopy-gen. DO NOT EDIT.
+
+package user
+
+import (
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+// DeepCopyInto copies the receiver into out. in must be non-nil.
+func (in *User) DeepCopyInto(out *User) {
+	*out = *in
+	in.TypeMeta.DeepCopyInto(&out.TypeMeta)
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Roles != nil {
+		out.Roles = make([]string, len(in.Roles))
+		copy(out.Roles, in.Roles)
+	}
+	if in.StatusHistory != nil {
+		out.StatusHistory = make([]StatusChange, len(in.StatusHistory))
+		copy(out.StatusHistory, in.StatusHistory)
+	}
+}
+
+// DeepCopy creates a new User copying the receiver.
+func (in *User) DeepCopy() *User {
+	if in == nil {
+		return nil
+	}
+	out := new(User)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject copies the receiver, creating a new runtime.Object.
+func (in *User) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+// ETag returns the entity tag of a resource version.
+func ETag(resourceVersion string) string {
+	return `"` + resourceVersion + `"`
+}
+
+// SetETag sets the ETag header to the resourceVersion of obj.
+func SetETag(c *gin.Context, obj runtime.Object) {
+	if meta, err := runtime.Accessor(obj); err == nil && meta.GetResourceVersion() != "" {
+		c.Header("ETag", ETag(meta.GetResourceVersion()))
+	}
+}
+
+// IfMatch returns the resourceVersion the If-Match header requires, or an
+// empty string when the header is absent or "*". Weak and multiple entity tags
+// are refused: a write is conditional on a single version.
+func IfMatch(c *gin.Context) (string, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return "", nil
+	}
+	if strings.HasPrefix(header, "W/") {
+		return "", fmt.Errorf("weak entity tags cannot be used with If-Match")
+	}
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' || strings.Contains(header[1:len(header)-1], `"`) {
+		return "", fmt.Errorf("If-Match must be a single entity tag")
+	}
+	return header[1 : len(header)-1], nil
+}
+
+// AbortWithStorageError answers with the status matching a storage error: 404
+// for missing objects, 409 for conflicts, or 412 when the conflict comes from
+// an If-Match header, 400 for invalid continue and resume tokens, and 410 for
+// expired resume tokens.
+func AbortWithStorageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.ErrInvalidContinue), errors.Is(err, storage.ErrInvalidResumeToken):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, storage.ErrResumeExpired):
+		c.AbortWithStatusJSON(http.StatusGone, gin.H{"error": storage.ErrResumeExpired.Error()})
+	case errors.Is(err, storage.ErrNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, storage.ErrConflict) && c.GetHeader("If-Match") != "":
+		c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	case errors.Is(err, storage.ErrConflict), errors.Is(err, storage.ErrAlreadyExists):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
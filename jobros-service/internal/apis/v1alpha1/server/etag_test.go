@@ -0,0 +1,67 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		header  string
+		version string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"*", "", false},
+		{`"42"`, "42", false},
+		{`W/"42"`, "", true},
+		{`"41", "42"`, "", true},
+		{`42`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("PUT", "/", nil)
+			c.Request.Header.Set("If-Match", tt.header)
+
+			version, err := IfMatch(c)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.version, version)
+		})
+	}
+}
+
+func TestAbortWithStorageError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		err     error
+		ifMatch string
+		status  int
+	}{
+		{storage.ErrNotFound, "", http.StatusNotFound},
+		{fmt.Errorf("%w: stale", storage.ErrConflict), "", http.StatusConflict},
+		{fmt.Errorf("%w: stale", storage.ErrConflict), `"1"`, http.StatusPreconditionFailed},
+		{storage.ErrAlreadyExists, "", http.StatusConflict},
+		{fmt.Errorf("connection reset"), "", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("PUT", "/", nil)
+		if tt.ifMatch != "" {
+			c.Request.Header.Set("If-Match", tt.ifMatch)
+		}
+
+		AbortWithStorageError(c, tt.err)
+		assert.Equal(t, tt.status, w.Code, tt.err.Error())
+	}
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/app"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// RateLimitKeyIP keys buckets by the client IP.
+	RateLimitKeyIP = "ip"
+	// RateLimitKeyAccount keys buckets by the account named in the request body.
+	RateLimitKeyAccount = "account"
+	// RateLimitKeyRoute keys buckets by route only, limiting all clients together.
+	RateLimitKeyRoute = "route"
+
+	defaultAccountField = "email"
+	// maxPeekedBodySize bounds how much of the body is read to find the account.
+	maxPeekedBodySize = 64 << 10
+)
+
+// RateLimitKeyFunc returns the part of a bucket key identifying the caller. It
+// returns false when the request carries nothing to key on.
+type RateLimitKeyFunc func(c *gin.Context) (string, bool)
+
+// KeyByIP keys buckets by the client IP.
+func KeyByIP() RateLimitKeyFunc {
+	return func(c *gin.Context) (string, bool) {
+		return "ip:" + c.ClientIP(), true
+	}
+}
+
+// KeyByRoute uses a single bucket for every caller of the route.
+func KeyByRoute() RateLimitKeyFunc {
+	return func(c *gin.Context) (string, bool) {
+		return "route", true
+	}
+}
+
+// KeyByAccount keys buckets by the value of field in the JSON request body, so
+// that an attacker rotating IPs against one account is still limited. The body
+// is restored for the handler.
+func KeyByAccount(field string) RateLimitKeyFunc {
+	if field == "" {
+		field = defaultAccountField
+	}
+	return func(c *gin.Context) (string, bool) {
+		if c.Request.Body == nil {
+			return "", false
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPeekedBodySize))
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+		if err != nil {
+			return "", false
+		}
+
+		var payload map[string]interface{}
+		if json.Unmarshal(body, &payload) != nil {
+			return "", false
+		}
+		account, ok := payload[field].(string)
+		if !ok || account == "" {
+			return "", false
+		}
+		return "account:" + strings.ToLower(strings.TrimSpace(account)), true
+	}
+}
+
+// RateLimitMiddleware limits requests to limit per bucket. Every key function
+// selects its own bucket and the request is rejected as soon as one of them is
+// empty. Store failures are logged and answered with 503, unless failOpen lets
+// the request through: an outage of the store must not lift the limits of
+// routes such as login.
+func RateLimitMiddleware(store RateLimitStore, limit RateLimit, failOpen bool, keys ...RateLimitKeyFunc) gin.HandlerFunc {
+	if len(keys) == 0 {
+		keys = []RateLimitKeyFunc{KeyByIP()}
+	}
+
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+
+		var wait time.Duration
+		limited := false
+		for _, keyFunc := range keys {
+			key, ok := keyFunc(c)
+			if !ok {
+				continue
+			}
+
+			allowed, retryAfter, err := store.Take(c.Request.Context(), route+"|"+key, limit)
+			if err != nil {
+				glog.Warningf("rate limit store failed for %s: %v", route, err)
+				if failOpen {
+					continue
+				}
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Service temporarily unavailable"})
+				return
+			}
+			if !allowed {
+				limited = true
+				if retryAfter > wait {
+					wait = retryAfter
+				}
+			}
+		}
+
+		if limited {
+			seconds := int(math.Ceil(wait.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RateLimiter applies the per-route limits configured in AppConfig.
+type RateLimiter struct {
+	store  RateLimitStore
+	routes map[string]gin.HandlerFunc
+}
+
+// NewRateLimiter creates a RateLimiter for the configured routes.
+func NewRateLimiter(config app.RateLimitConfig, store RateLimitStore) (*RateLimiter, error) {
+	limiter := &RateLimiter{
+		store:  store,
+		routes: make(map[string]gin.HandlerFunc),
+	}
+
+	for _, route := range config.Routes {
+		if route.Path == "" || route.Burst <= 0 || route.Period <= 0 {
+			return nil, fmt.Errorf("invalid rate limit for route %s %s: path, burst and period are required", route.Method, route.Path)
+		}
+
+		var keys []RateLimitKeyFunc
+		for _, keyBy := range route.KeyBy {
+			switch keyBy {
+			case RateLimitKeyIP:
+				keys = append(keys, KeyByIP())
+			case RateLimitKeyAccount:
+				keys = append(keys, KeyByAccount(route.AccountField))
+			case RateLimitKeyRoute:
+				keys = append(keys, KeyByRoute())
+			default:
+				return nil, fmt.Errorf("invalid rate limit key %q for route %s %s", keyBy, route.Method, route.Path)
+			}
+		}
+
+		limit := RateLimit{Burst: route.Burst, Period: route.Period}
+		limiter.routes[routeKey(route.Method, route.Path)] = RateLimitMiddleware(store, limit, route.FailOpen, keys...)
+	}
+
+	return limiter, nil
+}
+
+// SetupRateLimiter creates the RateLimiter configured by config, with the store
+// it names. It returns nil when rate limiting is disabled.
+func SetupRateLimiter(config app.RateLimitConfig, database *mongo.Database) (*RateLimiter, error) {
+	if !config.IsEnabled() {
+		return nil, nil
+	}
+	store, err := NewRateLimitStore(config.Store, database)
+	if err != nil {
+		return nil, err
+	}
+	return NewRateLimiter(config, store)
+}
+
+// Middleware returns a handler applying the limit configured for the matched route.
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		handler, ok := l.routes[routeKey(c.Request.Method, c.FullPath())]
+		if !ok {
+			handler, ok = l.routes[routeKey("", c.FullPath())]
+		}
+		if !ok {
+			c.Next()
+			return
+		}
+		handler(c)
+	}
+}
+
+// routeKey identifies a route; an empty method matches every method.
+func routeKey(method string, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
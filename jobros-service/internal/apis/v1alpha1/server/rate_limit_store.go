@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// RateLimitStoreMemory keeps buckets in the memory of a single replica.
+	RateLimitStoreMemory = "memory"
+	// RateLimitStoreMongo shares buckets between replicas through MongoDB.
+	RateLimitStoreMongo = "mongo"
+
+	rateLimitCollection = "rate_limits"
+	// maxMongoTakeRetries bounds how often a Take is retried when another replica
+	// updated the same bucket concurrently.
+	maxMongoTakeRetries = 5
+)
+
+// RateLimit describes a token bucket holding at most Burst tokens, refilled at a
+// rate of Burst tokens per Period.
+type RateLimit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// refillRate returns the number of tokens added per second.
+func (l RateLimit) refillRate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+// RateLimitStore keeps the state of the token buckets used by the rate limiter.
+type RateLimitStore interface {
+	// Take consumes a token from the bucket identified by key. When the bucket is
+	// empty it returns false and the time until the next token is available.
+	Take(ctx context.Context, key string, limit RateLimit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// NewRateLimitStore returns the store configured by name.
+func NewRateLimitStore(name string, database *mongo.Database) (RateLimitStore, error) {
+	switch name {
+	case "", RateLimitStoreMemory:
+		return NewMemoryRateLimitStore(), nil
+	case RateLimitStoreMongo:
+		if database == nil {
+			return nil, fmt.Errorf("rate limit store %q requires a database", name)
+		}
+		return NewMongoRateLimitStore(context.Background(), database)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", name)
+	}
+}
+
+// refill computes the tokens left in a bucket at now and tries to take one of them.
+func refill(tokens float64, updatedAt time.Time, now time.Time, limit RateLimit) (left float64, allowed bool, retryAfter time.Duration) {
+	elapsed := now.Sub(updatedAt).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	tokens = math.Min(float64(limit.Burst), tokens+elapsed*limit.refillRate())
+	if tokens >= 1 {
+		return tokens - 1, true, 0
+	}
+
+	missing := (1 - tokens) / limit.refillRate()
+	return tokens, false, time.Duration(math.Ceil(missing * float64(time.Second)))
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	period    time.Duration
+}
+
+// MemoryRateLimitStore keeps token buckets in memory. Limits are enforced per
+// replica, which is good enough for development and single instance deployments.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty in-memory store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: float64(limit.Burst), updatedAt: now}
+		s.buckets[key] = bucket
+	}
+
+	tokens, allowed, retryAfter := refill(bucket.tokens, bucket.updatedAt, now, limit)
+	bucket.tokens = tokens
+	bucket.updatedAt = now
+	bucket.period = limit.Period
+
+	return allowed, retryAfter, nil
+}
+
+// sweep drops buckets that have been idle long enough to be full again, so the
+// map does not grow with every client ever seen.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.updatedAt) > bucket.period {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+type mongoBucket struct {
+	Key       string    `bson:"_id"`
+	Tokens    float64   `bson:"tokens"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// MongoRateLimitStore keeps token buckets in MongoDB so that all replicas share
+// the same limits. Buckets expire through a TTL index once they are full again.
+type MongoRateLimitStore struct {
+	collection *mongo.Collection
+	now        func() time.Time
+}
+
+// NewMongoRateLimitStore creates a store backed by the rate_limits collection.
+func NewMongoRateLimitStore(ctx context.Context, database *mongo.Database) (*MongoRateLimitStore, error) {
+	collection := database.Collection(rateLimitCollection)
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rate limit index: %w", err)
+	}
+
+	return &MongoRateLimitStore{collection: collection, now: time.Now}, nil
+}
+
+func (s *MongoRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (bool, time.Duration, error) {
+	for i := 0; i < maxMongoTakeRetries; i++ {
+		now := s.now()
+
+		var bucket mongoBucket
+		err := s.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&bucket)
+		found := err == nil
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return false, 0, fmt.Errorf("failed to read rate limit bucket: %w", err)
+		}
+		if !found {
+			bucket = mongoBucket{Key: key, Tokens: float64(limit.Burst), UpdatedAt: now}
+		}
+
+		tokens, allowed, retryAfter := refill(bucket.Tokens, bucket.UpdatedAt, now, limit)
+		next := mongoBucket{
+			Key:       key,
+			Tokens:    tokens,
+			UpdatedAt: now,
+			ExpiresAt: now.Add(limit.Period),
+		}
+
+		if !found {
+			_, err = s.collection.InsertOne(ctx, next)
+			if mongo.IsDuplicateKeyError(err) {
+				continue
+			}
+			if err != nil {
+				return false, 0, fmt.Errorf("failed to create rate limit bucket: %w", err)
+			}
+			return allowed, retryAfter, nil
+		}
+
+		// Only apply the update if no other replica touched the bucket in between.
+		res, err := s.collection.ReplaceOne(ctx, bson.M{"_id": key, "updatedAt": bucket.UpdatedAt}, next)
+		if err != nil {
+			return false, 0, fmt.Errorf("failed to update rate limit bucket: %w", err)
+		}
+		if res.MatchedCount == 0 {
+			continue
+		}
+		return allowed, retryAfter, nil
+	}
+
+	return false, 0, fmt.Errorf("rate limit bucket %q is contended", key)
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/app"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMemoryStore(now *time.Time) *MemoryRateLimitStore {
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func TestMemoryRateLimitStore_Take(t *testing.T) {
+	now := time.Date(2024, 12, 20, 10, 0, 0, 0, time.UTC)
+	store := newTestMemoryStore(&now)
+	limit := RateLimit{Burst: 2, Period: time.Minute}
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := store.Take(context.Background(), "key", limit)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	allowed, retryAfter, err := store.Take(context.Background(), "key", limit)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 30*time.Second, retryAfter)
+
+	// Other keys have their own bucket
+	allowed, _, _ = store.Take(context.Background(), "other", limit)
+	assert.True(t, allowed)
+
+	// One token is refilled every 30 seconds
+	now = now.Add(30 * time.Second)
+	allowed, _, _ = store.Take(context.Background(), "key", limit)
+	assert.True(t, allowed)
+	allowed, _, _ = store.Take(context.Background(), "key", limit)
+	assert.False(t, allowed)
+}
+
+func setupRateLimitTest(t *testing.T, config app.RateLimitConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	limiter, err := NewRateLimiter(config, NewMemoryRateLimitStore())
+	if err != nil {
+		t.Fatalf("Failed to create RateLimiter: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(limiter.Middleware())
+	router.POST("/login", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/unlimited", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestRateLimiter_Middleware(t *testing.T) {
+	router := setupRateLimitTest(t, app.RateLimitConfig{
+		Routes: []app.RouteRateLimitConfig{
+			{Method: "POST", Path: "/login", Burst: 1, Period: time.Minute, KeyBy: []string{RateLimitKeyIP}},
+		},
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/login", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/login", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// Routes without a configured limit are not limited
+	for i := 0; i < 3; i++ {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/unlimited", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+}
+
+func TestRateLimiter_KeyByAccount(t *testing.T) {
+	router := setupRateLimitTest(t, app.RateLimitConfig{
+		Routes: []app.RouteRateLimitConfig{
+			{Method: "POST", Path: "/login", Burst: 1, Period: time.Minute, KeyBy: []string{RateLimitKeyAccount}},
+		},
+	})
+
+	login := func(body string, ip string) int {
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(body))
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, login(`{"email":"jane@example.com"}`, "10.0.0.1"))
+	// Changing IP does not reset the account bucket
+	assert.Equal(t, http.StatusTooManyRequests, login(`{"email":"Jane@example.com"}`, "10.0.0.2"))
+	assert.Equal(t, http.StatusOK, login(`{"email":"john@example.com"}`, "10.0.0.2"))
+}
+
+func TestNewRateLimiter_InvalidConfig(t *testing.T) {
+	_, err := NewRateLimiter(app.RateLimitConfig{
+		Routes: []app.RouteRateLimitConfig{{Path: "/login", Burst: 1, Period: time.Minute, KeyBy: []string{"device"}}},
+	}, NewMemoryRateLimitStore())
+	assert.Error(t, err)
+
+	_, err = NewRateLimiter(app.RateLimitConfig{
+		Routes: []app.RouteRateLimitConfig{{Path: "/login"}},
+	}, NewMemoryRateLimitStore())
+	assert.Error(t, err)
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, RateLimit) (bool, time.Duration, error) {
+	return false, 0, errors.New("store unavailable")
+}
+
+func TestRateLimiter_StoreFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter, err := NewRateLimiter(app.RateLimitConfig{
+		Routes: []app.RouteRateLimitConfig{
+			{Method: "POST", Path: "/login", Burst: 1, Period: time.Minute},
+			{Method: "GET", Path: "/search", Burst: 1, Period: time.Minute, FailOpen: true},
+		},
+	}, failingRateLimitStore{})
+	if err != nil {
+		t.Fatalf("Failed to create RateLimiter: %v", err)
+	}
+	router := gin.New()
+	router.Use(limiter.Middleware())
+	router.POST("/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/search", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/login", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/search", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestSetupRateLimiter(t *testing.T) {
+	disabled := false
+	limiter, err := SetupRateLimiter(app.RateLimitConfig{Enabled: &disabled}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, limiter)
+
+	limiter, err = SetupRateLimiter(app.RateLimitConfig{Store: RateLimitStoreMemory}, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, limiter)
+
+	_, err = SetupRateLimiter(app.RateLimitConfig{Store: RateLimitStoreMongo}, nil)
+	assert.Error(t, err)
+	_, err = SetupRateLimiter(app.RateLimitConfig{Store: "redis"}, nil)
+	assert.Error(t, err)
+}
@@ -11,7 +11,9 @@ const (
 	defaultPort = "8080"
 )
 
-func SetupRouter(host string, port string) *gin.Engine {
+// SetupRouter creates the router. The given middlewares, such as the rate
+// limiter, run after CORS handling for every route.
+func SetupRouter(host string, port string, middlewares ...gin.HandlerFunc) *gin.Engine {
 	if host == "" {
 		host = defaultHost
 	}
@@ -22,6 +24,7 @@ func SetupRouter(host string, port string) *gin.Engine {
 
 	router := gin.Default()
 	router.Use(CORSMiddleware())
+	router.Use(middlewares...)
 
 	//v1 := router.Group("/api/v1")
 	//{
@@ -57,7 +60,7 @@ func SetupRouter(host string, port string) *gin.Engine {
 }
 
 // StartServer starts the server
-func StartServer(host string, port string) error {
-	router := SetupRouter(host, port)
+func StartServer(host string, port string, middlewares ...gin.HandlerFunc) error {
+	router := SetupRouter(host, port, middlewares...)
 	return router.Run(fmt.Sprintf("%s:%s", host, port))
 }
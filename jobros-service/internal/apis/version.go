@@ -6,6 +6,7 @@ const (
 	AppVersion = "<VERSION>"
 	// APIGroup is the group name of the API.
 	APIGroup = "jobros.io"
-	// APIVersion is the version of the API.
+	// APIVersion is the preferred version of the API, in which resources are
+	// stored. Other served versions convert to it through the internal types.
 	APIVersion = "v1alpha1"
 )
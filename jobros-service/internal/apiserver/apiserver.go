@@ -0,0 +1,236 @@
+// Package apiserver assembles the HTTP API of the service from the application
+// context: the stores, the middlewares, the routes of every API and the
+// controllers running alongside them.
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/install"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/invite"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/loginhistory"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/oauth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/password"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/user"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/server"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/app"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/openapi"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// setupTimeout bounds the creation of the stores and their indexes.
+const setupTimeout = 30 * time.Second
+
+// Server is the HTTP API of the service.
+type Server struct {
+	address string
+	// api serves the requests. It is replaced by one using the new database
+	// when MongoDB is reconnected.
+	api atomic.Pointer[api]
+}
+
+// api is the API assembled over a database.
+type api struct {
+	router *gin.Engine
+	// stop stops the controllers.
+	stop context.CancelFunc
+}
+
+// shared holds what the APIs assembled over successive databases share.
+type shared struct {
+	// geoIP resolves the locations of logins. It is nil without a database.
+	geoIP *loginhistory.GeoIPDatabase
+	// limiter is the rate limiter keeping its buckets in memory, which must
+	// outlive reconnections so that reconnecting does not lift the limits. It
+	// is nil when the buckets are kept in MongoDB or rate limiting is disabled.
+	limiter *server.RateLimiter
+}
+
+// newShared creates what the APIs assembled over successive databases share.
+func newShared(config app.AppConfig) (shared, error) {
+	var deps shared
+	if path := config.GeoIP.DatabasePath; path != "" {
+		geoIP, err := loginhistory.LoadGeoIPDatabase(path)
+		if err != nil {
+			return shared{}, err
+		}
+		deps.geoIP = geoIP
+	}
+	if config.RateLimit.Store != server.RateLimitStoreMongo {
+		limiter, err := server.SetupRateLimiter(config.RateLimit, nil)
+		if err != nil {
+			return shared{}, fmt.Errorf("failed to set up rate limiting: %w", err)
+		}
+		deps.limiter = limiter
+	}
+	return deps, nil
+}
+
+// New assembles the API configured by appCtx and starts its controllers. The
+// API is assembled again, with new stores, when appCtx reconnects to MongoDB.
+func New(appCtx *app.AppContext) (*Server, error) {
+	config := appCtx.Config
+	deps, err := newShared(config)
+	if err != nil {
+		return nil, err
+	}
+
+	_, database := appCtx.Mongo()
+	current, err := newAPI(appCtx, database, deps)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{address: fmt.Sprintf("%s:%d", config.Host, config.Port)}
+	s.api.Store(current)
+	appCtx.OnMongoReconnect(func(database *mongo.Database) error {
+		next, err := newAPI(appCtx, database, deps)
+		if err != nil {
+			return err
+		}
+		s.api.Swap(next).stop()
+		return nil
+	})
+	return s, nil
+}
+
+// newAPI assembles the routes of the API over database and starts its
+// controllers.
+func newAPI(appCtx *app.AppContext, database *mongo.Database, deps shared) (*api, error) {
+	config := appCtx.Config
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+
+	var middlewares []gin.HandlerFunc
+	limiter := deps.limiter
+	if config.RateLimit.Store == server.RateLimitStoreMongo {
+		var err error
+		limiter, err = server.SetupRateLimiter(config.RateLimit, database)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up rate limiting: %w", err)
+		}
+	}
+	if limiter != nil {
+		middlewares = append(middlewares, limiter.Middleware())
+	}
+
+	router := server.SetupRouter(config.Host, strconv.Itoa(config.Port), middlewares...)
+
+	passwords, err := password.NewPolicy(config.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the password policy: %w", err)
+	}
+	router.POST("/auth/password/check", password.CheckHandler(passwords))
+
+	scheme, err := install.NewScheme()
+	if err != nil {
+		return nil, err
+	}
+	admission, err := install.NewAdmission()
+	if err != nil {
+		return nil, err
+	}
+	var storeOpts []storage.Option
+	if config.Storage.ContinueKey != "" {
+		storeOpts = append(storeOpts, storage.WithContinueKey([]byte(config.Storage.ContinueKey)))
+	} else {
+		glog.Warning("storage.continueKey is not set: list continue tokens are only valid on this server until it restarts")
+	}
+	users, err := user.NewREST(ctx, database, scheme, admission, storeOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the users store: %w", err)
+	}
+	revocations, err := auth.NewMongoRevocationStore(ctx, database, appCtx.JWTManager.RefreshTokenTTL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the revocation store: %w", err)
+	}
+
+	statuses := user.NewStatusService(users.Store())
+	statuses.OnTransition(user.StatusSuspended, user.RevokeTokensHook(revocations))
+	statuses.OnTransition(user.StatusDeleted, user.RevokeTokensHook(revocations))
+	authMiddleware := auth.AuthMiddleware(appCtx.JWTManager,
+		auth.RevocationCheck(revocations),
+		auth.ActiveUserCheck(statuses),
+	)
+
+	discovery, err := install.NewDiscovery(scheme)
+	if err != nil {
+		return nil, err
+	}
+	discovery.RegisterRoutes(router)
+	doc, err := install.NewOpenAPI(scheme)
+	if err != nil {
+		return nil, err
+	}
+	openAPI, err := openapi.NewHandler(doc)
+	if err != nil {
+		return nil, err
+	}
+	openAPI.RegisterRoutes(router)
+
+	users.RegisterRoutes(router, authMiddleware)
+	router.PUT("/users/:uid/status", authMiddleware, user.UpdateStatusHandler(statuses))
+	router.POST("/auth/role", authMiddleware, auth.SwitchRoleHandler(appCtx.JWTManager, statuses, revocations))
+
+	clients, err := oauth.NewMongoStore(ctx, database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the OAuth store: %w", err)
+	}
+	logins, err := loginhistory.NewMongoStore(ctx, database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the login history store: %w", err)
+	}
+	registerLoginRoutes(router, authMiddleware,
+		oauth.NewServer(clients, appCtx.JWTManager, revocations),
+		logins, loginhistory.NewRecorder(logins, deps.geoIP, nil))
+
+	invites, err := invite.NewMongoStore(ctx, database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the invite store: %w", err)
+	}
+	gate, err := invite.NewGate(config.Registration, invites)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up registration: %w", err)
+	}
+	invite.NewServer(gate, invites, nil).RegisterRoutes(router, authMiddleware)
+	router.POST("/register", user.RegisterHandler(users, gate))
+
+	controllers, stop := context.WithCancel(context.Background())
+	go user.NewTokensFinalizer(users.Store(), revocations).Run(controllers)
+
+	return &api{router: router, stop: stop}, nil
+}
+
+// registerLoginRoutes mounts the OAuth server, whose logins recorder records,
+// and the login history of the caller in logins.
+func registerLoginRoutes(router gin.IRouter, authMiddleware gin.HandlerFunc, oauthServer *oauth.Server, logins loginhistory.Store, recorder *loginhistory.Recorder) {
+	oauthServer.OnLogin(loginhistory.OAuthLoginHook(recorder))
+	oauthServer.RegisterRoutes(router, authMiddleware)
+	router.GET("/auth/logins", authMiddleware, loginhistory.ListHandler(logins))
+}
+
+// Handler returns the handler serving the API.
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.api.Load().router.ServeHTTP(w, r)
+	})
+}
+
+// Run serves the API on the configured host and port.
+func (s *Server) Run() error {
+	return http.ListenAndServe(s.address, s.Handler())
+}
+
+// Close stops the controllers.
+func (s *Server) Close() {
+	s.api.Load().stop()
+}
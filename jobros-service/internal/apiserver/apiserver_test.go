@@ -0,0 +1,135 @@
+package apiserver
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/loginhistory"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/oauth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/server"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterLoginRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager, err := auth.NewJWTManager(auth.WithSecret([]byte("test-secret-key")))
+	require.NoError(t, err)
+	adminToken, err := jwtManager.GenerateAccessToken("admin1", auth.RoleAdmin)
+	require.NoError(t, err)
+	userToken, err := jwtManager.GenerateAccessToken("user1", auth.RoleClient)
+	require.NoError(t, err)
+
+	router := gin.New()
+	logins := loginhistory.NewMemoryStore()
+	oauthServer := oauth.NewServer(oauth.NewMemoryStore(), jwtManager, auth.NewMemoryRevocationStore())
+	registerLoginRoutes(router, auth.AuthMiddleware(jwtManager), oauthServer, logins, loginhistory.NewRecorder(logins, nil, nil))
+
+	serve := func(req *http.Request, token string) *httptest.ResponseRecorder {
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	postJSON := func(path, token string, body interface{}) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(data)))
+		req.Header.Set("Content-Type", "application/json")
+		return serve(req, token)
+	}
+
+	const redirectURI = "https://app.example.com/callback"
+	w := postJSON("/oauth/clients", adminToken, gin.H{"name": "App", "redirectUris": []string{redirectURI}, "scopes": []string{auth.ScopeProfileRead}})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var registered struct {
+		Client oauth.Client `json:"client"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &registered))
+	clientID := registered.Client.ID
+
+	verifier := "a-code-verifier-long-enough-for-the-test"
+	sum := sha256.Sum256([]byte(verifier))
+	w = postJSON("/oauth/authorize", userToken, gin.H{
+		"response_type":         "code",
+		"client_id":             clientID,
+		"redirect_uri":          redirectURI,
+		"scope":                 auth.ScopeProfileRead,
+		"code_challenge":        base64.RawURLEncoding.EncodeToString(sum[:]),
+		"code_challenge_method": "S256",
+		"approve":               true,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var authorization struct {
+		RedirectURI string `json:"redirectUri"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &authorization))
+	redirect, err := url.Parse(authorization.RedirectURI)
+	require.NoError(t, err)
+
+	exchange := func() int {
+		form := url.Values{
+			"grant_type":    {oauth.GrantTypeAuthorizationCode},
+			"code":          {redirect.Query().Get("code")},
+			"redirect_uri":  {redirectURI},
+			"code_verifier": {verifier},
+			"client_id":     {clientID},
+		}
+		req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set(loginhistory.DeviceIDHeader, "device-1")
+		return serve(req, "").Code
+	}
+	require.Equal(t, http.StatusOK, exchange())
+	// A replayed code is a failed login.
+	require.Equal(t, http.StatusBadRequest, exchange())
+
+	history := func(token string) []loginhistory.Event {
+		w := serve(httptest.NewRequest(http.MethodGet, "/auth/logins", nil), token)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var list struct {
+			Items []loginhistory.Event `json:"items"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		return list.Items
+	}
+	events := history(userToken)
+	require.Len(t, events, 2)
+	var outcomes []loginhistory.Outcome
+	for _, event := range events {
+		assert.Equal(t, "user1", event.UserID)
+		assert.Equal(t, loginhistory.MethodOAuth, event.Method)
+		outcomes = append(outcomes, event.Outcome)
+	}
+	assert.ElementsMatch(t, []loginhistory.Outcome{loginhistory.OutcomeSuccess, loginhistory.OutcomeFailure}, outcomes)
+
+	// Users only read their own history.
+	assert.Empty(t, history(adminToken))
+	w = serve(httptest.NewRequest(http.MethodGet, "/auth/logins", nil), "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestNewShared_RateLimiter(t *testing.T) {
+	deps, err := newShared(app.AppConfig{RateLimit: app.RateLimitConfig{Store: server.RateLimitStoreMemory}})
+	require.NoError(t, err)
+	// Buckets in memory are created once, to survive MongoDB reconnections.
+	assert.NotNil(t, deps.limiter)
+
+	deps, err = newShared(app.AppConfig{RateLimit: app.RateLimitConfig{Store: server.RateLimitStoreMongo}})
+	require.NoError(t, err)
+	assert.Nil(t, deps.limiter)
+
+	_, err = newShared(app.AppConfig{RateLimit: app.RateLimitConfig{Store: "redis"}})
+	assert.Error(t, err)
+}
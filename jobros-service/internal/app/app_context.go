@@ -5,43 +5,43 @@ import (
 	"flag"
 	"fmt"
 	"github.com/golang/glog"
-	"github.com/kelseyhightower/envconfig"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"gopkg.in/yaml.v3"
 	"log"
-	"os"
+	"path/filepath"
+	"sync"
 	"time"
 )
 
+// mongoDrainTimeout is how long the client replaced by a reconnection is kept,
+// so that the requests using it can complete.
+const mongoDrainTimeout = 30 * time.Second
+
+// minContinueKeyLength is the shortest key accepted to sign continue tokens.
+const minContinueKeyLength = 32
+
 type AppContext struct {
 	Config      AppConfig
 	MongoClient *mongo.Client
 	Database    *mongo.Database
+	JWTManager  *auth.JWTManager
+	Secrets     SecretProvider
+
+	stopWatchingSecrets context.CancelFunc
+
+	// mu guards MongoClient and Database once the secrets are watched.
+	mu                sync.RWMutex
+	reconnectHandlers []func(database *mongo.Database) error
+	// connect connects to MongoDB, for tests.
+	connect func(uri string, database string) (*mongo.Client, *mongo.Database, error)
 }
 
 // NewAppContext initializes and returns a new AppContext.
 func NewAppContext() (*AppContext, error) {
-	var config AppConfig
-
-	// Load config from file if provided, otherwise use env vars
-	configPath := os.Getenv("CONFIG_PATH")
-	if configPath != "" {
-		configFile, err := os.ReadFile(configPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read config file: %w", err)
-		}
-
-		err = yaml.Unmarshal(configFile, &config)
-		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal config file: %w", err)
-		}
-	}
-
-	// Process environment variables to override config file or defaults
-	err := envconfig.Process("", &config)
+	config, err := LoadConfig()
 	if err != nil {
-		return nil, fmt.Errorf("failed to process env vars: %w", err)
+		return nil, err
 	}
 
 	// Initialize glog with the log level from config
@@ -51,7 +51,20 @@ func NewAppContext() (*AppContext, error) {
 
 	glog.V(2).Info("Starting application with config:", config)
 
-	mongoClient, database, err := initMongo(config)
+	secrets, err := NewSecretProvider(config.Secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize secret provider: %w", err)
+	}
+	if err := resolveSecrets(&config, secrets); err != nil {
+		return nil, err
+	}
+
+	jwtManager, err := NewJWTManager(config.JWT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize JWT manager: %w", err)
+	}
+
+	mongoClient, database, err := connectMongo(config.Mongo.URI, config.Mongo.Database)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize mongo: %w", err)
 	}
@@ -60,27 +73,162 @@ func NewAppContext() (*AppContext, error) {
 		Config:      config,
 		MongoClient: mongoClient,
 		Database:    database,
+		JWTManager:  jwtManager,
+		Secrets:     secrets,
 	}
+	appCtx.watchSecrets()
 
 	return appCtx, nil
 }
 
-// initMongo initializes the MongoDB client and database
-func initMongo(config AppConfig) (*mongo.Client, *mongo.Database, error) {
+// resolveSecrets replaces the secrets of the configuration with the values held
+// by the secret provider.
+func resolveSecrets(config *AppConfig, secrets SecretProvider) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	uri, err := resolveSecret(ctx, secrets, SecretMongoURI, config.Mongo.URI)
+	if err != nil {
+		return fmt.Errorf("failed to read mongo uri secret: %w", err)
+	}
+	if uri == "" {
+		return fmt.Errorf("mongo.uri is required")
+	}
+	config.Mongo.URI = uri
+
+	if config.JWT.SecretKeyFile == "" {
+		key, err := resolveSecret(ctx, secrets, SecretJWTSecretKey, config.JWT.SecretKey)
+		if err != nil {
+			return fmt.Errorf("failed to read JWT secret key: %w", err)
+		}
+		config.JWT.SecretKey = key
+	}
+
+	continueKey, err := resolveSecret(ctx, secrets, SecretStorageContinueKey, config.Storage.ContinueKey)
+	if err != nil {
+		return fmt.Errorf("failed to read storage continue key: %w", err)
+	}
+	if continueKey != "" && len(continueKey) < minContinueKeyLength {
+		return fmt.Errorf("storage.continueKey must be at least %d bytes", minContinueKeyLength)
+	}
+	config.Storage.ContinueKey = continueKey
+	return nil
+}
+
+// watchSecrets applies rotated secrets until Close is called. The JWT secret
+// is swapped in place; a rotated mongo URI reconnects with a new client, see
+// OnMongoReconnect.
+func (a *AppContext) watchSecrets() {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopWatchingSecrets = cancel
+	interval := a.Config.Secrets.RefreshInterval
+
+	setJWTSecret := func(value string) error {
+		return a.JWTManager.SetSecret([]byte(value))
+	}
+	switch {
+	case a.Config.JWT.SecretKeyFile != "":
+		// A key file is typically mounted from a secret volume too.
+		keyFile := FileSecretProvider{Dir: filepath.Dir(a.Config.JWT.SecretKeyFile)}
+		name := filepath.Base(a.Config.JWT.SecretKeyFile)
+		initial, _ := keyFile.GetSecret(ctx, name)
+		go WatchSecret(ctx, keyFile, name, initial, interval, func(value string) error {
+			return a.JWTManager.SetSecret(auth.SecretFromFile([]byte(value)))
+		})
+	case a.Config.Secrets.Provider != "" && a.Config.Secrets.Provider != "env":
+		go WatchSecret(ctx, a.Secrets, SecretJWTSecretKey, a.Config.JWT.SecretKey, interval, setJWTSecret)
+	}
+
+	if a.Config.Secrets.Provider != "" && a.Config.Secrets.Provider != "env" {
+		go WatchSecret(ctx, a.Secrets, SecretMongoURI, a.Config.Mongo.URI, interval, a.reconnectMongo)
+	}
+}
+
+// Mongo returns the current MongoDB client and database.
+func (a *AppContext) Mongo() (*mongo.Client, *mongo.Database) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.MongoClient, a.Database
+}
+
+// OnMongoReconnect registers handler to be called with the database of a new
+// client when the mongo URI is rotated. The users of the database swap it in
+// from handler; when a handler fails, the previous client is kept and the
+// reconnection is retried at the next refresh of the secret.
+func (a *AppContext) OnMongoReconnect(handler func(database *mongo.Database) error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reconnectHandlers = append(a.reconnectHandlers, handler)
+}
+
+// reconnectMongo connects to MongoDB with uri and swaps the new client in,
+// disconnecting the previous one once the requests using it had time to
+// complete.
+func (a *AppContext) reconnectMongo(uri string) error {
+	connect := a.connect
+	if connect == nil {
+		connect = connectMongo
+	}
+	client, database, err := connect(uri, a.Config.Mongo.Database)
+	if err != nil {
+		return err
+	}
+
+	a.mu.RLock()
+	handlers := a.reconnectHandlers
+	a.mu.RUnlock()
+	for _, handler := range handlers {
+		if err := handler(database); err != nil {
+			go client.Disconnect(context.Background())
+			return err
+		}
+	}
+
+	a.mu.Lock()
+	previous := a.MongoClient
+	a.MongoClient, a.Database = client, database
+	a.mu.Unlock()
+	glog.Info("reconnected to MongoDB with the rotated mongo uri")
+
+	if previous != nil {
+		time.AfterFunc(mongoDrainTimeout, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := previous.Disconnect(ctx); err != nil {
+				glog.Errorf("failed to disconnect the previous mongo client: %v", err)
+			}
+		})
+	}
+	return nil
+}
+
+// Close stops watching secrets and disconnects from MongoDB.
+func (a *AppContext) Close(ctx context.Context) error {
+	if a.stopWatchingSecrets != nil {
+		a.stopWatchingSecrets()
+	}
+	client, _ := a.Mongo()
+	return client.Disconnect(ctx)
+}
+
+// connectMongo connects to the MongoDB server at uri and returns the client
+// and the database.
+func connectMongo(uri string, databaseName string) (*mongo.Client, *mongo.Database, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(config.Mongo.URI))
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to connect to mongo: %w", err)
 	}
 
 	err = client.Ping(ctx, nil)
 	if err != nil {
+		client.Disconnect(context.Background())
 		return nil, nil, fmt.Errorf("failed to ping mongo: %w", err)
 	}
 
-	database := client.Database(config.Mongo.Database)
+	database := client.Database(databaseName)
 	log.Println("Connected to MongoDB!")
 	glog.V(2).Info("Connected to MongoDB!")
 	return client, database, nil
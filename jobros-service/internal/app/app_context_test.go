@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestAppContext_ReconnectMongo(t *testing.T) {
+	// Clients connect lazily, so no server is needed until they are used.
+	connect := func(uri string, database string) (*mongo.Client, *mongo.Database, error) {
+		client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri))
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, client.Database(database), nil
+	}
+	first, database, err := connect("mongodb://first:27017", "jobros")
+	require.NoError(t, err)
+	appCtx := &AppContext{
+		Config:      AppConfig{Mongo: MongoConfig{Database: "jobros"}},
+		MongoClient: first,
+		Database:    database,
+		connect:     connect,
+	}
+
+	var swapped *mongo.Database
+	fail := true
+	appCtx.OnMongoReconnect(func(database *mongo.Database) error {
+		if fail {
+			return errors.New("failed to create indexes")
+		}
+		swapped = database
+		return nil
+	})
+
+	// The previous client is kept when a user of the database cannot swap
+	assert.Error(t, appCtx.reconnectMongo("mongodb://second:27017"))
+	client, _ := appCtx.Mongo()
+	assert.Same(t, first, client)
+
+	fail = false
+	require.NoError(t, appCtx.reconnectMongo("mongodb://second:27017"))
+	client, database = appCtx.Mongo()
+	assert.NotSame(t, first, client)
+	assert.Same(t, swapped, database)
+	assert.Equal(t, "jobros", database.Name())
+}
+
+func TestAppContext_WatchSecretKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt.key")
+	require.NoError(t, os.WriteFile(path, []byte("  first-secret\n"), 0o600))
+	config := AppConfig{
+		JWT:     JWTConfig{SecretKeyFile: path},
+		Secrets: SecretsConfig{RefreshInterval: 10 * time.Millisecond},
+	}
+	jwtManager, err := NewJWTManager(config.JWT)
+	require.NoError(t, err)
+	appCtx := &AppContext{Config: config, JWTManager: jwtManager}
+	appCtx.watchSecrets()
+	defer appCtx.stopWatchingSecrets()
+
+	// Signed with the secret the rotated key file holds, whitespace aside.
+	signedWith := func(secret string) bool {
+		token, err := jwtManager.GenerateAccessToken("user123", auth.RoleClient)
+		require.NoError(t, err)
+		verifier, err := auth.NewJWTManager(auth.WithSecret([]byte(secret)))
+		require.NoError(t, err)
+		_, err = verifier.GetTokenClaims(token, auth.TokenTypeAccess)
+		return err == nil
+	}
+	assert.True(t, signedWith("first-secret"))
+
+	require.NoError(t, os.WriteFile(path, []byte("  second-secret \n"), 0o600))
+	assert.Eventually(t, func() bool { return signedWith("second-secret") }, 2*time.Second, 10*time.Millisecond)
+}
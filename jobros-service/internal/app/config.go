@@ -1,26 +1,187 @@
 package app
 
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultHost              = "localhost"
+	defaultPort              = 8080
+	defaultInviteTTL         = 14 * 24 * time.Hour
+	defaultPasswordMinLength = 12
+	defaultPasswordMaxLength = 128
+)
+
 // LoggingConfig holds logging-related configuration
 type LoggingConfig struct {
-	Level int `yaml:"level" envconfig:"LOG_LEVEL" default:"0"`
+	Level int `yaml:"level" envconfig:"LOG_LEVEL"`
 }
 
-// JWTConfig holds JWT-related configuration
+// JWTConfig holds JWT-related configuration. Exactly one of SecretKey and
+// SecretKeyFile must be set; zero token lifetimes use the auth defaults.
 type JWTConfig struct {
-	SecretKey string `yaml:"secretKey" envconfig:"JWT_SECRET_KEY" required:"true"`
+	SecretKey       string        `yaml:"secretKey" envconfig:"JWT_SECRET_KEY"`
+	SecretKeyFile   string        `yaml:"secretKeyFile" envconfig:"JWT_SECRET_KEY_FILE"`
+	AccessTokenTTL  time.Duration `yaml:"accessTokenTTL" envconfig:"JWT_ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `yaml:"refreshTokenTTL" envconfig:"JWT_REFRESH_TOKEN_TTL"`
+	Issuer          string        `yaml:"issuer" envconfig:"JWT_ISSUER"`
 }
 
 // MongoConfig holds MongoDB-related configuration
 type MongoConfig struct {
-	URI      string `yaml:"uri" envconfig:"MONGO_URI" required:"true"`
-	Database string `yaml:"database" envconfig:"MONGO_DATABASE" required:"true"`
+	URI      string `yaml:"uri" envconfig:"MONGO_URI"`
+	Database string `yaml:"database" envconfig:"MONGO_DATABASE"`
+}
+
+// VaultConfig holds the configuration of the Vault KV secret provider
+type VaultConfig struct {
+	Address   string `yaml:"address" envconfig:"VAULT_ADDR"`
+	Token     string `yaml:"token" envconfig:"VAULT_TOKEN"`
+	TokenFile string `yaml:"tokenFile" envconfig:"VAULT_TOKEN_FILE"`
+	Mount     string `yaml:"mount" envconfig:"VAULT_KV_MOUNT"`
+	Path      string `yaml:"path" envconfig:"VAULT_SECRET_PATH"`
+}
+
+// StorageConfig holds the configuration of the stores. ContinueKey signs the
+// continue tokens of lists: replicas must share it for a list started on one
+// to continue on another, and across restarts. Without it, every server signs
+// with a random key.
+type StorageConfig struct {
+	ContinueKey string `yaml:"continueKey" envconfig:"STORAGE_CONTINUE_KEY"`
+}
+
+// SecretsConfig selects where secrets are read from: env (default), file or
+// vault. Secrets from file and vault override the configuration and are
+// refreshed every RefreshInterval (30s by default).
+type SecretsConfig struct {
+	Provider        string        `yaml:"provider" envconfig:"SECRETS_PROVIDER"`
+	Dir             string        `yaml:"dir" envconfig:"SECRETS_DIR"`
+	RefreshInterval time.Duration `yaml:"refreshInterval" envconfig:"SECRETS_REFRESH_INTERVAL"`
+	Vault           VaultConfig   `yaml:"vault"`
+}
+
+// PasswordConfig holds the password policy configuration. Passwords are 12
+// to 128 characters long by default, and the breached password corpus, a file
+// sorted by hash, reports the hashes seen at least once unless BreachedMinCount
+// is set.
+type PasswordConfig struct {
+	MinLength          int      `yaml:"minLength" envconfig:"PASSWORD_MIN_LENGTH"`
+	MaxLength          int      `yaml:"maxLength" envconfig:"PASSWORD_MAX_LENGTH"`
+	BannedPasswords    []string `yaml:"bannedPasswords" envconfig:"PASSWORD_BANNED"`
+	BreachedCorpusPath string   `yaml:"breachedCorpusPath" envconfig:"PASSWORD_BREACHED_CORPUS_PATH"`
+	BreachedMinCount   int      `yaml:"breachedMinCount" envconfig:"PASSWORD_BREACHED_MIN_COUNT"`
+}
+
+// RegistrationConfig holds the configuration of account registration. The
+// mode defaults to open and invites are valid for two weeks by default.
+type RegistrationConfig struct {
+	Mode      string        `yaml:"mode" envconfig:"REGISTRATION_MODE"`
+	InviteTTL time.Duration `yaml:"inviteTTL" envconfig:"REGISTRATION_INVITE_TTL"`
+}
+
+// GeoIPConfig holds the configuration of the offline GeoIP database
+type GeoIPConfig struct {
+	DatabasePath string `yaml:"databasePath" envconfig:"GEOIP_DATABASE_PATH"`
+}
+
+// RouteRateLimitConfig holds the rate limit applied to a single route. When the
+// store fails, requests are rejected unless FailOpen lets them through.
+type RouteRateLimitConfig struct {
+	Method       string        `yaml:"method"`
+	Path         string        `yaml:"path"`
+	Burst        int           `yaml:"burst"`
+	Period       time.Duration `yaml:"period"`
+	KeyBy        []string      `yaml:"keyBy"`
+	AccountField string        `yaml:"accountField"`
+	FailOpen     bool          `yaml:"failOpen"`
+}
+
+// RateLimitConfig holds rate limiting configuration. Rate limiting is enabled
+// unless Enabled is false, and Store defaults to memory.
+type RateLimitConfig struct {
+	Enabled *bool                  `yaml:"enabled" envconfig:"RATE_LIMIT_ENABLED"`
+	Store   string                 `yaml:"store" envconfig:"RATE_LIMIT_STORE"`
+	Routes  []RouteRateLimitConfig `yaml:"routes" ignored:"true"`
+}
+
+// IsEnabled reports whether rate limiting is enabled.
+func (c RateLimitConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
 }
 
 // AppConfig represents the configuration for the application
 type AppConfig struct {
-	Host    string        `yaml:"host" envconfig:"HOST" default:"localhost"`
-	Port    int           `yaml:"port" envconfig:"PORT" default:"8080"`
-	Mongo   MongoConfig   `yaml:"mongo"`
-	Logging LoggingConfig `yaml:"logging"`
-	JWT     JWTConfig     `yaml:"jwt"`
+	Host         string             `yaml:"host" envconfig:"HOST"`
+	Port         int                `yaml:"port" envconfig:"PORT"`
+	Mongo        MongoConfig        `yaml:"mongo"`
+	Storage      StorageConfig      `yaml:"storage"`
+	Logging      LoggingConfig      `yaml:"logging"`
+	JWT          JWTConfig          `yaml:"jwt"`
+	Secrets      SecretsConfig      `yaml:"secrets"`
+	RateLimit    RateLimitConfig    `yaml:"rateLimit"`
+	GeoIP        GeoIPConfig        `yaml:"geoip"`
+	Password     PasswordConfig     `yaml:"password"`
+	Registration RegistrationConfig `yaml:"registration"`
+}
+
+// LoadConfig reads the configuration from the YAML file at CONFIG_PATH, when
+// set, and from the environment, which overrides the file. Defaults are only
+// applied to the fields neither of them set.
+func LoadConfig() (AppConfig, error) {
+	var config AppConfig
+
+	if configPath := os.Getenv("CONFIG_PATH"); configPath != "" {
+		configFile, err := os.ReadFile(configPath)
+		if err != nil {
+			return config, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := yaml.Unmarshal(configFile, &config); err != nil {
+			return config, fmt.Errorf("failed to unmarshal config file: %w", err)
+		}
+	}
+
+	// The struct tags have no defaults: envconfig would apply them over the
+	// values of the file.
+	if err := envconfig.Process("", &config); err != nil {
+		return config, fmt.Errorf("failed to process env vars: %w", err)
+	}
+	config.setDefaults()
+
+	if config.Mongo.Database == "" {
+		return config, fmt.Errorf("mongo.database is required")
+	}
+	return config, nil
+}
+
+// setDefaults sets the fields left empty to their defaults.
+func (c *AppConfig) setDefaults() {
+	if c.Host == "" {
+		c.Host = defaultHost
+	}
+	if c.Port == 0 {
+		c.Port = defaultPort
+	}
+	if c.RateLimit.Store == "" {
+		c.RateLimit.Store = "memory"
+	}
+	if c.Password.MinLength == 0 {
+		c.Password.MinLength = defaultPasswordMinLength
+	}
+	if c.Password.MaxLength == 0 {
+		c.Password.MaxLength = defaultPasswordMaxLength
+	}
+	if c.Password.BreachedMinCount == 0 {
+		c.Password.BreachedMinCount = 1
+	}
+	if c.Registration.Mode == "" {
+		c.Registration.Mode = "open"
+	}
+	if c.Registration.InviteTTL == 0 {
+		c.Registration.InviteTTL = defaultInviteTTL
+	}
 }
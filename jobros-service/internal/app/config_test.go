@@ -0,0 +1,87 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unsetEnv unsets the environment variables for the duration of the test.
+func unsetEnv(t *testing.T, keys ...string) {
+	for _, key := range keys {
+		// Setenv restores the previous value when the test ends.
+		t.Setenv(key, "")
+		require.NoError(t, os.Unsetenv(key))
+	}
+}
+
+func writeConfig(t *testing.T, content string) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	t.Setenv("CONFIG_PATH", path)
+}
+
+func TestLoadConfig_File(t *testing.T) {
+	unsetEnv(t, "HOST", "PORT", "MONGO_DATABASE", "REGISTRATION_MODE", "REGISTRATION_INVITE_TTL", "PASSWORD_MIN_LENGTH")
+	writeConfig(t, `
+port: 9090
+mongo:
+  database: jobros
+registration:
+  mode: invite-only
+`)
+
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "invite-only", config.Registration.Mode)
+	assert.Equal(t, 9090, config.Port)
+	assert.Equal(t, "jobros", config.Mongo.Database)
+	// The fields the file leaves out get their defaults.
+	assert.Equal(t, "localhost", config.Host)
+	assert.Equal(t, 14*24*time.Hour, config.Registration.InviteTTL)
+	assert.Equal(t, 12, config.Password.MinLength)
+}
+
+func TestLoadConfig_PasswordFile(t *testing.T) {
+	unsetEnv(t, "MONGO_DATABASE", "PASSWORD_MIN_LENGTH", "PASSWORD_MAX_LENGTH", "PASSWORD_BREACHED_MIN_COUNT")
+	writeConfig(t, `
+mongo:
+  database: jobros
+password:
+  minLength: 16
+  breachedMinCount: 5
+`)
+
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 16, config.Password.MinLength)
+	assert.Equal(t, 5, config.Password.BreachedMinCount)
+	assert.Equal(t, 128, config.Password.MaxLength)
+}
+
+func TestLoadConfig_EnvOverridesFile(t *testing.T) {
+	unsetEnv(t, "HOST", "PORT", "MONGO_DATABASE", "REGISTRATION_INVITE_TTL")
+	writeConfig(t, `
+mongo:
+  database: jobros
+registration:
+  mode: invite-only
+`)
+	t.Setenv("REGISTRATION_MODE", "closed")
+
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "closed", config.Registration.Mode)
+	assert.Equal(t, 8080, config.Port)
+}
+
+func TestLoadConfig_DatabaseRequired(t *testing.T) {
+	unsetEnv(t, "CONFIG_PATH", "MONGO_DATABASE")
+
+	_, err := LoadConfig()
+	assert.ErrorContains(t, err, "mongo.database is required")
+}
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+)
+
+// NewJWTManager creates the JWT manager described by the JWT configuration.
+func NewJWTManager(config JWTConfig, opts ...auth.JWTOption) (*auth.JWTManager, error) {
+	switch {
+	case config.SecretKey != "" && config.SecretKeyFile != "":
+		return nil, fmt.Errorf("only one of jwt.secretKey and jwt.secretKeyFile can be set")
+	case config.SecretKeyFile != "":
+		opts = append(opts, auth.WithSecretFile(config.SecretKeyFile))
+	case config.SecretKey != "":
+		opts = append(opts, auth.WithSecret([]byte(config.SecretKey)))
+	default:
+		return nil, fmt.Errorf("jwt.secretKey or jwt.secretKeyFile is required")
+	}
+
+	opts = append(opts,
+		auth.WithTokenTTLs(config.AccessTokenTTL, config.RefreshTokenTTL),
+		auth.WithIssuer(config.Issuer),
+	)
+	return auth.NewJWTManager(opts...)
+}
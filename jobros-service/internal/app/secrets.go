@@ -0,0 +1,230 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// Names of the secrets resolved through the secret provider. They match the
+// environment variables holding the same values.
+const (
+	SecretMongoURI           = "MONGO_URI"
+	SecretJWTSecretKey       = "JWT_SECRET_KEY"
+	SecretStorageContinueKey = "STORAGE_CONTINUE_KEY"
+)
+
+const (
+	defaultSecretsRefreshInterval = 30 * time.Second
+	defaultVaultMount             = "secret"
+)
+
+// ErrSecretNotFound is returned by a SecretProvider that does not hold the secret.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretProvider resolves secrets by name.
+type SecretProvider interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// NewSecretProvider creates the secret provider selected by the configuration.
+func NewSecretProvider(config SecretsConfig) (SecretProvider, error) {
+	switch config.Provider {
+	case "", "env":
+		return EnvSecretProvider{}, nil
+	case "file":
+		if config.Dir == "" {
+			return nil, fmt.Errorf("secrets.dir is required for the file secret provider")
+		}
+		return FileSecretProvider{Dir: config.Dir}, nil
+	case "vault":
+		return NewVaultSecretProvider(config.Vault)
+	default:
+		return nil, fmt.Errorf("unknown secret provider %q", config.Provider)
+	}
+}
+
+// EnvSecretProvider reads secrets from environment variables of the same name.
+type EnvSecretProvider struct{}
+
+func (EnvSecretProvider) GetSecret(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	return value, nil
+}
+
+// FileSecretProvider reads each secret from the file of the same name in Dir,
+// as laid out by a Kubernetes secret volume. Trailing newlines are ignored.
+type FileSecretProvider struct {
+	Dir string
+}
+
+func (p FileSecretProvider) GetSecret(_ context.Context, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	value, err := os.ReadFile(filepath.Join(p.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+	return strings.TrimRight(string(value), "\r\n"), nil
+}
+
+// VaultSecretProvider reads secrets from the keys of a secret in a Vault KV
+// version 2 engine.
+type VaultSecretProvider struct {
+	address   string
+	token     string
+	tokenFile string
+	mount     string
+	path      string
+	client    *http.Client
+}
+
+// NewVaultSecretProvider creates a VaultSecretProvider. When TokenFile is set,
+// the token is read from it on every request, so that a token rotated by an
+// agent is picked up.
+func NewVaultSecretProvider(config VaultConfig) (*VaultSecretProvider, error) {
+	if config.Address == "" || config.Path == "" {
+		return nil, fmt.Errorf("secrets.vault.address and secrets.vault.path are required for the vault secret provider")
+	}
+	provider := &VaultSecretProvider{token: config.Token, tokenFile: config.TokenFile}
+	// Fail at startup rather than at the first read.
+	if _, err := provider.readToken(); err != nil {
+		return nil, err
+	}
+	mount := config.Mount
+	if mount == "" {
+		mount = defaultVaultMount
+	}
+
+	provider.address = strings.TrimRight(config.Address, "/")
+	provider.mount = strings.Trim(mount, "/")
+	provider.path = strings.Trim(config.Path, "/")
+	provider.client = &http.Client{Timeout: 10 * time.Second}
+	return provider, nil
+}
+
+// readToken returns the current Vault token.
+func (p *VaultSecretProvider) readToken() (string, error) {
+	if p.tokenFile == "" {
+		return p.token, nil
+	}
+	value, err := os.ReadFile(p.tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read vault token: %w", err)
+	}
+	return strings.TrimSpace(string(value)), nil
+}
+
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+}
+
+func (p *VaultSecretProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	token, err := p.readToken()
+	if err != nil {
+		return "", err
+	}
+	endpoint := fmt.Sprintf("%s/v1/%s/data/%s", p.address, url.PathEscape(p.mount), p.path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read vault secret: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return "", fmt.Errorf("failed to read vault secret: %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+
+	var kv vaultKVResponse
+	if err := json.NewDecoder(res.Body).Decode(&kv); err != nil {
+		return "", fmt.Errorf("failed to decode vault secret: %w", err)
+	}
+	value, ok := kv.Data.Data[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s is not a string", name)
+	}
+	return s, nil
+}
+
+// WatchSecret polls the secret every interval and calls onChange with its new
+// value whenever it changes, until ctx is done. initial is the value already
+// in use. Failed reads are logged and retried at the next interval.
+func WatchSecret(ctx context.Context, provider SecretProvider, name string, initial string, interval time.Duration, onChange func(value string) error) {
+	if interval <= 0 {
+		interval = defaultSecretsRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	current := initial
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		value, err := provider.GetSecret(ctx, name)
+		if err != nil {
+			if ctx.Err() == nil {
+				glog.Errorf("failed to refresh secret %s: %v", name, err)
+			}
+			continue
+		}
+		if value == current {
+			continue
+		}
+		if err := onChange(value); err != nil {
+			glog.Errorf("failed to apply rotated secret %s: %v", name, err)
+			continue
+		}
+		current = value
+		glog.Infof("secret %s was rotated", name)
+	}
+}
+
+// resolveSecret returns the secret from the provider, or fallback when the
+// provider does not hold it.
+func resolveSecret(ctx context.Context, provider SecretProvider, name string, fallback string) (string, error) {
+	value, err := provider.GetSecret(ctx, name)
+	if errors.Is(err, ErrSecretNotFound) {
+		return fallback, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return value, nil
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvSecretProvider(t *testing.T) {
+	t.Setenv("JOBROS_TEST_SECRET", "from-env")
+	provider := EnvSecretProvider{}
+
+	value, err := provider.GetSecret(context.Background(), "JOBROS_TEST_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+
+	_, err = provider.GetSecret(context.Background(), "JOBROS_TEST_MISSING_SECRET")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+}
+
+func TestFileSecretProvider(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SecretMongoURI), []byte("mongodb://db:27017\n"), 0o600))
+	provider := FileSecretProvider{Dir: dir}
+
+	value, err := provider.GetSecret(context.Background(), SecretMongoURI)
+	require.NoError(t, err)
+	assert.Equal(t, "mongodb://db:27017", value)
+
+	_, err = provider.GetSecret(context.Background(), SecretJWTSecretKey)
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+
+	_, err = provider.GetSecret(context.Background(), "../etc/passwd")
+	assert.Error(t, err)
+}
+
+func TestResolveSecrets_ContinueKey(t *testing.T) {
+	dir := t.TempDir()
+	key := "0123456789abcdef0123456789abcdef"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SecretStorageContinueKey), []byte(key), 0o600))
+	config := AppConfig{Mongo: MongoConfig{URI: "mongodb://db:27017"}, Storage: StorageConfig{ContinueKey: "from-config"}}
+
+	require.NoError(t, resolveSecrets(&config, FileSecretProvider{Dir: dir}))
+	assert.Equal(t, key, config.Storage.ContinueKey)
+
+	config.Storage.ContinueKey = "too-short"
+	assert.ErrorContains(t, resolveSecrets(&config, FileSecretProvider{Dir: t.TempDir()}), "at least 32 bytes")
+}
+
+func TestVaultSecretProvider(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/jobros/core" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"JWT_SECRET_KEY":"from-vault"},"metadata":{"version":3}}}`))
+	}))
+	defer vault.Close()
+	ctx := context.Background()
+
+	provider, err := NewVaultSecretProvider(VaultConfig{Address: vault.URL, Token: "test-token", Mount: "kv", Path: "jobros/core"})
+	require.NoError(t, err)
+
+	value, err := provider.GetSecret(ctx, SecretJWTSecretKey)
+	require.NoError(t, err)
+	assert.Equal(t, "from-vault", value)
+
+	_, err = provider.GetSecret(ctx, SecretMongoURI)
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+
+	unauthorized, err := NewVaultSecretProvider(VaultConfig{Address: vault.URL, Token: "wrong", Mount: "kv", Path: "jobros/core"})
+	require.NoError(t, err)
+	_, err = unauthorized.GetSecret(ctx, SecretJWTSecretKey)
+	assert.ErrorContains(t, err, "permission denied")
+
+	// A token file is read again on every request.
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("expired-token\n"), 0o600))
+	rotated, err := NewVaultSecretProvider(VaultConfig{Address: vault.URL, TokenFile: tokenFile, Mount: "kv", Path: "jobros/core"})
+	require.NoError(t, err)
+	_, err = rotated.GetSecret(ctx, SecretJWTSecretKey)
+	assert.ErrorContains(t, err, "permission denied")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("test-token\n"), 0o600))
+	value, err = rotated.GetSecret(ctx, SecretJWTSecretKey)
+	require.NoError(t, err)
+	assert.Equal(t, "from-vault", value)
+}
+
+func TestWatchSecret(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, SecretJWTSecretKey)
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan string, 1)
+	go WatchSecret(ctx, FileSecretProvider{Dir: dir}, SecretJWTSecretKey, "first", 10*time.Millisecond, func(value string) error {
+		changes <- value
+		return nil
+	})
+
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0o600))
+	select {
+	case value := <-changes:
+		assert.Equal(t, "second", value)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the rotated secret was not applied")
+	}
+}
+
+func TestNewJWTManager_Config(t *testing.T) {
+	_, err := NewJWTManager(JWTConfig{})
+	assert.Error(t, err)
+
+	_, err = NewJWTManager(JWTConfig{SecretKey: "inline", SecretKeyFile: "/run/secrets/jwt"})
+	assert.Error(t, err)
+
+	manager, err := NewJWTManager(JWTConfig{SecretKey: "inline", AccessTokenTTL: 5 * time.Minute})
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, manager.AccessTokenTTL())
+}
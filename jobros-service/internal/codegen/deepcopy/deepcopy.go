@@ -0,0 +1,513 @@
+// Package deepcopy generates DeepCopyInto, DeepCopy and DeepCopyObject methods
+// for the structs of a package annotated with a marker comment:
+//
+//	// User represents a user in the system.
+//	//
+//	// +jobros:deepcopy-gen=true
+//	type User struct { ... }
+//
+// The +jobros:deepcopy-gen:object=true marker also generates DeepCopyObject,
+// making the type implement runtime.Object together with GetGroupVersionKind.
+package deepcopy
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// OutputFile is the name of the file the methods are generated into.
+	OutputFile = "zz_generated.deepcopy.go"
+
+	typeMarker   = "+jobros:deepcopy-gen=true"
+	objectMarker = "+jobros:deepcopy-gen:object=true"
+
+	runtimePackage = "github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	header         = "// Code generated by deepcopy-gen. DO NOT EDIT.\n\n"
+)
+
+// basicTypes are the predeclared types, copied by assignment.
+var basicTypes = map[string]bool{
+	"bool": true, "string": true, "byte": true, "rune": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+}
+
+// valueTypes are the types of other packages copied by assignment. Other
+// imported types must have a DeepCopyInto method.
+var valueTypes = map[string]bool{
+	"time.Time":          true,
+	"time.Duration":      true,
+	"time.Month":         true,
+	"time.Weekday":       true,
+	"primitive.ObjectID": true,
+}
+
+// typeDecl is a type declared in the package.
+type typeDecl struct {
+	name    string
+	spec    *ast.TypeSpec
+	marked  bool
+	object  bool
+	imports map[string]string // package name to import path
+}
+
+type generator struct {
+	fset    *token.FileSet
+	pkgName string
+	types   map[string]*typeDecl
+	ordered []*typeDecl
+	imports map[string]string // import paths used by the generated code, by name
+	deep    map[string]bool   // memoized needsDeepCopy of named local types
+	body    bytes.Buffer
+	current *typeDecl // type whose methods are being generated
+}
+
+// Generate returns the source of the deepcopy methods of the annotated types
+// of the package in dir, or nil if no type is annotated. Test files and the
+// output file are ignored.
+func Generate(dir string) ([]byte, error) {
+	g := &generator{
+		fset:    token.NewFileSet(),
+		types:   make(map[string]*typeDecl),
+		imports: make(map[string]string),
+		deep:    make(map[string]bool),
+	}
+	if err := g.parse(dir); err != nil {
+		return nil, err
+	}
+
+	marked := false
+	for _, decl := range g.ordered {
+		if !decl.marked {
+			continue
+		}
+		marked = true
+		if err := g.generate(decl); err != nil {
+			return nil, err
+		}
+	}
+	if !marked {
+		return nil, nil
+	}
+
+	var src bytes.Buffer
+	src.WriteString(header)
+	fmt.Fprintf(&src, "package %s\n\n", g.pkgName)
+	if len(g.imports) > 0 {
+		names := make([]string, 0, len(g.imports))
+		for name := range g.imports {
+			names = append(names, name)
+		}
+		// Standard library packages come first, in their own group.
+		sort.Slice(names, func(i, j int) bool {
+			pi, pj := g.imports[names[i]], g.imports[names[j]]
+			if isStandard(pi) != isStandard(pj) {
+				return isStandard(pi)
+			}
+			return pi < pj
+		})
+		src.WriteString("import (\n")
+		for i, name := range names {
+			path := g.imports[name]
+			if i > 0 && isStandard(g.imports[names[i-1]]) && !isStandard(path) {
+				src.WriteString("\n")
+			}
+			if filepath.Base(path) == name {
+				fmt.Fprintf(&src, "%q\n", path)
+			} else {
+				fmt.Fprintf(&src, "%s %q\n", name, path)
+			}
+		}
+		src.WriteString(")\n\n")
+	}
+	src.Write(g.body.Bytes())
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return out, nil
+}
+
+func isStandard(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+// parse collects the type declarations of the package in dir.
+func (g *generator) parse(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || name == OutputFile {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		file, err := parser.ParseFile(g.fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		if g.pkgName == "" {
+			g.pkgName = file.Name.Name
+		} else if g.pkgName != file.Name.Name {
+			return fmt.Errorf("%s: found packages %s and %s", dir, g.pkgName, file.Name.Name)
+		}
+
+		imports := make(map[string]string)
+		for _, spec := range file.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			name := filepath.Base(path)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imports[name] = path
+		}
+
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.TypeSpec)
+				doc := spec.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				decl := &typeDecl{name: spec.Name.Name, spec: spec, imports: imports}
+				decl.object = hasMarker(doc, objectMarker)
+				decl.marked = decl.object || hasMarker(doc, typeMarker)
+				if decl.marked {
+					if _, ok := spec.Type.(*ast.StructType); !ok {
+						return fmt.Errorf("%s: %s is not a struct", g.fset.Position(spec.Pos()), decl.name)
+					}
+					if spec.TypeParams != nil {
+						return fmt.Errorf("%s: generic type %s is not supported", g.fset.Position(spec.Pos()), decl.name)
+					}
+				}
+				g.types[decl.name] = decl
+				g.ordered = append(g.ordered, decl)
+			}
+		}
+	}
+	if g.pkgName == "" {
+		return fmt.Errorf("%s: no Go files", dir)
+	}
+	return nil
+}
+
+func hasMarker(doc *ast.CommentGroup, marker string) bool {
+	if doc == nil {
+		return false
+	}
+	for _, comment := range doc.List {
+		if strings.TrimSpace(strings.TrimPrefix(comment.Text, "//")) == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// generate writes the methods of decl.
+func (g *generator) generate(decl *typeDecl) error {
+	g.current = decl
+	name := decl.name
+	w := &g.body
+
+	fmt.Fprintf(w, "// DeepCopyInto copies the receiver into out. in must be non-nil.\n")
+	fmt.Fprintf(w, "func (in *%s) DeepCopyInto(out *%s) {\n", name, name)
+	fmt.Fprintf(w, "*out = *in\n")
+	if err := g.copyFields(decl.spec.Type.(*ast.StructType), "in", "out", 0); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "}\n\n")
+
+	fmt.Fprintf(w, "// DeepCopy creates a new %s copying the receiver.\n", name)
+	fmt.Fprintf(w, "func (in *%s) DeepCopy() *%s {\n", name, name)
+	fmt.Fprintf(w, "if in == nil {\nreturn nil\n}\n")
+	fmt.Fprintf(w, "out := new(%s)\nin.DeepCopyInto(out)\nreturn out\n}\n\n", name)
+
+	if decl.object {
+		object := "Object"
+		if g.pkgName != "runtime" {
+			g.imports["runtime"] = runtimePackage
+			object = "runtime.Object"
+		}
+		fmt.Fprintf(w, "// DeepCopyObject copies the receiver, creating a new %s.\n", object)
+		fmt.Fprintf(w, "func (in *%s) DeepCopyObject() %s {\n", name, object)
+		fmt.Fprintf(w, "if c := in.DeepCopy(); c != nil {\nreturn c\n}\nreturn nil\n}\n\n")
+	}
+	return nil
+}
+
+// copyFields writes the copy of the fields of a struct needing a deep copy,
+// out having already been assigned in.
+func (g *generator) copyFields(st *ast.StructType, in, out string, depth int) error {
+	for _, field := range st.Fields.List {
+		names := make([]string, 0, len(field.Names))
+		for _, ident := range field.Names {
+			names = append(names, ident.Name)
+		}
+		if len(names) == 0 {
+			embedded, err := embeddedName(field.Type)
+			if err != nil {
+				return fmt.Errorf("%s: %w", g.fset.Position(field.Pos()), err)
+			}
+			names = append(names, embedded)
+		}
+
+		deep, err := g.needsDeepCopy(field.Type)
+		if err != nil {
+			return fmt.Errorf("%s: %w", g.fset.Position(field.Pos()), err)
+		}
+		if !deep {
+			continue
+		}
+		for _, name := range names {
+			if name == "_" {
+				continue
+			}
+			if err := g.copyDeep(field.Type, in+"."+name, out+"."+name, depth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func embeddedName(expr ast.Expr) (string, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name, nil
+	case *ast.SelectorExpr:
+		return t.Sel.Name, nil
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	}
+	return "", fmt.Errorf("unsupported embedded field type %T", expr)
+}
+
+// needsDeepCopy reports whether values of type expr share memory when
+// assigned, and so must be copied field by field.
+func (g *generator) needsDeepCopy(expr ast.Expr) (bool, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if basicTypes[t.Name] {
+			return false, nil
+		}
+		decl, ok := g.types[t.Name]
+		if !ok {
+			return false, fmt.Errorf("unknown type %s", t.Name)
+		}
+		if deep, ok := g.deep[t.Name]; ok {
+			return deep, nil
+		}
+		// Named types can only contain themselves through pointers, slices or
+		// maps, which always need a deep copy, so the recursion ends.
+		deep, err := g.needsDeepCopy(decl.spec.Type)
+		if err != nil {
+			return false, err
+		}
+		if deep && !decl.marked {
+			if _, ok := decl.spec.Type.(*ast.StructType); ok {
+				return false, fmt.Errorf("struct %s needs a deep copy: annotate it with %s", t.Name, typeMarker)
+			}
+		}
+		g.deep[t.Name] = deep
+		return deep, nil
+	case *ast.SelectorExpr:
+		return !valueTypes[g.typeString(t)], nil
+	case *ast.StarExpr, *ast.MapType:
+		return true, nil
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return true, nil
+		}
+		return g.needsDeepCopy(t.Elt)
+	case *ast.StructType:
+		for _, field := range t.Fields.List {
+			deep, err := g.needsDeepCopy(field.Type)
+			if deep || err != nil {
+				return deep, err
+			}
+		}
+		return false, nil
+	case *ast.ParenExpr:
+		return g.needsDeepCopy(t.X)
+	}
+	return false, fmt.Errorf("type %s cannot be deep copied", g.typeString(expr))
+}
+
+// copyDeep writes the copy of in into out, both expressions of type expr
+// needing a deep copy. out may already hold a shallow copy of in.
+func (g *generator) copyDeep(expr ast.Expr, in, out string, depth int) error {
+	w := &g.body
+	switch t := expr.(type) {
+	case *ast.ParenExpr:
+		return g.copyDeep(t.X, in, out, depth)
+
+	case *ast.Ident:
+		decl := g.types[t.Name]
+		if decl.marked {
+			fmt.Fprintf(w, "%s.DeepCopyInto(&%s)\n", in, out)
+			return nil
+		}
+		return g.copyDeep(decl.spec.Type, in, out, depth)
+
+	case *ast.SelectorExpr:
+		fmt.Fprintf(w, "%s.DeepCopyInto(&%s)\n", in, out)
+		return nil
+
+	case *ast.StarExpr:
+		deep, err := g.needsDeepCopy(t.X)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "if %s != nil {\n", in)
+		fmt.Fprintf(w, "%s = new(%s)\n", out, g.useType(t.X))
+		switch {
+		case !deep:
+			fmt.Fprintf(w, "*%s = *%s\n", out, in)
+		case g.hasDeepCopyInto(t.X):
+			fmt.Fprintf(w, "%s.DeepCopyInto(%s)\n", in, out)
+		default:
+			fmt.Fprintf(w, "*%s = *%s\n", out, in)
+			if err := g.copyDeep(t.X, "(*"+in+")", "(*"+out+")", depth+1); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(w, "}\n")
+		return nil
+
+	case *ast.ArrayType:
+		deep, err := g.needsDeepCopy(t.Elt)
+		if err != nil {
+			return err
+		}
+		index := loopVar("i", depth)
+		if t.Len != nil {
+			fmt.Fprintf(w, "%s = %s\n", out, in)
+			fmt.Fprintf(w, "for %s := range %s {\n", index, in)
+			if err := g.copyDeep(t.Elt, in+"["+index+"]", out+"["+index+"]", depth+1); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "}\n")
+			return nil
+		}
+		fmt.Fprintf(w, "if %s != nil {\n", in)
+		fmt.Fprintf(w, "%s = make(%s, len(%s))\n", out, g.useType(t), in)
+		if !deep {
+			fmt.Fprintf(w, "copy(%s, %s)\n", out, in)
+		} else {
+			fmt.Fprintf(w, "for %s := range %s {\n", index, in)
+			if g.hasDeepCopyInto(t.Elt) {
+				fmt.Fprintf(w, "%s[%s].DeepCopyInto(&%s[%s])\n", in, index, out, index)
+			} else {
+				fmt.Fprintf(w, "%s[%s] = %s[%s]\n", out, index, in, index)
+				if err := g.copyDeep(t.Elt, in+"["+index+"]", out+"["+index+"]", depth+1); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(w, "}\n")
+		}
+		fmt.Fprintf(w, "}\n")
+		return nil
+
+	case *ast.MapType:
+		deep, err := g.needsDeepCopy(t.Value)
+		if err != nil {
+			return err
+		}
+		key, val := loopVar("key", depth), loopVar("val", depth)
+		fmt.Fprintf(w, "if %s != nil {\n", in)
+		fmt.Fprintf(w, "%s = make(%s, len(%s))\n", out, g.useType(t), in)
+		fmt.Fprintf(w, "for %s, %s := range %s {\n", key, val, in)
+		if !deep {
+			fmt.Fprintf(w, "%s[%s] = %s\n", out, key, val)
+		} else {
+			copied := loopVar("copied", depth)
+			if g.hasDeepCopyInto(t.Value) {
+				fmt.Fprintf(w, "var %s %s\n", copied, g.useType(t.Value))
+				fmt.Fprintf(w, "%s.DeepCopyInto(&%s)\n", val, copied)
+			} else {
+				fmt.Fprintf(w, "%s := %s\n", copied, val)
+				if err := g.copyDeep(t.Value, val, copied, depth+1); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(w, "%s[%s] = %s\n", out, key, copied)
+		}
+		fmt.Fprintf(w, "}\n}\n")
+		return nil
+
+	case *ast.StructType:
+		return g.copyFields(t, in, out, depth)
+	}
+	return fmt.Errorf("type %s cannot be deep copied", g.typeString(expr))
+}
+
+// hasDeepCopyInto reports whether expr names a type with a DeepCopyInto method.
+func (g *generator) hasDeepCopyInto(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		decl, ok := g.types[t.Name]
+		return ok && decl.marked
+	case *ast.SelectorExpr:
+		return !valueTypes[g.typeString(t)]
+	}
+	return false
+}
+
+func loopVar(name string, depth int) string {
+	if depth == 0 {
+		return name
+	}
+	return name + strconv.Itoa(depth)
+}
+
+func (g *generator) typeString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = printer.Fprint(&buf, g.fset, expr)
+	return buf.String()
+}
+
+// useType returns the source of the type expr, importing the packages it
+// refers to.
+func (g *generator) useType(expr ast.Expr) string {
+	ast.Inspect(expr, func(node ast.Node) bool {
+		sel, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if ident, ok := sel.X.(*ast.Ident); ok {
+			if path, ok := g.current.imports[ident.Name]; ok {
+				g.imports[ident.Name] = path
+			}
+		}
+		return false
+	})
+	return g.typeString(expr)
+}
@@ -0,0 +1,69 @@
+package deepcopy
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGenerate_Golden(t *testing.T) {
+	dir := filepath.Join("testdata", "listing")
+	got, err := Generate(dir)
+	require.NoError(t, err)
+
+	golden := filepath.Join(dir, OutputFile+".golden")
+	if *update {
+		require.NoError(t, os.WriteFile(golden, got, 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	tests := []struct {
+		dir     string
+		wantErr string
+	}{
+		{"unannotated", "struct Address needs a deep copy: annotate it with +jobros:deepcopy-gen=true"},
+		{"interface", "type interface{} cannot be deep copied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			_, err := Generate(filepath.Join("testdata", tt.dir))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestGenerate_NoAnnotatedTypes(t *testing.T) {
+	src, err := Generate("../../../cmd/deepcopy-gen")
+	require.NoError(t, err)
+	assert.Nil(t, src)
+}
+
+// TestGenerate_UpToDate fails when an annotated type of the repository changed
+// without running go generate.
+func TestGenerate_UpToDate(t *testing.T) {
+	for _, dir := range []string{
+		"../../../runtime",
+		"../../apis/identity/user",
+		"../../apis/v1alpha1/identity/user",
+	} {
+		t.Run(dir, func(t *testing.T) {
+			want, err := Generate(dir)
+			require.NoError(t, err)
+			got, err := os.ReadFile(filepath.Join(dir, OutputFile))
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got), "run go generate ./...")
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package iface
+
+// +jobros:deepcopy-gen=true
+type Event struct {
+	Payload interface{}
+}
@@ -0,0 +1,54 @@
+package listing
+
+import (
+	"time"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tags is a named slice type.
+type Tags []string
+
+// Rate is copied by assignment.
+type Rate struct {
+	Amount   int64
+	Currency string
+}
+
+// Slot is a time slot of a listing.
+//
+// +jobros:deepcopy-gen=true
+type Slot struct {
+	Start    time.Time
+	End      time.Time
+	Bookings []string
+}
+
+// Listing is a service offered by a provider.
+//
+// +jobros:deepcopy-gen:object=true
+type Listing struct {
+	runtime.TypeMeta   `json:",inline"`
+	runtime.ObjectMeta `json:"metadata"`
+	ProviderID         primitive.ObjectID
+	Title              string
+	Tags               Tags
+	Rate               Rate
+	Rates              []Rate
+	Discount           *Rate
+	Slots              []Slot
+	NextSlot           *Slot
+	SlotsByDay         map[string][]Slot
+	Extras             map[string]*Rate
+	Weekly             [7][]string
+	Deadline           *time.Time
+	Coverage           struct {
+		Cities []string
+		Radius float64
+	}
+	Contact struct {
+		Email string
+		Phone string
+	}
+}
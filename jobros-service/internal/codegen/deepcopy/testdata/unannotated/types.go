@@ -0,0 +1,10 @@
+package unannotated
+
+type Address struct {
+	Lines []string
+}
+
+// +jobros:deepcopy-gen=true
+type Provider struct {
+	Address Address
+}
@@ -0,0 +1,228 @@
+// Package controller runs the controllers acting on stored objects, such as
+// the cleanups finalizers hold deletions for.
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+const (
+	// defaultRetryDelay is the wait before retrying after a failure.
+	defaultRetryDelay = 5 * time.Second
+	// defaultResync is the interval at which every object is checked again, in
+	// case an event was missed.
+	defaultResync = 10 * time.Minute
+	// updateAttempts bounds the retries of clearing a finalizer from an object
+	// changing meanwhile.
+	updateAttempts = 5
+)
+
+// FinalizeFunc performs the cleanup of a finalizer for an object being
+// deleted. It may run more than once for the same object, after a failure or
+// a restart, so it must be idempotent.
+type FinalizeFunc func(ctx context.Context, obj runtime.Object) error
+
+// Finalizer performs the cleanup named by a finalizer on the objects of a
+// store being deleted, then clears the finalizer so that the objects can be
+// removed.
+type Finalizer struct {
+	name       string
+	store      storage.Interface
+	finalize   FinalizeFunc
+	retryDelay time.Duration
+	resync     time.Duration
+}
+
+// NewFinalizer creates the controller of the finalizer named name.
+func NewFinalizer(name string, store storage.Interface, finalize FinalizeFunc) *Finalizer {
+	return &Finalizer{
+		name:       name,
+		store:      store,
+		finalize:   finalize,
+		retryDelay: defaultRetryDelay,
+		resync:     defaultResync,
+	}
+}
+
+// Run finalizes the objects being deleted until ctx is done. It finalizes the
+// objects already marked for deletion, then those marked later as it watches
+// the store. An object whose cleanup failed is retried alone after a delay;
+// when listing or watching fails, it waits and starts over.
+func (f *Finalizer) Run(ctx context.Context) {
+	for {
+		if err := f.sync(ctx); err != nil && ctx.Err() == nil {
+			glog.Warningf("Finalizer %s failed, retrying in %s: %v", f.name, f.retryDelay, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(f.retryDelay):
+		}
+	}
+}
+
+// sync finalizes the pending objects, then those of the watch until it ends
+// at the next resync. Objects failing are requeued until then.
+func (f *Finalizer) sync(ctx context.Context) error {
+	watchCtx, cancel := context.WithTimeout(ctx, f.resync)
+	defer cancel()
+	// The watch starts before the list, so that no change falls in between.
+	events, err := f.store.Watch(watchCtx, storage.WatchOptions{})
+	if err != nil {
+		return err
+	}
+
+	retries := make(map[string]time.Time)
+	handle := func(obj runtime.Object) {
+		meta, err := runtime.Accessor(obj)
+		if err != nil {
+			return
+		}
+		if err := f.process(ctx, obj); err != nil {
+			if ctx.Err() == nil {
+				glog.Warningf("Finalizer %s failed, retrying %s in %s: %v", f.name, meta.GetUID(), f.retryDelay, err)
+			}
+			retries[meta.GetUID()] = time.Now().Add(f.retryDelay)
+			return
+		}
+		delete(retries, meta.GetUID())
+	}
+
+	opts := storage.ListOptions{}
+	for {
+		page, err := f.store.List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for _, obj := range page.Items {
+			handle(obj)
+		}
+		if page.Continue == "" {
+			break
+		}
+		opts.Continue = page.Continue
+	}
+
+	for {
+		var retry <-chan time.Time
+		var timer *time.Timer
+		if next, ok := nextRetry(retries); ok {
+			timer = time.NewTimer(time.Until(next))
+			retry = timer.C
+		}
+
+		var event storage.Event
+		open, retried := true, false
+		select {
+		case event, open = <-events:
+		case <-retry:
+			retried = true
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+
+		switch {
+		case retried:
+			f.retry(ctx, retries, handle)
+		case !open:
+			return nil
+		case event.Type == storage.EventError:
+			return event.Err
+		case event.Type == storage.EventDeleted:
+			if meta, err := runtime.Accessor(event.Object); err == nil {
+				delete(retries, meta.GetUID())
+			}
+		default:
+			handle(event.Object)
+		}
+	}
+}
+
+// retry processes again the latest version of the objects due for a retry.
+func (f *Finalizer) retry(ctx context.Context, retries map[string]time.Time, handle func(runtime.Object)) {
+	now := time.Now()
+	for uid, at := range retries {
+		if at.After(now) {
+			continue
+		}
+		obj, err := f.store.Get(ctx, uid)
+		if errors.Is(err, storage.ErrNotFound) {
+			delete(retries, uid)
+			continue
+		}
+		if err != nil {
+			glog.Warningf("Finalizer %s failed, retrying %s in %s: %v", f.name, uid, f.retryDelay, err)
+			retries[uid] = now.Add(f.retryDelay)
+			continue
+		}
+		handle(obj)
+	}
+}
+
+// nextRetry returns the earliest time at which an object is retried.
+func nextRetry(retries map[string]time.Time) (time.Time, bool) {
+	var next time.Time
+	for _, at := range retries {
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// pending reports whether obj waits for the cleanup of the finalizer.
+func (f *Finalizer) pending(obj runtime.Object) (runtime.MetaObject, bool) {
+	meta, err := runtime.Accessor(obj)
+	if err != nil {
+		return nil, false
+	}
+	return meta, meta.GetDeletionTimestamp() != nil && runtime.HasFinalizer(meta, f.name)
+}
+
+// process finalizes obj if it is pending, then clears the finalizer from the
+// latest version of the object.
+func (f *Finalizer) process(ctx context.Context, obj runtime.Object) error {
+	meta, ok := f.pending(obj)
+	if !ok {
+		return nil
+	}
+	uid := meta.GetUID()
+	if err := f.finalize(ctx, obj); err != nil {
+		return fmt.Errorf("failed to finalize %s: %w", uid, err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		latest, err := f.store.Get(ctx, uid)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		meta, err := runtime.Accessor(latest)
+		if err != nil {
+			return err
+		}
+		if !runtime.RemoveFinalizer(meta, f.name) {
+			return nil
+		}
+
+		err = f.store.Update(ctx, latest)
+		if errors.Is(err, storage.ErrConflict) && attempt < updateAttempts {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to clear finalizer of %s: %w", uid, err)
+		}
+		glog.Infof("Finalizer %s cleared from %s", f.name, uid)
+		return nil
+	}
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFinalizer = "jobros.io/test-cleanup"
+
+type testBooking struct {
+	runtime.TypeMeta   `bson:",inline"`
+	runtime.ObjectMeta `json:"metadata" bson:"metadata"`
+}
+
+func (b *testBooking) GetGroupVersionKind() runtime.GroupVersionKind {
+	return runtime.GroupVersionKind{Group: "jobros.io", Version: "v1alpha1", Kind: "Booking"}
+}
+
+func (b *testBooking) DeepCopyObject() runtime.Object {
+	out := *b
+	b.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+// cleanups records the finalized objects, failing the first calls and every
+// call for the failing objects.
+type cleanups struct {
+	mu       sync.Mutex
+	failures int
+	failing  map[string]int
+	uids     []string
+}
+
+func (c *cleanups) finalize(_ context.Context, obj runtime.Object) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if attempts, ok := c.failing[obj.(*testBooking).UID]; ok {
+		c.failing[obj.(*testBooking).UID] = attempts + 1
+		return errors.New("payout service unavailable")
+	}
+	if c.failures > 0 {
+		c.failures--
+		return errors.New("payout service unavailable")
+	}
+	c.uids = append(c.uids, obj.(*testBooking).UID)
+	return nil
+}
+
+func (c *cleanups) finalized() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.uids...)
+}
+
+func runFinalizer(t *testing.T, store storage.Interface, c *cleanups) {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := NewFinalizer(testFinalizer, store, c.finalize)
+	f.retryDelay = 10 * time.Millisecond
+	done := make(chan struct{})
+	go func() {
+		f.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func assertRemoved(t *testing.T, store storage.Interface, uid string) {
+	assert.Eventually(t, func() bool {
+		_, err := store.Get(context.Background(), uid)
+		return errors.Is(err, storage.ErrNotFound)
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestFinalizer(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+
+	// An object marked before the controller starts is finalized too.
+	early := &testBooking{ObjectMeta: runtime.ObjectMeta{Finalizers: []string{testFinalizer}}}
+	require.NoError(t, store.Create(ctx, early))
+	require.NoError(t, store.Delete(ctx, early.UID, ""))
+
+	c := &cleanups{}
+	runFinalizer(t, store, c)
+	assertRemoved(t, store, early.UID)
+
+	// Other finalizers hold the object until they are cleared as well.
+	booking := &testBooking{ObjectMeta: runtime.ObjectMeta{Finalizers: []string{testFinalizer, "jobros.io/payouts"}}}
+	require.NoError(t, store.Create(ctx, booking))
+	require.NoError(t, store.Delete(ctx, booking.UID, ""))
+	assert.Eventually(t, func() bool {
+		stored, err := store.Get(ctx, booking.UID)
+		return err == nil && len(stored.(*testBooking).Finalizers) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	stored, err := store.Get(ctx, booking.UID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jobros.io/payouts"}, stored.(*testBooking).Finalizers)
+	assert.NotNil(t, stored.(*testBooking).DeletionTimestamp)
+	stored.(*testBooking).Finalizers = nil
+	require.NoError(t, store.Update(ctx, stored))
+	assertRemoved(t, store, booking.UID)
+
+	assert.Equal(t, []string{early.UID, booking.UID}, c.finalized())
+}
+
+func TestFinalizer_Retries(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	c := &cleanups{failures: 2}
+	runFinalizer(t, store, c)
+
+	// Objects not being deleted are left alone.
+	kept := &testBooking{ObjectMeta: runtime.ObjectMeta{Finalizers: []string{testFinalizer}}}
+	require.NoError(t, store.Create(ctx, kept))
+
+	booking := &testBooking{ObjectMeta: runtime.ObjectMeta{Finalizers: []string{testFinalizer}}}
+	require.NoError(t, store.Create(ctx, booking))
+	require.NoError(t, store.Delete(ctx, booking.UID, ""))
+	assertRemoved(t, store, booking.UID)
+
+	assert.Equal(t, []string{booking.UID}, c.finalized())
+	_, err := store.Get(ctx, kept.UID)
+	assert.NoError(t, err)
+}
+
+func TestFinalizer_RetriesFailingObjectAlone(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+
+	var bookings []*testBooking
+	for i := 0; i < 3; i++ {
+		booking := &testBooking{ObjectMeta: runtime.ObjectMeta{Finalizers: []string{testFinalizer}}}
+		require.NoError(t, store.Create(ctx, booking))
+		require.NoError(t, store.Delete(ctx, booking.UID, ""))
+		bookings = append(bookings, booking)
+	}
+	failing := bookings[1].UID
+	c := &cleanups{failing: map[string]int{failing: 0}}
+	runFinalizer(t, store, c)
+
+	// The cleanup failing for one object does not hold the others.
+	assertRemoved(t, store, bookings[0].UID)
+	assertRemoved(t, store, bookings[2].UID)
+	assert.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.failing[failing] >= 3
+	}, time.Second, 5*time.Millisecond)
+	assert.ElementsMatch(t, []string{bookings[0].UID, bookings[2].UID}, c.finalized())
+	_, err := store.Get(ctx, failing)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,169 @@
+// Package discovery serves the documents describing the API: its groups and
+// versions, taken from the Scheme, and the resources served in each version.
+package discovery
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+// documentVersion is the apiVersion of the discovery documents.
+const documentVersion = "v1"
+
+// APIResource describes a resource served in a version.
+type APIResource struct {
+	// GroupVersion is the version the resource is served in.
+	GroupVersion runtime.GroupVersion `json:"-"`
+	// Name is the plural name of the resource, the path segment of its routes.
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// OwnerScoped tells whether objects belong to the account creating them.
+	OwnerScoped bool `json:"ownerScoped"`
+	// Verbs are the operations served, such as "get", "list" and "watch".
+	Verbs      []string `json:"verbs"`
+	ShortNames []string `json:"shortNames,omitempty"`
+}
+
+// APIResourceList is the document of a version.
+type APIResourceList struct {
+	runtime.TypeMeta `json:",inline"`
+	ServerVersion    string        `json:"serverVersion"`
+	GroupVersion     string        `json:"groupVersion"`
+	Resources        []APIResource `json:"resources"`
+}
+
+// GroupVersionForDiscovery is a version of a group.
+type GroupVersionForDiscovery struct {
+	GroupVersion string `json:"groupVersion"`
+	Version      string `json:"version"`
+}
+
+// APIGroup describes a group and its versions, preferred first.
+type APIGroup struct {
+	Name             string                     `json:"name"`
+	Versions         []GroupVersionForDiscovery `json:"versions"`
+	PreferredVersion GroupVersionForDiscovery   `json:"preferredVersion"`
+}
+
+// APIGroupList is the document of the whole API.
+type APIGroupList struct {
+	runtime.TypeMeta `json:",inline"`
+	ServerVersion    string     `json:"serverVersion"`
+	Groups           []APIGroup `json:"groups"`
+}
+
+// Handler serves the discovery documents.
+type Handler struct {
+	scheme         *runtime.Scheme
+	serverVersion  string
+	servedVersions []runtime.GroupVersion
+
+	mu        sync.RWMutex
+	resources map[runtime.GroupVersion][]APIResource
+}
+
+// NewHandler creates a Handler for the served versions of scheme, in order of
+// preference. Versions without kinds registered in scheme are not listed.
+func NewHandler(scheme *runtime.Scheme, serverVersion string, servedVersions []runtime.GroupVersion) *Handler {
+	return &Handler{
+		scheme:         scheme,
+		serverVersion:  serverVersion,
+		servedVersions: servedVersions,
+		resources:      make(map[runtime.GroupVersion][]APIResource),
+	}
+}
+
+// AddResource lists a served resource, whose kind must be registered in the
+// scheme.
+func (h *Handler) AddResource(resource APIResource) error {
+	gvk := resource.GroupVersion.WithKind(resource.Kind)
+	if !h.scheme.Recognizes(gvk) {
+		return fmt.Errorf("resource %s has kind %s, which is %w", resource.Name, gvk, runtime.ErrNotRegistered)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, existing := range h.resources[resource.GroupVersion] {
+		if existing.Name == resource.Name {
+			return fmt.Errorf("resource %s is already served in %s", resource.Name, resource.GroupVersion)
+		}
+	}
+	h.resources[resource.GroupVersion] = append(h.resources[resource.GroupVersion], resource)
+	return nil
+}
+
+// RegisterRoutes registers the discovery documents, readable without
+// authentication:
+//
+//	GET /apis                   the groups and their versions
+//	GET /apis/:group/:version   the resources of a version
+func (h *Handler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/apis", h.Groups)
+	router.GET("/apis/:group/:version", h.Resources)
+}
+
+// groupVersions returns the served versions with registered kinds.
+func (h *Handler) groupVersions() []runtime.GroupVersion {
+	registered := make(map[runtime.GroupVersion]bool)
+	for _, gvk := range h.scheme.KnownKinds() {
+		registered[gvk.GroupVersion()] = true
+	}
+	var versions []runtime.GroupVersion
+	for _, gv := range h.servedVersions {
+		if registered[gv] && !gv.IsInternal() {
+			versions = append(versions, gv)
+		}
+	}
+	return versions
+}
+
+// Groups answers with the APIGroupList.
+func (h *Handler) Groups(c *gin.Context) {
+	list := APIGroupList{
+		TypeMeta:      runtime.TypeMeta{APIVersion: documentVersion, Kind: "APIGroupList"},
+		ServerVersion: h.serverVersion,
+		Groups:        []APIGroup{},
+	}
+	index := make(map[string]int)
+	for _, gv := range h.groupVersions() {
+		version := GroupVersionForDiscovery{GroupVersion: gv.String(), Version: gv.Version}
+		i, ok := index[gv.Group]
+		if !ok {
+			i = len(list.Groups)
+			index[gv.Group] = i
+			list.Groups = append(list.Groups, APIGroup{Name: gv.Group, PreferredVersion: version})
+		}
+		list.Groups[i].Versions = append(list.Groups[i].Versions, version)
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// Resources answers with the APIResourceList of a version.
+func (h *Handler) Resources(c *gin.Context) {
+	gv := runtime.GroupVersion{Group: c.Param("group"), Version: c.Param("version")}
+	served := false
+	for _, version := range h.groupVersions() {
+		served = served || version == gv
+	}
+	if !served {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("%s is not served", gv)})
+		return
+	}
+
+	h.mu.RLock()
+	resources := append([]APIResource{}, h.resources[gv]...)
+	h.mu.RUnlock()
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Name < resources[j].Name })
+
+	c.JSON(http.StatusOK, APIResourceList{
+		TypeMeta:      runtime.TypeMeta{APIVersion: documentVersion, Kind: "APIResourceList"},
+		ServerVersion: h.serverVersion,
+		GroupVersion:  gv.String(),
+		Resources:     resources,
+	})
+}
@@ -0,0 +1,113 @@
+package discovery
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	alphaVersion = runtime.GroupVersion{Group: "jobros.io", Version: "v1alpha1"}
+	betaVersion  = runtime.GroupVersion{Group: "jobros.io", Version: "v1beta1"}
+)
+
+type alphaWidget struct {
+	runtime.TypeMeta
+	runtime.ObjectMeta `json:"metadata"`
+}
+
+func (w *alphaWidget) GetGroupVersionKind() runtime.GroupVersionKind {
+	return alphaVersion.WithKind("Widget")
+}
+
+func (w *alphaWidget) DeepCopyObject() runtime.Object {
+	out := *w
+	w.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+type betaWidget struct{ alphaWidget }
+
+func (w *betaWidget) GetGroupVersionKind() runtime.GroupVersionKind {
+	return betaVersion.WithKind("Widget")
+}
+
+func (w *betaWidget) DeepCopyObject() runtime.Object {
+	out := *w
+	w.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+func newTestHandler(t *testing.T) (*Handler, *gin.Engine) {
+	gin.SetMode(gin.TestMode)
+	scheme := runtime.NewScheme()
+	require.NoError(t, scheme.AddKnownTypes(&alphaWidget{}, &betaWidget{}))
+	// v1 has no kinds registered, so it is not listed.
+	served := []runtime.GroupVersion{betaVersion, {Group: "jobros.io", Version: "v1"}, alphaVersion}
+	h := NewHandler(scheme, "1.2.3", served)
+	router := gin.New()
+	h.RegisterRoutes(router)
+	return h, router
+}
+
+func get(router *gin.Engine, path string, out interface{}) int {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	if w.Code == http.StatusOK {
+		_ = json.Unmarshal(w.Body.Bytes(), out)
+	}
+	return w.Code
+}
+
+func TestHandler_Groups(t *testing.T) {
+	_, router := newTestHandler(t)
+
+	var list APIGroupList
+	require.Equal(t, http.StatusOK, get(router, "/apis", &list))
+	assert.Equal(t, "APIGroupList", list.Kind)
+	assert.Equal(t, "1.2.3", list.ServerVersion)
+	beta := GroupVersionForDiscovery{GroupVersion: "jobros.io/v1beta1", Version: "v1beta1"}
+	alpha := GroupVersionForDiscovery{GroupVersion: "jobros.io/v1alpha1", Version: "v1alpha1"}
+	assert.Equal(t, []APIGroup{{
+		Name:             "jobros.io",
+		Versions:         []GroupVersionForDiscovery{beta, alpha},
+		PreferredVersion: beta,
+	}}, list.Groups)
+}
+
+func TestHandler_Resources(t *testing.T) {
+	h, router := newTestHandler(t)
+	widgets := APIResource{
+		GroupVersion: alphaVersion,
+		Name:         "widgets",
+		Kind:         "Widget",
+		Verbs:        []string{"get", "list"},
+		ShortNames:   []string{"wd"},
+	}
+	require.NoError(t, h.AddResource(widgets))
+	assert.Error(t, h.AddResource(widgets))
+	gadgets := APIResource{GroupVersion: alphaVersion, Name: "gadgets", Kind: "Gadget"}
+	assert.ErrorIs(t, h.AddResource(gadgets), runtime.ErrNotRegistered)
+
+	var list APIResourceList
+	require.Equal(t, http.StatusOK, get(router, "/apis/jobros.io/v1alpha1", &list))
+	assert.Equal(t, "APIResourceList", list.Kind)
+	assert.Equal(t, "1.2.3", list.ServerVersion)
+	assert.Equal(t, "jobros.io/v1alpha1", list.GroupVersion)
+	widgets.GroupVersion = runtime.GroupVersion{}
+	assert.Equal(t, []APIResource{widgets}, list.Resources)
+
+	// Served versions without resources list none.
+	require.Equal(t, http.StatusOK, get(router, "/apis/jobros.io/v1beta1", &list))
+	assert.Empty(t, list.Resources)
+	assert.NotNil(t, list.Resources)
+
+	assert.Equal(t, http.StatusNotFound, get(router, "/apis/jobros.io/v1", &list))
+	assert.Equal(t, http.StatusNotFound, get(router, "/apis/other.io/v1alpha1", &list))
+}
@@ -0,0 +1,298 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/discovery"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/registry"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+const (
+	jsonType        = "application/json"
+	mergePatchType  = "application/merge-patch+json"
+	jsonPatchType   = "application/json-patch+json"
+	applyPatchType  = "application/apply-patch+json"
+	eventStreamType = "text/event-stream"
+	bearerAuth      = "bearerAuth"
+)
+
+// Builder builds the Document of the served resources.
+type Builder struct {
+	scheme *runtime.Scheme
+	doc    *Document
+	names  map[reflect.Type]string
+}
+
+// NewBuilder creates a Builder of a document with the discovery routes and
+// the route of the document itself.
+func NewBuilder(scheme *runtime.Scheme, title, version string) *Builder {
+	b := &Builder{
+		scheme: scheme,
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    Info{Title: title, Version: version},
+			Paths:   make(map[string]*PathItem),
+			Components: Components{
+				Schemas: map[string]*Schema{
+					"Error": {
+						Type:       "object",
+						Properties: map[string]*Schema{"error": {Type: "string"}},
+						Required:   []string{"error"},
+					},
+					"FieldError": {
+						Type: "object",
+						Properties: map[string]*Schema{
+							"field":   {Type: "string"},
+							"message": {Type: "string"},
+						},
+						Required: []string{"field", "message"},
+					},
+					"ValidationError": {
+						Type: "object",
+						Properties: map[string]*Schema{
+							"error":  {Type: "string"},
+							"errors": {Type: "array", Items: Ref("FieldError")},
+						},
+						Required: []string{"error", "errors"},
+					},
+					"JSONPatchOperation": {
+						Type: "object",
+						Properties: map[string]*Schema{
+							"op":    {Type: "string", Enum: []string{"add", "remove", "replace", "move", "copy", "test"}},
+							"path":  {Type: "string", Description: "JSON pointer (RFC 6901) of the target."},
+							"from":  {Type: "string", Description: "JSON pointer of the source of move and copy."},
+							"value": {Description: "Value of add, replace and test."},
+						},
+						Required: []string{"op", "path"},
+					},
+					"ListMeta": {
+						Type: "object",
+						Properties: map[string]*Schema{
+							"continue":           {Type: "string", Description: "Token of the next page, while more objects remain."},
+							"remainingItemCount": {Type: "integer", Format: "int64", Description: "Estimated number of objects after the page, while more objects remain."},
+						},
+					},
+				},
+				SecuritySchemes: map[string]*SecurityScheme{
+					bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				},
+			},
+		},
+		names: make(map[reflect.Type]string),
+	}
+	b.addDiscovery()
+	return b
+}
+
+// Document returns the document built.
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// addDiscovery adds the routes of the discovery documents and of the OpenAPI
+// document, readable without authentication.
+func (b *Builder) addDiscovery() {
+	b.doc.Paths["/apis"] = &PathItem{Get: &Operation{
+		OperationID: "getAPIGroups",
+		Summary:     "List the API groups and their versions",
+		Tags:        []string{"discovery"},
+		Responses: map[string]*Response{
+			"200": {Description: "The API groups", Content: jsonContent(b.schemaOf(reflect.TypeOf(discovery.APIGroupList{})))},
+		},
+	}}
+	b.doc.Paths["/apis/{group}/{version}"] = &PathItem{
+		Parameters: []*Parameter{pathParameter("group"), pathParameter("version")},
+		Get: &Operation{
+			OperationID: "getAPIResources",
+			Summary:     "List the resources of a version",
+			Tags:        []string{"discovery"},
+			Responses: withErrors(map[string]*Response{
+				"200": {Description: "The resources of the version", Content: jsonContent(b.schemaOf(reflect.TypeOf(discovery.APIResourceList{})))},
+			}, http.StatusNotFound),
+		},
+	}
+	b.doc.Paths["/openapi.json"] = &PathItem{Get: &Operation{
+		OperationID: "getOpenAPI",
+		Summary:     "Get this OpenAPI document",
+		Tags:        []string{"discovery"},
+		Responses: map[string]*Response{
+			"200": {Description: "The OpenAPI document", Content: jsonContent(&Schema{Type: "object"})},
+		},
+	}}
+}
+
+// AddResource adds the routes served by the registry for resource, given the
+// type registered in the scheme for its kind.
+func (b *Builder) AddResource(resource discovery.APIResource) error {
+	gvk := resource.GroupVersion.WithKind(resource.Kind)
+	obj, err := b.scheme.New(gvk)
+	if err != nil {
+		return err
+	}
+	collection, item := "/"+resource.Name, "/"+resource.Name+"/{uid}"
+	if b.doc.Paths[collection] != nil || b.doc.Paths[item] != nil {
+		return fmt.Errorf("path %s is already served", collection)
+	}
+
+	// Kinds are named after the kind rather than the Go type.
+	kind := Ref(b.component(reflect.TypeOf(obj).Elem(), resource.Kind))
+	b.doc.Components.Schemas[resource.Kind+"List"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"apiVersion": {Type: "string", Const: resource.GroupVersion.String()},
+			"kind":       {Type: "string", Const: resource.Kind + "List"},
+			"metadata":   Ref("ListMeta"),
+			"items":      {Type: "array", Items: kind},
+		},
+		Required: []string{"apiVersion", "kind", "metadata", "items"},
+	}
+	list := Ref(resource.Kind + "List")
+
+	verbs := make(map[string]bool)
+	for _, verb := range resource.Verbs {
+		verbs[verb] = true
+	}
+	op := func(id, summary string, responses map[string]*Response, codes ...int) *Operation {
+		return &Operation{
+			OperationID: id + resource.Kind,
+			Summary:     summary,
+			Tags:        []string{resource.Name},
+			Responses:   withErrors(responses, append(codes, http.StatusUnauthorized, http.StatusForbidden)...),
+			Security:    []map[string][]string{{bearerAuth: {}}},
+		}
+	}
+
+	collectionItem := &PathItem{}
+	if verbs["list"] {
+		get := op("list", fmt.Sprintf("List the %s", resource.Name), map[string]*Response{
+			"200": {Description: fmt.Sprintf("A page of %s", resource.Name), Content: jsonContent(list)},
+		}, http.StatusBadRequest)
+		get.Parameters = b.listParameters(resource, verbs["watch"])
+		if verbs["watch"] {
+			get.Summary += ", or watch their changes with watch=true"
+			get.Responses["200"].Content[eventStreamType] = &MediaType{Schema: &Schema{
+				Type:        "string",
+				Description: "Server-Sent Events named ADDED, MODIFIED, DELETED or ERROR, with the object as data and the resume token as id.",
+			}}
+			get.Responses[strconv.Itoa(http.StatusGone)] = errorResponse(http.StatusGone)
+		}
+		collectionItem.Get = get
+	}
+	if verbs["create"] {
+		post := op("create", fmt.Sprintf("Create %s", resource.Kind), map[string]*Response{
+			"201": objectResponse("The created object", kind),
+		}, http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity)
+		post.Parameters = []*Parameter{fieldManagerParameter()}
+		post.RequestBody = &RequestBody{Required: true, Content: jsonContent(kind)}
+		collectionItem.Post = post
+	}
+
+	itemItem := &PathItem{Parameters: []*Parameter{pathParameter("uid")}}
+	if verbs["get"] {
+		itemItem.Get = op("read", fmt.Sprintf("Get %s", resource.Kind), map[string]*Response{
+			"200": objectResponse("The object", kind),
+		}, http.StatusNotFound)
+	}
+	if verbs["update"] {
+		put := op("replace", fmt.Sprintf("Replace %s", resource.Kind), map[string]*Response{
+			"200": objectResponse("The replaced object", kind),
+		}, http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusUnprocessableEntity)
+		put.Parameters = []*Parameter{ifMatchParameter(), fieldManagerParameter()}
+		put.RequestBody = &RequestBody{Required: true, Content: jsonContent(kind)}
+		itemItem.Put = put
+	}
+	if verbs["patch"] {
+		patch := op("patch", fmt.Sprintf("Patch %s", resource.Kind), map[string]*Response{
+			"200": objectResponse("The patched object", kind),
+		}, http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity)
+		patch.Parameters = []*Parameter{
+			ifMatchParameter(),
+			fieldManagerParameter(),
+			{Name: "force", In: "query", Description: "Takes over the fields of other managers when applying.", Schema: &Schema{Type: "boolean"}},
+		}
+		patch.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{
+			mergePatchType: {Schema: &Schema{Type: "object", Description: fmt.Sprintf("JSON merge patch (RFC 7396) of the %s.", resource.Kind)}},
+			jsonPatchType:  {Schema: &Schema{Type: "array", Items: Ref("JSONPatchOperation"), Description: "JSON patch (RFC 6902)."}},
+			applyPatchType: {Schema: &Schema{Ref: kind.Ref, Description: "Configuration applied by the fieldManager: the fields it manages."}},
+		}}
+		itemItem.Patch = patch
+	}
+	if verbs["delete"] {
+		itemItem.Delete = op("delete", fmt.Sprintf("Delete %s", resource.Kind), map[string]*Response{
+			"202": {Description: "The object, marked for deletion until its finalizers are cleared", Content: jsonContent(kind)},
+			"204": {Description: "The object was deleted"},
+		}, http.StatusNotFound)
+	}
+
+	b.doc.Paths[collection] = collectionItem
+	b.doc.Paths[item] = itemItem
+	return nil
+}
+
+// listParameters returns the query parameters of the list route.
+func (b *Builder) listParameters(resource discovery.APIResource, watch bool) []*Parameter {
+	lowest, highest := 1.0, float64(storage.MaxListLimit)
+	params := []*Parameter{
+		{Name: "limit", In: "query", Description: fmt.Sprintf("Page size, %d by default.", storage.DefaultListLimit), Schema: &Schema{Type: "integer", Minimum: &lowest, Maximum: &highest}},
+		{Name: "continue", In: "query", Description: "Token of the page, from metadata.continue of the previous one. It is only valid with the same selectors and owner, for an hour.", Schema: &Schema{Type: "string"}},
+		{Name: "labelSelector", In: "query", Description: "Requirements on the labels, such as tier=gold,region!=eu.", Schema: &Schema{Type: "string"}},
+		{Name: "fieldSelector", In: "query", Description: "Requirements on the fields supported by the resource, such as status=active.", Schema: &Schema{Type: "string"}},
+	}
+	if resource.OwnerScoped {
+		params = append(params, &Parameter{Name: "owner", In: "query", Description: "Owner of the objects, for administrators; every owner by default.", Schema: &Schema{Type: "string"}})
+	}
+	if watch {
+		params = append(params,
+			&Parameter{Name: "watch", In: "query", Description: "Streams the changes as Server-Sent Events instead.", Schema: &Schema{Type: "boolean"}},
+			&Parameter{Name: "resumeToken", In: "query", Description: "Resumes a watch after the event with this id, like the Last-Event-ID header.", Schema: &Schema{Type: "string"}},
+		)
+	}
+	return params
+}
+
+func pathParameter(name string) *Parameter {
+	return &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}}
+}
+
+func fieldManagerParameter() *Parameter {
+	maxLength := registry.MaxFieldManagerLength
+	return &Parameter{Name: "fieldManager", In: "query", Description: "Name of the client writing, recorded in metadata.managedFields. Required to apply.", Schema: &Schema{Type: "string", MaxLength: &maxLength}}
+}
+
+func ifMatchParameter() *Parameter {
+	return &Parameter{Name: "If-Match", In: "header", Description: "ETag of the version the write applies to.", Schema: &Schema{Type: "string"}}
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{jsonType: {Schema: schema}}
+}
+
+// objectResponse answers with an object and its ETag.
+func objectResponse(description string, schema *Schema) *Response {
+	return &Response{
+		Description: description,
+		Headers:     map[string]*Header{"ETag": {Description: "Resource version of the object.", Schema: &Schema{Type: "string"}}},
+		Content:     jsonContent(schema),
+	}
+}
+
+func errorResponse(code int) *Response {
+	schema := Ref("Error")
+	if code == http.StatusUnprocessableEntity {
+		schema = Ref("ValidationError")
+	}
+	return &Response{Description: http.StatusText(code), Content: jsonContent(schema)}
+}
+
+// withErrors adds the error responses of the codes to responses.
+func withErrors(responses map[string]*Response, codes ...int) map[string]*Response {
+	for _, code := range codes {
+		responses[strconv.Itoa(code)] = errorResponse(code)
+	}
+	return responses
+}
@@ -0,0 +1,33 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves a Document.
+type Handler struct {
+	data []byte
+}
+
+// NewHandler creates a Handler of doc, encoded once.
+func NewHandler(doc *Document) (*Handler, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{data: data}, nil
+}
+
+// RegisterRoutes registers GET /openapi.json, readable without
+// authentication.
+func (h *Handler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/openapi.json", h.Document)
+}
+
+// Document answers with the document.
+func (h *Handler) Document(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.data)
+}
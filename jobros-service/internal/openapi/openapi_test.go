@@ -0,0 +1,226 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/discovery"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testGroupVersion = runtime.GroupVersion{Group: "jobros.io", Version: "v1alpha1"}
+
+type testAddress struct {
+	City string `json:"city" binding:"required,max=64"`
+}
+
+type testService struct {
+	runtime.TypeMeta   `bson:",inline"`
+	runtime.ObjectMeta `json:"metadata"`
+	Title              string            `json:"title" binding:"required,min=3"`
+	Price              int64             `json:"price" binding:"gte=0,lt=100000"`
+	Tier               string            `json:"tier,omitempty" binding:"omitempty,oneof=basic premium"`
+	Tags               []string          `json:"tags" binding:"max=5,dive,min=2"`
+	Address            *testAddress      `json:"address,omitempty"`
+	Options            map[string]string `json:"options,omitempty"`
+	PublishedAt        time.Time         `json:"publishedAt"`
+	Logo               []byte            `json:"logo,omitempty"`
+	Internal           string            `json:"-"`
+	secret             string
+}
+
+func (s *testService) GetGroupVersionKind() runtime.GroupVersionKind {
+	return testGroupVersion.WithKind("Service")
+}
+
+func (s *testService) DeepCopyObject() runtime.Object {
+	out := *s
+	s.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+func newTestBuilder(t *testing.T) *Builder {
+	scheme := runtime.NewScheme()
+	require.NoError(t, scheme.AddKnownType(&testService{}))
+	return NewBuilder(scheme, "Test API", "1.2.3")
+}
+
+func serviceResource(verbs ...string) discovery.APIResource {
+	return discovery.APIResource{GroupVersion: testGroupVersion, Name: "services", Kind: "Service", OwnerScoped: true, Verbs: verbs}
+}
+
+func floatPtr(f float64) *float64 { return &f }
+func intPtr(n int) *int           { return &n }
+
+func TestBuilder_Schemas(t *testing.T) {
+	b := newTestBuilder(t)
+	require.NoError(t, b.AddResource(serviceResource("get")))
+	schemas := b.Document().Components.Schemas
+
+	service := schemas["Service"]
+	require.NotNil(t, service)
+	assert.Equal(t, []string{"title"}, service.Required)
+	assert.ElementsMatch(t, []string{"apiVersion", "kind", "metadata", "title", "price", "tier", "tags", "address", "options", "publishedAt", "logo"}, keys(service.Properties))
+	assert.Equal(t, Ref("ObjectMeta"), service.Properties["metadata"])
+	assert.Equal(t, &Schema{Type: "string", MinLength: intPtr(3)}, service.Properties["title"])
+	assert.Equal(t, &Schema{Type: "integer", Format: "int64", Minimum: floatPtr(0), ExclusiveMaximum: floatPtr(100000)}, service.Properties["price"])
+	assert.Equal(t, &Schema{Type: "string", Enum: []string{"basic", "premium"}}, service.Properties["tier"])
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Type: "string", MinLength: intPtr(2)}, MaxItems: intPtr(5)}, service.Properties["tags"])
+	assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}, service.Properties["options"])
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, service.Properties["publishedAt"])
+	assert.Equal(t, &Schema{Type: "string", Format: "byte"}, service.Properties["logo"])
+
+	assert.Equal(t, Ref("testAddress"), service.Properties["address"])
+	assert.Equal(t, []string{"city"}, schemas["testAddress"].Required)
+	assert.Equal(t, intPtr(64), schemas["testAddress"].Properties["city"].MaxLength)
+
+	list := schemas["ServiceList"]
+	require.NotNil(t, list)
+	assert.Equal(t, "jobros.io/v1alpha1", list.Properties["apiVersion"].Const)
+	assert.Equal(t, Ref("Service"), list.Properties["items"].Items)
+}
+
+func keys(m map[string]*Schema) []string {
+	var out []string
+	for key := range m {
+		out = append(out, key)
+	}
+	return out
+}
+
+func TestBuilder_AddResource(t *testing.T) {
+	b := newTestBuilder(t)
+	require.NoError(t, b.AddResource(serviceResource("get", "list", "watch", "create", "update", "patch", "delete")))
+	assert.ErrorContains(t, b.AddResource(serviceResource("get")), "already served")
+	unknown := serviceResource("get")
+	unknown.Kind = "Gadget"
+	assert.ErrorIs(t, b.AddResource(unknown), runtime.ErrNotRegistered)
+
+	paths := b.Document().Paths
+	collection, item := paths["/services"], paths["/services/{uid}"]
+	require.NotNil(t, collection)
+	require.NotNil(t, item)
+
+	list := collection.Get
+	assert.Equal(t, "listService", list.OperationID)
+	var params []string
+	for _, param := range list.Parameters {
+		params = append(params, param.Name)
+	}
+	assert.Equal(t, []string{"limit", "continue", "labelSelector", "fieldSelector", "owner", "watch", "resumeToken"}, params)
+	assert.Contains(t, list.Responses["200"].Content, "text/event-stream")
+	assert.Contains(t, list.Responses, "410")
+
+	assert.Equal(t, Ref("Service"), collection.Post.RequestBody.Content["application/json"].Schema)
+	assert.Equal(t, Ref("ValidationError"), collection.Post.Responses["422"].Content["application/json"].Schema)
+	assert.Contains(t, item.Patch.RequestBody.Content, "application/merge-patch+json")
+	assert.Contains(t, item.Put.Responses, "412")
+	assert.Contains(t, item.Delete.Responses, "202")
+	assert.Equal(t, []map[string][]string{{"bearerAuth": {}}}, item.Get.Security)
+
+	// Verbs not served have no operation.
+	b = newTestBuilder(t)
+	require.NoError(t, b.AddResource(serviceResource("get", "list")))
+	collection = b.Document().Paths["/services"]
+	assert.Nil(t, collection.Post)
+	assert.NotContains(t, collection.Get.Responses, "410")
+	assert.Nil(t, b.Document().Paths["/services/{uid}"].Delete)
+}
+
+type testTokenRequest struct {
+	GrantType string `form:"grant_type" binding:"required"`
+	Scope     string `form:"scope"`
+}
+
+type testQuoteRequest struct {
+	Amount int64 `json:"amount" binding:"required,gt=0"`
+}
+
+func TestBuilder_AddRoute(t *testing.T) {
+	b := newTestBuilder(t)
+	quote := Route{
+		Method:      http.MethodPost,
+		Path:        "/services/{uid}/quotes",
+		OperationID: "createQuote",
+		Tag:         "services",
+		Auth:        true,
+		Query:       testTokenRequest{},
+		Request:     testQuoteRequest{},
+		Status:      http.StatusCreated,
+		Response:    testAddress{},
+		Errors:      []int{http.StatusBadRequest},
+	}
+	require.NoError(t, b.AddRoute(quote))
+	assert.ErrorContains(t, b.AddRoute(quote), "already served")
+	require.NoError(t, b.AddRoute(Route{
+		Method:  http.MethodPost,
+		Path:    "/token",
+		Request: testTokenRequest{},
+		Form:    true,
+		Response: struct {
+			Valid bool `json:"valid"`
+		}{},
+	}))
+
+	paths := b.Document().Paths
+	item := paths["/services/{uid}/quotes"]
+	require.NotNil(t, item)
+	assert.Equal(t, []*Parameter{pathParameter("uid")}, item.Parameters)
+	op := item.Post
+	require.NotNil(t, op)
+	assert.Equal(t, []*Parameter{
+		{Name: "grant_type", In: "query", Required: true, Schema: &Schema{Type: "string"}},
+		{Name: "scope", In: "query", Schema: &Schema{Type: "string"}},
+	}, op.Parameters)
+	// Private request types are inlined.
+	assert.Equal(t, &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"amount": {Type: "integer", Format: "int64", ExclusiveMinimum: floatPtr(0)}},
+		Required:   []string{"amount"},
+	}, op.RequestBody.Content["application/json"].Schema)
+	assert.Equal(t, []string{"201", "400", "401"}, responseCodes(op))
+	assert.Equal(t, []map[string][]string{{"bearerAuth": {}}}, op.Security)
+
+	token := paths["/token"].Post
+	form := token.RequestBody.Content["application/x-www-form-urlencoded"].Schema
+	assert.Equal(t, []string{"grant_type"}, form.Required)
+	assert.Equal(t, []string{"200"}, responseCodes(token))
+	assert.Contains(t, token.Responses["200"].Content["application/json"].Schema.Properties, "valid")
+	assert.Nil(t, token.Security)
+}
+
+func responseCodes(op *Operation) []string {
+	var codes []string
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	b := newTestBuilder(t)
+	require.NoError(t, b.AddResource(serviceResource("get")))
+	h, err := NewHandler(b.Document())
+	require.NoError(t, err)
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var doc Document
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Equal(t, Info{Title: "Test API", Version: "1.2.3"}, doc.Info)
+	assert.Contains(t, doc.Paths, "/apis")
+	assert.Contains(t, doc.Paths, "/services/{uid}")
+}
@@ -0,0 +1,162 @@
+package openapi
+
+import (
+	"fmt"
+	"go/token"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const formType = "application/x-www-form-urlencoded"
+
+// Route describes a route served by a handler of its own rather than by the
+// registry, such as the OAuth or invite endpoints.
+type Route struct {
+	Method string
+	// Path is the OpenAPI path, with {name} path parameters.
+	Path        string
+	OperationID string
+	Summary     string
+	Tag         string
+	// Auth requires a bearer token.
+	Auth bool
+	// Query is a value of the struct bound from the query, its fields named
+	// by their form tags.
+	Query any
+	// Request is a value of the struct bound from the body: JSON, or a form
+	// when Form is set. Nil routes take no body.
+	Request any
+	Form    bool
+	// Status is the status of success, 200 by default.
+	Status int
+	// Response is a value of the type answered, or its *Schema. Nil routes
+	// answer without content.
+	Response any
+	// Description describes the response of success.
+	Description string
+	// Errors are the statuses of the error responses.
+	Errors []int
+}
+
+// AddRoute adds route to the document.
+func (b *Builder) AddRoute(route Route) error {
+	item := b.doc.Paths[route.Path]
+	if item == nil {
+		item = &PathItem{}
+		for _, segment := range strings.Split(route.Path, "/") {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				item.Parameters = append(item.Parameters, pathParameter(segment[1:len(segment)-1]))
+			}
+		}
+	}
+	var slot **Operation
+	switch route.Method {
+	case http.MethodGet:
+		slot = &item.Get
+	case http.MethodPut:
+		slot = &item.Put
+	case http.MethodPost:
+		slot = &item.Post
+	case http.MethodDelete:
+		slot = &item.Delete
+	case http.MethodPatch:
+		slot = &item.Patch
+	default:
+		return fmt.Errorf("unsupported method %s of %s", route.Method, route.Path)
+	}
+	if *slot != nil {
+		return fmt.Errorf("route %s %s is already served", route.Method, route.Path)
+	}
+
+	op := &Operation{
+		OperationID: route.OperationID,
+		Summary:     route.Summary,
+		Tags:        []string{route.Tag},
+	}
+	if route.Query != nil {
+		for _, param := range b.formParameters(reflect.TypeOf(route.Query)) {
+			param.In = "query"
+			op.Parameters = append(op.Parameters, param)
+		}
+	}
+	if route.Request != nil {
+		t := reflect.TypeOf(route.Request)
+		if route.Form {
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{formType: {Schema: b.formSchema(t)}}}
+		} else {
+			op.RequestBody = &RequestBody{Required: true, Content: jsonContent(b.bodySchema(t))}
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := &Response{Description: route.Description}
+	switch value := route.Response.(type) {
+	case nil:
+	case *Schema:
+		response.Content = jsonContent(value)
+	default:
+		response.Content = jsonContent(b.bodySchema(reflect.TypeOf(value)))
+	}
+	codes := append([]int(nil), route.Errors...)
+	if route.Auth {
+		codes = append(codes, http.StatusUnauthorized)
+		op.Security = []map[string][]string{{bearerAuth: {}}}
+	}
+	op.Responses = withErrors(map[string]*Response{strconv.Itoa(status): response}, codes...)
+
+	*slot = op
+	b.doc.Paths[route.Path] = item
+	return nil
+}
+
+// bodySchema returns the schema of the bodies of type t. The structs private
+// to the package of their handler are inlined rather than made components.
+func (b *Builder) bodySchema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct && !token.IsExported(t.Name()) {
+		return b.structSchema(t)
+	}
+	return b.schemaOf(t)
+}
+
+// formParameters returns the fields of the struct type t bound from forms
+// and queries, named by their form tags.
+func (b *Builder) formParameters(t reflect.Type) []*Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var params []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema := b.schemaOf(field.Type)
+		required := applyBinding(schema, field.Tag.Get("binding"))
+		params = append(params, &Parameter{Name: name, Required: required, Schema: schema})
+	}
+	return params
+}
+
+// formSchema returns the object schema of the form fields of t.
+func (b *Builder) formSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, param := range b.formParameters(t) {
+		if param.Required {
+			s.Required = append(s.Required, param.Name)
+		}
+		s.Properties[param.Name] = param.Schema
+	}
+	return s
+}
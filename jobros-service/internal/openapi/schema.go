@@ -0,0 +1,207 @@
+package openapi
+
+import (
+	"encoding"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaOf returns the schema of the JSON encoding of values of type t. Named
+// struct types are added to the components and referenced.
+func (b *Builder) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t.Implements(textMarshalerType) {
+		// Such as object IDs, encoded as text by encoding/json.
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes bytes in base64.
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return Ref(b.component(t, t.Name()))
+	default:
+		// Interfaces hold any value.
+		return &Schema{}
+	}
+}
+
+// component adds the schema of the struct type t to the components, once,
+// and returns its name: name, prefixed with the package of t when another type
+// has the same name.
+func (b *Builder) component(t reflect.Type, name string) string {
+	if existing, ok := b.names[t]; ok {
+		return existing
+	}
+	if _, taken := b.doc.Components.Schemas[name]; taken {
+		pkg := []rune(path.Base(t.PkgPath()))
+		pkg[0] = unicode.ToUpper(pkg[0])
+		name = string(pkg) + name
+	}
+	// The name is recorded first, for types referencing themselves.
+	b.names[t] = name
+	b.doc.Components.Schemas[name] = &Schema{}
+	*b.doc.Components.Schemas[name] = *b.structSchema(t)
+	return name
+}
+
+// structSchema returns the object schema of the fields of t.
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	b.addFields(s, t)
+	return s
+}
+
+// addFields adds the fields of t to s the way encoding/json encodes them:
+// embedded structs without a JSON name have their fields promoted.
+func (b *Builder) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addFields(s, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := b.schemaOf(field.Type)
+		if applyBinding(prop, field.Tag.Get("binding")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// applyBinding adds the constraints of the validator rules of a binding tag
+// to s, and reports whether the field is required. The rules after dive apply
+// to the elements.
+func applyBinding(s *Schema, tag string) (required bool) {
+	if tag == "" {
+		return false
+	}
+	target := s
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = required || target == s
+		case "dive":
+			switch {
+			case target.Items != nil:
+				target = target.Items
+			case target.AdditionalProperties != nil:
+				target = target.AdditionalProperties
+			default:
+				return required
+			}
+		case "email":
+			target.Format = "email"
+		case "url", "uri":
+			target.Format = "uri"
+		case "uuid":
+			target.Format = "uuid"
+		case "oneof":
+			target.Enum = strings.Fields(param)
+		case "min", "gte":
+			bound(target, param, 0, true)
+		case "max", "lte":
+			bound(target, param, 0, false)
+		case "gt":
+			bound(target, param, 1, true)
+		case "lt":
+			bound(target, param, -1, false)
+		case "len":
+			bound(target, param, 0, true)
+			bound(target, param, 0, false)
+		}
+	}
+	return required
+}
+
+// bound sets a lower or upper bound of s: its length for strings and arrays,
+// its value for numbers. offset turns the bound of strict rules inclusive for
+// lengths; for numbers, strict rules set an exclusive bound instead.
+func bound(s *Schema, param string, offset int, lower bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch s.Type {
+	case "string", "array":
+		length := int(n) + offset
+		switch {
+		case s.Type == "string" && lower:
+			s.MinLength = &length
+		case s.Type == "string":
+			s.MaxLength = &length
+		case lower:
+			s.MinItems = &length
+		default:
+			s.MaxItems = &length
+		}
+	case "integer", "number":
+		switch {
+		case offset != 0 && lower:
+			s.ExclusiveMinimum = &n
+		case offset != 0:
+			s.ExclusiveMaximum = &n
+		case lower:
+			s.Minimum = &n
+		default:
+			s.Maximum = &n
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+// Operation is the kind of write being admitted.
+type Operation string
+
+const (
+	Create Operation = "CREATE"
+	Update Operation = "UPDATE"
+	Delete Operation = "DELETE"
+)
+
+// Attributes describe a write being admitted.
+type Attributes struct {
+	Operation Operation
+	Kind      runtime.GroupVersionKind
+	// Object is the object to store, nil for Delete. Mutating hooks change it
+	// in place.
+	Object runtime.Object
+	// OldObject is the stored object, nil for Create.
+	OldObject runtime.Object
+	// User is the caller.
+	User *auth.JWTClaims
+}
+
+// MutatingHook changes objects before they are validated, to set defaults or
+// normalize values.
+type MutatingHook interface {
+	Name() string
+	// Mutate changes a.Object. An error rejects the write.
+	Mutate(ctx context.Context, a *Attributes) error
+}
+
+// ValidatingHook checks writes against rules the object alone cannot tell,
+// such as business rules and quotas.
+type ValidatingHook interface {
+	Name() string
+	// Validate returns FieldErrors for invalid fields, DeniedError to refuse
+	// the write as a whole, or another error when it could not decide.
+	Validate(ctx context.Context, a *Attributes) error
+}
+
+// DeniedError refuses a write for a reason other than invalid fields, such as
+// an exhausted quota. It is answered with 403 and the reason.
+type DeniedError struct {
+	Reason string
+}
+
+func (e *DeniedError) Error() string { return e.Reason }
+
+// Denied returns a DeniedError with the formatted reason.
+func Denied(format string, args ...interface{}) error {
+	return &DeniedError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Admission holds the admission hooks of each kind. Packages register their
+// hooks with an AddToAdmission function, like types with AddToScheme, so
+// that a package can add rules to kinds it does not define.
+type Admission struct {
+	mu         sync.RWMutex
+	mutating   map[runtime.GroupVersionKind][]MutatingHook
+	validating map[runtime.GroupVersionKind][]ValidatingHook
+}
+
+// NewAdmission creates an Admission without hooks.
+func NewAdmission() *Admission {
+	return &Admission{
+		mutating:   make(map[runtime.GroupVersionKind][]MutatingHook),
+		validating: make(map[runtime.GroupVersionKind][]ValidatingHook),
+	}
+}
+
+// AddMutatingHook runs hook on the writes of the served kind, after the hooks
+// added before it.
+func (a *Admission) AddMutatingHook(gvk runtime.GroupVersionKind, hook MutatingHook) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.mutating[gvk] = append(a.mutating[gvk], hook)
+}
+
+// AddValidatingHook runs hook on the writes of the served kind.
+func (a *Admission) AddValidatingHook(gvk runtime.GroupVersionKind, hook ValidatingHook) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.validating[gvk] = append(a.validating[gvk], hook)
+}
+
+// Mutate runs the mutating hooks of the kind in order, stopping at the first
+// error.
+func (a *Admission) Mutate(ctx context.Context, attrs *Attributes) error {
+	if a == nil {
+		return nil
+	}
+	a.mu.RLock()
+	hooks := a.mutating[attrs.Kind]
+	a.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook.Mutate(ctx, attrs); err != nil {
+			return admissionError(hook.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Validate runs every validating hook of the kind, and returns the field
+// errors of all of them, unless one fails otherwise.
+func (a *Admission) Validate(ctx context.Context, attrs *Attributes) error {
+	if a == nil {
+		return nil
+	}
+	a.mu.RLock()
+	hooks := a.validating[attrs.Kind]
+	a.mu.RUnlock()
+
+	var errs FieldErrors
+	for _, hook := range hooks {
+		err := hook.Validate(ctx, attrs)
+		var fieldErrs FieldErrors
+		switch {
+		case err == nil:
+		case errors.As(err, &fieldErrs):
+			errs = append(errs, fieldErrs...)
+		default:
+			return admissionError(hook.Name(), err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// admissionError wraps the error of a hook, keeping the errors answered as is.
+func admissionError(hook string, err error) error {
+	var fieldErrs FieldErrors
+	var denied *DeniedError
+	if errors.As(err, &fieldErrs) || errors.As(err, &denied) {
+		return err
+	}
+	return fmt.Errorf("admission hook %s failed: %w", hook, err)
+}
+
+// AdmissionBuilder collects the functions adding the hooks of a package, to
+// be applied to an Admission with AddToAdmission.
+type AdmissionBuilder []func(*Admission) error
+
+// NewAdmissionBuilder creates an AdmissionBuilder with the functions.
+func NewAdmissionBuilder(funcs ...func(*Admission) error) AdmissionBuilder {
+	var ab AdmissionBuilder
+	ab.Register(funcs...)
+	return ab
+}
+
+// Register adds functions to the builder.
+func (ab *AdmissionBuilder) Register(funcs ...func(*Admission) error) {
+	*ab = append(*ab, funcs...)
+}
+
+// AddToAdmission applies all the functions to a.
+func (ab AdmissionBuilder) AddToAdmission(a *Admission) error {
+	for _, add := range ab {
+		if err := add(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
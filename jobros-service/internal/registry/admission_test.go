@@ -0,0 +1,106 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testHook struct {
+	name     string
+	mutate   func(*testService)
+	validate func(*Attributes) error
+}
+
+func (h testHook) Name() string { return h.name }
+
+func (h testHook) Mutate(_ context.Context, a *Attributes) error {
+	h.mutate(a.Object.(*testService))
+	return nil
+}
+
+func (h testHook) Validate(_ context.Context, a *Attributes) error {
+	return h.validate(a)
+}
+
+func TestAdmission(t *testing.T) {
+	gvk := testGroupVersion.WithKind("Service")
+	admission := NewAdmission()
+	admission.AddMutatingHook(gvk, testHook{name: "trim", mutate: func(s *testService) {
+		s.Title = strings.TrimSpace(s.Title)
+	}})
+	admission.AddMutatingHook(gvk, testHook{name: "title", mutate: func(s *testService) {
+		s.Title = strings.ToUpper(s.Title[:1]) + s.Title[1:]
+	}})
+	admission.AddValidatingHook(gvk, testHook{name: "price", validate: func(a *Attributes) error {
+		if a.Object.(*testService).Price > 1000 {
+			return FieldErrors{{Field: "price", Message: "must be at most 1000"}}
+		}
+		return nil
+	}})
+	admission.AddValidatingHook(gvk, testHook{name: "title", validate: func(a *Attributes) error {
+		if a.Object.(*testService).Title == "Free" {
+			return FieldErrors{{Field: "title", Message: "is reserved"}}
+		}
+		return nil
+	}})
+
+	ctx := context.Background()
+	attrs := &Attributes{Operation: Create, Kind: gvk, Object: &testService{Title: "  plumbing "}}
+	require.NoError(t, admission.Mutate(ctx, attrs))
+	assert.Equal(t, "Plumbing", attrs.Object.(*testService).Title)
+	assert.NoError(t, admission.Validate(ctx, attrs))
+
+	// Field errors of every hook are aggregated.
+	attrs.Object = &testService{Title: "Free", Price: 5000}
+	assert.Equal(t, FieldErrors{
+		{Field: "price", Message: "must be at most 1000"},
+		{Field: "title", Message: "is reserved"},
+	}, admission.Validate(ctx, attrs))
+
+	// Hooks only run for their kind, and a nil Admission admits everything.
+	other := &Attributes{Operation: Create, Kind: testGroupVersion.WithKind("Listing"), Object: &testService{Title: "Free"}}
+	assert.NoError(t, admission.Validate(ctx, other))
+	assert.NoError(t, (*Admission)(nil).Validate(ctx, attrs))
+
+	admission.AddValidatingHook(gvk, testHook{name: "quota", validate: func(*Attributes) error {
+		return errors.New("quota service unavailable")
+	}})
+	assert.EqualError(t, admission.Validate(ctx, attrs), "admission hook quota failed: quota service unavailable")
+}
+
+func TestREST_Admission(t *testing.T) {
+	rt := newRESTTest(t)
+	gvk := testGroupVersion.WithKind("Service")
+	rt.rest.Admission = NewAdmission()
+	rt.rest.Admission.AddMutatingHook(gvk, testHook{name: "trim", mutate: func(s *testService) {
+		s.Title = strings.TrimSpace(s.Title)
+	}})
+	rt.rest.Admission.AddValidatingHook(gvk, testHook{name: "quota", validate: func(a *Attributes) error {
+		switch {
+		case a.Operation == Delete:
+			return Denied("services of %s cannot be deleted", a.OldObject.(*testService).Owner)
+		case a.Object.(*testService).Price > 1000:
+			return FieldErrors{{Field: "price", Message: "must be at most 1000"}}
+		}
+		return nil
+	}})
+
+	created := rt.create("provider-1", `{"title":"  Plumbing  "}`)
+	assert.Equal(t, "Plumbing", created.Title)
+
+	// Hook and binding errors are answered together.
+	w := rt.do("provider-1", "PUT", "/services/"+created.UID, `{"title":"","price":5000}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"title"`)
+	assert.Contains(t, w.Body.String(), `"field":"price"`)
+
+	w = rt.do("provider-1", "DELETE", "/services/"+created.UID, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"error":"services of provider-1 cannot be deleted"}`, w.Body.String())
+}
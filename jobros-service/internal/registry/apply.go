@@ -0,0 +1,267 @@
+package registry
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+// MaxFieldManagerLength bounds the names of field managers.
+const MaxFieldManagerLength = 128
+
+// untrackedFields are managed by the server, so no client manages them.
+var untrackedFields = map[string]bool{
+	"/apiVersion":                 true,
+	"/kind":                       true,
+	"/metadata/uid":               true,
+	"/metadata/owner":             true,
+	"/metadata/resourceVersion":   true,
+	"/metadata/generation":        true,
+	"/metadata/creationTimestamp": true,
+	"/metadata/deletionTimestamp": true,
+	"/metadata/managedFields":     true,
+}
+
+// FieldConflict is a field an applied configuration sets to another value
+// than the one set by another manager.
+type FieldConflict struct {
+	Field   string `json:"field"`
+	Manager string `json:"manager"`
+}
+
+// applyConflictError refuses an apply changing the fields of other managers.
+// It is answered with 409 and the conflicts.
+type applyConflictError struct {
+	conflicts []FieldConflict
+}
+
+func (e *applyConflictError) Error() string {
+	return fmt.Sprintf("apply conflicts with %d fields of other managers", len(e.conflicts))
+}
+
+// fieldManager returns the manager of a write: the fieldManager query
+// parameter, or else the product named by the User-Agent header.
+func fieldManager(c *gin.Context) (string, error) {
+	manager := c.Query("fieldManager")
+	if manager == "" {
+		product, _, _ := strings.Cut(c.GetHeader("User-Agent"), "/")
+		manager = strings.TrimSpace(product)
+	}
+	if manager == "" {
+		manager = "unknown"
+	}
+	if len(manager) > MaxFieldManagerLength || !utf8.ValidString(manager) {
+		return "", fmt.Errorf("fieldManager must be at most %d characters", MaxFieldManagerLength)
+	}
+	return manager, nil
+}
+
+// now returns the time recorded in managed fields.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// fieldValues returns the values of the managed fields of doc by JSON pointer.
+// Objects are walked field by field, while lists and scalars are values. Empty
+// objects hold no field: applying {"metadata":{}} must not claim, and so
+// replace, the whole metadata.
+func fieldValues(doc interface{}) map[string]interface{} {
+	values := make(map[string]interface{})
+	var walk func(path []string, value interface{})
+	walk = func(path []string, value interface{}) {
+		pointer := formatPointer(path)
+		if untrackedFields[pointer] {
+			return
+		}
+		if fields, ok := value.(map[string]interface{}); ok {
+			for key, child := range fields {
+				walk(append(path[:len(path):len(path)], key), child)
+			}
+			return
+		}
+		values[pointer] = value
+	}
+	walk(nil, doc)
+	return values
+}
+
+// overlaps reports whether the fields a and b are the same or one contains
+// the other.
+func overlaps(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+func overlapsAny(field string, fields []string) bool {
+	for _, other := range fields {
+		if overlaps(field, other) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedFields(values map[string]interface{}) []string {
+	fields := make([]string, 0, len(values))
+	for field := range values {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// releaseFields returns a copy of entries where the entries selected by from
+// no longer manage the fields overlapping fields. Entries left without fields
+// are dropped.
+func releaseFields(entries []runtime.ManagedFieldsEntry, fields []string, from func(runtime.ManagedFieldsEntry) bool) []runtime.ManagedFieldsEntry {
+	var out []runtime.ManagedFieldsEntry
+	for _, entry := range entries {
+		entry.Fields = append([]string(nil), entry.Fields...)
+		if from(entry) {
+			kept := entry.Fields[:0]
+			for _, field := range entry.Fields {
+				if !overlapsAny(field, fields) {
+					kept = append(kept, field)
+				}
+			}
+			entry.Fields = kept
+		}
+		if len(entry.Fields) > 0 {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+// claimFields adds fields to the entry of manager and operation.
+func claimFields(entries []runtime.ManagedFieldsEntry, manager string, operation runtime.ManagedFieldsOperation, fields []string, at time.Time) []runtime.ManagedFieldsEntry {
+	if len(fields) == 0 {
+		return entries
+	}
+	for i, entry := range entries {
+		if entry.Manager == manager && entry.Operation == operation {
+			set := make(map[string]interface{}, len(entry.Fields)+len(fields))
+			for _, field := range append(entry.Fields, fields...) {
+				set[field] = nil
+			}
+			entries[i].Fields = sortedFields(set)
+			entries[i].Time = at
+			return entries
+		}
+	}
+	fields = append([]string(nil), fields...)
+	sort.Strings(fields)
+	return append(entries, runtime.ManagedFieldsEntry{Manager: manager, Operation: operation, Time: at, Fields: fields})
+}
+
+// updateManagedFields records the fields changed from oldValues to newValues
+// as managed by manager through an update. Other managers lose them, without
+// conflict: only applies are checked for conflicts.
+func updateManagedFields(entries []runtime.ManagedFieldsEntry, oldValues, newValues map[string]interface{}, manager string, at time.Time) []runtime.ManagedFieldsEntry {
+	var changed, set []string
+	for field, value := range newValues {
+		if old, ok := oldValues[field]; !ok || !reflect.DeepEqual(old, value) {
+			changed = append(changed, field)
+			set = append(set, field)
+		}
+	}
+	for field := range oldValues {
+		if _, ok := newValues[field]; !ok {
+			changed = append(changed, field)
+		}
+	}
+	if len(changed) == 0 {
+		return entries
+	}
+	entries = releaseFields(entries, changed, func(runtime.ManagedFieldsEntry) bool { return true })
+	return claimFields(entries, manager, runtime.ManagedFieldsUpdate, set, at)
+}
+
+// applyConfiguration merges config, the configuration applied by manager,
+// into doc, the encoded object, and returns the managed fields updated.
+//
+// The fields of config are set, and managed by manager. Fields another
+// manager set to another value are conflicts, refused unless force takes them
+// over; fields set to the same value are managed by both. Fields manager
+// applied before but left out of config are removed, unless another manager
+// still manages them.
+func applyConfiguration(doc, config map[string]interface{}, entries []runtime.ManagedFieldsEntry, manager string, force bool, at time.Time) ([]runtime.ManagedFieldsEntry, error) {
+	applied := fieldValues(config)
+	fields := sortedFields(applied)
+	isApplier := func(entry runtime.ManagedFieldsEntry) bool {
+		return entry.Manager == manager && entry.Operation == runtime.ManagedFieldsApply
+	}
+
+	var conflicts []FieldConflict
+	var conflicting []string
+	seen := make(map[FieldConflict]bool)
+	for _, field := range fields {
+		path, _ := parsePointer(field)
+		current, err := getValue(doc, path)
+		if err == nil && reflect.DeepEqual(current, applied[field]) {
+			continue
+		}
+		for _, entry := range entries {
+			conflict := FieldConflict{Field: field, Manager: entry.Manager}
+			if entry.Manager == manager || seen[conflict] || !overlapsAny(field, entry.Fields) {
+				continue
+			}
+			seen[conflict] = true
+			conflicts = append(conflicts, conflict)
+			conflicting = append(conflicting, field)
+		}
+	}
+	if len(conflicts) > 0 && !force {
+		return nil, &applyConflictError{conflicts: conflicts}
+	}
+
+	var previous []string
+	for _, entry := range entries {
+		if isApplier(entry) {
+			previous = entry.Fields
+		}
+	}
+	entries = releaseFields(entries, conflicting, func(entry runtime.ManagedFieldsEntry) bool { return entry.Manager != manager })
+	// The applied fields move from the updates of manager to its apply, whose
+	// fields are replaced.
+	entries = releaseFields(entries, fields, func(entry runtime.ManagedFieldsEntry) bool { return entry.Manager == manager })
+	entries = releaseFields(entries, previous, isApplier)
+
+	for _, field := range previous {
+		if overlapsAny(field, fields) {
+			continue
+		}
+		managed := false
+		for _, entry := range entries {
+			managed = managed || overlapsAny(field, entry.Fields)
+		}
+		if !managed {
+			path, _ := parsePointer(field)
+			// The fields are in objects, which are modified in place.
+			_, _, _ = removeValue(doc, path)
+		}
+	}
+	for _, field := range fields {
+		path, _ := parsePointer(field)
+		setField(doc, path, applied[field])
+	}
+	return claimFields(entries, manager, runtime.ManagedFieldsApply, fields, at), nil
+}
+
+// setField sets the field at path, creating the objects containing it.
+func setField(doc map[string]interface{}, path []string, value interface{}) {
+	for _, token := range path[:len(path)-1] {
+		child, ok := doc[token].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			doc[token] = child
+		}
+		doc = child
+	}
+	doc[path[len(path)-1]] = value
+}
@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeDoc(t *testing.T, data string) map[string]interface{} {
+	var doc map[string]interface{}
+	require.NoError(t, unmarshalNumbers([]byte(data), &doc))
+	return doc
+}
+
+func TestFieldValues(t *testing.T) {
+	doc := decodeDoc(t, `{"apiVersion":"jobros.io/v1alpha1","metadata":{"uid":"1","labels":{"a/b":"c"},"annotations":{}},"title":"Plumbing","tags":["a"],"address":{"city":null}}`)
+	assert.Equal(t, []string{"/address/city", "/metadata/labels/a~1b", "/tags", "/title"}, sortedFields(fieldValues(doc)))
+}
+
+func TestUpdateManagedFields(t *testing.T) {
+	at := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	entries := []runtime.ManagedFieldsEntry{
+		{Manager: "pricing", Operation: runtime.ManagedFieldsApply, Fields: []string{"/price", "/currency"}},
+		{Manager: "catalog", Operation: runtime.ManagedFieldsApply, Fields: []string{"/title"}},
+	}
+	old := fieldValues(decodeDoc(t, `{"title":"Plumbing","price":40,"currency":"EUR"}`))
+	updated := fieldValues(decodeDoc(t, `{"title":"Plumbing","price":50,"rating":5}`))
+
+	got := updateManagedFields(entries, old, updated, "console", at)
+	assert.Equal(t, []runtime.ManagedFieldsEntry{
+		{Manager: "catalog", Operation: runtime.ManagedFieldsApply, Fields: []string{"/title"}},
+		{Manager: "console", Operation: runtime.ManagedFieldsUpdate, Time: at, Fields: []string{"/price", "/rating"}},
+	}, got)
+	// The entries given are not modified.
+	assert.Equal(t, []string{"/price", "/currency"}, entries[0].Fields)
+
+	assert.Equal(t, entries, updateManagedFields(entries, old, old, "console", at))
+}
+
+func TestApplyConfiguration(t *testing.T) {
+	at := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	entries := []runtime.ManagedFieldsEntry{
+		{Manager: "console", Operation: runtime.ManagedFieldsUpdate, Fields: []string{"/metadata/labels"}},
+		{Manager: "pricing", Operation: runtime.ManagedFieldsApply, Fields: []string{"/currency", "/price"}},
+	}
+
+	// Fields under a field of another manager conflict with it.
+	doc := decodeDoc(t, `{"metadata":{"labels":{"tier":"gold"}},"price":40,"currency":"EUR"}`)
+	_, err := applyConfiguration(doc, decodeDoc(t, `{"metadata":{"labels":{"tier":"silver"}}}`), entries, "catalog", false, at)
+	var conflict *applyConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, []FieldConflict{{Field: "/metadata/labels/tier", Manager: "console"}}, conflict.conflicts)
+
+	// Reapplying without a field removes it.
+	got, err := applyConfiguration(doc, decodeDoc(t, `{"price":45}`), entries, "pricing", false, at)
+	require.NoError(t, err)
+	assert.Equal(t, decodeDoc(t, `{"metadata":{"labels":{"tier":"gold"}},"price":45}`), doc)
+	assert.Equal(t, []runtime.ManagedFieldsEntry{
+		entries[0],
+		{Manager: "pricing", Operation: runtime.ManagedFieldsApply, Time: at, Fields: []string{"/price"}},
+	}, got)
+}
+
+func TestApplyConfiguration_EmptyObject(t *testing.T) {
+	at := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	entries := []runtime.ManagedFieldsEntry{
+		{Manager: "console", Operation: runtime.ManagedFieldsUpdate, Fields: []string{"/metadata/finalizers", "/metadata/labels/tier", "/metadata/name"}},
+	}
+	metadata := `{"name":"jane","labels":{"tier":"gold"},"finalizers":["identity.jobros.io/revoke-tokens"]}`
+	doc := decodeDoc(t, `{"metadata":`+metadata+`,"title":"Plumbing"}`)
+
+	// An empty object applies no field, so the metadata of the other manager
+	// is kept, even when forcing.
+	got, err := applyConfiguration(doc, decodeDoc(t, `{"metadata":{},"title":"Cleaning"}`), entries, "catalog", true, at)
+	require.NoError(t, err)
+	assert.Equal(t, decodeDoc(t, `{"metadata":`+metadata+`,"title":"Cleaning"}`), doc)
+	assert.Equal(t, []runtime.ManagedFieldsEntry{
+		entries[0],
+		{Manager: "catalog", Operation: runtime.ManagedFieldsApply, Time: at, Fields: []string{"/title"}},
+	}, got)
+}
+
+func TestFieldManager(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := func(query, userAgent string) (string, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("PATCH", "/services/1?"+query, nil)
+		c.Request.Header.Set("User-Agent", userAgent)
+		return fieldManager(c)
+	}
+
+	got, err := manager("fieldManager=pricing", "jobros-app/2.1")
+	require.NoError(t, err)
+	assert.Equal(t, "pricing", got)
+	got, err = manager("", "jobros-app/2.1 (iOS)")
+	require.NoError(t, err)
+	assert.Equal(t, "jobros-app", got)
+	got, err = manager("", "")
+	require.NoError(t, err)
+	assert.Equal(t, "unknown", got)
+	_, err = manager("fieldManager="+strings.Repeat("a", MaxFieldManagerLength+1), "")
+	assert.Error(t, err)
+}
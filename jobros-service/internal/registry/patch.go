@@ -0,0 +1,325 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// errTestFailed is returned by JSON patches whose test operation failed, as
+// the object changed since the client read it.
+var errTestFailed = errors.New("test operation failed")
+
+// mergePatch applies a JSON merge patch (RFC 7396) to doc: objects are merged
+// recursively, null removes a field, and any other value replaces it.
+func mergePatch(doc, patch []byte) ([]byte, error) {
+	var target, changes interface{}
+	if err := unmarshalNumbers(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := unmarshalNumbers(patch, &changes); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+	if _, ok := changes.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("merge patch must be a JSON object")
+	}
+	return json.Marshal(merge(target, changes))
+}
+
+func merge(target, patch interface{}) interface{} {
+	fields, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	merged, ok := target.(map[string]interface{})
+	if !ok {
+		merged = make(map[string]interface{}, len(fields))
+	}
+	for key, value := range fields {
+		if value == nil {
+			delete(merged, key)
+		} else {
+			merged[key] = merge(merged[key], value)
+		}
+	}
+	return merged
+}
+
+// unmarshalNumbers decodes data keeping numbers as written, so that large
+// integers survive the round trip.
+func unmarshalNumbers(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// jsonPatch applies a JSON patch (RFC 6902) to doc: its operations are
+// applied in order, and the patch fails as a whole if one fails.
+func jsonPatch(doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := unmarshalNumbers(doc, &target); err != nil {
+		return nil, err
+	}
+	var ops []map[string]interface{}
+	if err := unmarshalNumbers(patch, &ops); err != nil {
+		return nil, fmt.Errorf("JSON patch must be an array of operations: %w", err)
+	}
+	for i, op := range ops {
+		var err error
+		if target, err = applyOperation(target, op); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+// applyOperation applies a JSON patch operation to doc and returns the result.
+func applyOperation(doc interface{}, op map[string]interface{}) (interface{}, error) {
+	name, _ := op["op"].(string)
+	path, err := operationPointer(op, "path")
+	if err != nil {
+		return nil, err
+	}
+	value, hasValue := op["value"]
+	if !hasValue && (name == "add" || name == "replace" || name == "test") {
+		return nil, fmt.Errorf("%s needs a value", name)
+	}
+
+	switch name {
+	case "add":
+		return addValue(doc, path, value)
+	case "remove":
+		doc, _, err := removeValue(doc, path)
+		return doc, err
+	case "replace":
+		if len(path) == 0 {
+			return value, nil
+		}
+		if doc, _, err = removeValue(doc, path); err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	case "move", "copy":
+		from, err := operationPointer(op, "from")
+		if err != nil {
+			return nil, err
+		}
+		if name == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, fmt.Errorf("cannot move %s into itself", formatPointer(from))
+			}
+			if doc, value, err = removeValue(doc, from); err != nil {
+				return nil, err
+			}
+		} else if value, err = getValue(doc, from); err != nil {
+			return nil, err
+		}
+		// Copies must not share their objects and arrays with the source.
+		return addValue(doc, path, copyValue(value))
+	case "test":
+		current, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("%w: %s has another value", errTestFailed, formatPointer(path))
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unknown op %q", name)
+	}
+}
+
+func operationPointer(op map[string]interface{}, member string) ([]string, error) {
+	value, ok := op[member].(string)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a JSON pointer", member)
+	}
+	return parsePointer(value)
+}
+
+// parsePointer splits a JSON pointer (RFC 6901) into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON pointer %q must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// formatPointer joins tokens into a JSON pointer.
+func formatPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// isPrefix reports whether the pointer prefix is path or one of its parents.
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i, token := range prefix {
+		if path[i] != token {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayIndex parses the token of an array element, which must be below max.
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i >= max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("index %q is out of range", token)
+	}
+	return i, nil
+}
+
+// getValue returns the value at path.
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	for i, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", formatPointer(path[:i+1]))
+			}
+			doc = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", formatPointer(path[:i+1]), err)
+			}
+			doc = node[index]
+		default:
+			return nil, fmt.Errorf("%s is not an object or an array", formatPointer(path[:i]))
+		}
+	}
+	return doc, nil
+}
+
+// modifyParent replaces the container of the value at path with the result of
+// modify, which is given the container and the last token.
+func modifyParent(doc interface{}, path []string, modify func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	parent, err := getValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	modified, err := modify(parent, path[len(path)-1])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", formatPointer(path), err)
+	}
+	if len(path) == 1 {
+		return modified, nil
+	}
+	// Arrays change length, so their parent must be updated too.
+	return modifyParent(doc, path[:len(path)-1], func(container interface{}, token string) (interface{}, error) {
+		return setChild(container, token, modified)
+	})
+}
+
+func setChild(container interface{}, token string, value interface{}) (interface{}, error) {
+	switch node := container.(type) {
+	case map[string]interface{}:
+		node[token] = value
+		return node, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(node))
+		if err != nil {
+			return nil, err
+		}
+		node[index] = value
+		return node, nil
+	default:
+		return nil, fmt.Errorf("parent is not an object or an array")
+	}
+}
+
+// addValue adds value at path: it sets a member of an object, or inserts an
+// element in an array, appending it for the "-" index.
+func addValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return modifyParent(doc, path, func(container interface{}, token string) (interface{}, error) {
+		node, ok := container.([]interface{})
+		if !ok {
+			return setChild(container, token, value)
+		}
+		index := len(node)
+		if token != "-" {
+			var err error
+			if index, err = arrayIndex(token, len(node)+1); err != nil {
+				return nil, err
+			}
+		}
+		node = append(node, nil)
+		copy(node[index+1:], node[index:])
+		node[index] = value
+		return node, nil
+	})
+}
+
+// removeValue removes the value at path, which must exist, and returns it.
+func removeValue(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the whole document")
+	}
+	var removed interface{}
+	doc, err := modifyParent(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch node := container.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("does not exist")
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(node))
+			if err != nil {
+				return nil, err
+			}
+			removed = node[index]
+			return append(node[:index:index], node[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("parent is not an object or an array")
+		}
+	})
+	return doc, removed, err
+}
+
+// copyValue returns a deep copy of a decoded JSON value.
+func copyValue(value interface{}) interface{} {
+	switch node := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(node))
+		for key, child := range node {
+			out[key] = copyValue(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(node))
+		for i, child := range node {
+			out[i] = copyValue(child)
+		}
+		return out
+	default:
+		return value
+	}
+}
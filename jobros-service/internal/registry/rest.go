@@ -0,0 +1,858 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang/glog"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/server"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/discovery"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/selection"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// mergePatchType is the content type of JSON merge patches (RFC 7396).
+	mergePatchType = "application/merge-patch+json"
+	// jsonPatchType is the content type of JSON patches (RFC 6902).
+	jsonPatchType = "application/json-patch+json"
+	// applyPatchType is the content type of configurations applied by a field
+	// manager.
+	applyPatchType = "application/apply-patch+json"
+
+	// patchAttempts bounds the retries of a patch without precondition that
+	// raced with another write.
+	patchAttempts = 3
+)
+
+// Options describe a kind served by a REST.
+type Options struct {
+	// Resource is the plural name of the kind, the path segment of its routes
+	// and the name of its collection, such as "users".
+	Resource string
+	// Kind is the served kind.
+	Kind runtime.GroupVersionKind
+	// ShortNames are abbreviations of Resource listed by discovery, such as
+	// "usr".
+	ShortNames []string
+	// StorageVersion is the version objects are stored in. It defaults to the
+	// version of Kind.
+	StorageVersion runtime.GroupVersion
+	Strategy       Strategy
+	// ReadScopes and WriteScopes are the token scopes required to read and to
+	// write objects.
+	ReadScopes  []string
+	WriteScopes []string
+	// FieldSelectors are the fields lists may select objects by, given by
+	// their path in documents of the storage version. They must hold strings
+	// or lists of strings.
+	FieldSelectors selection.Fields
+	// Admission holds the hooks run on writes, after the strategy prepared
+	// the object.
+	Admission *Admission
+}
+
+// REST serves the objects of a kind.
+type REST struct {
+	Options
+	scheme   *runtime.Scheme
+	store    storage.Interface
+	validate *validator.Validate
+}
+
+// NewREST creates a REST over store, which holds objects of the storage
+// version.
+func NewREST(scheme *runtime.Scheme, store storage.Interface, opts Options) *REST {
+	if opts.StorageVersion == (runtime.GroupVersion{}) {
+		opts.StorageVersion = opts.Kind.GroupVersion()
+	}
+	if opts.StorageVersion != opts.Kind.GroupVersion() {
+		store = storage.NewVersioned(store, scheme, opts.StorageVersion, opts.Kind.GroupVersion())
+	}
+
+	validate := validator.New()
+	validate.SetTagName("binding")
+	validate.RegisterTagNameFunc(jsonName)
+	return &REST{Options: opts, scheme: scheme, store: store, validate: validate}
+}
+
+// Store returns the store of the objects, in the served version, for the
+// controllers acting on them.
+func (r *REST) Store() storage.Interface {
+	return r.store
+}
+
+// NewMongoREST creates a REST storing objects in the collection named after
+// the resource, configured by storeOpts, such as the key signing continue
+// tokens.
+func NewMongoREST(ctx context.Context, database *mongo.Database, scheme *runtime.Scheme, opts Options, storeOpts ...storage.Option) (*REST, error) {
+	storageVersion := opts.StorageVersion
+	if storageVersion == (runtime.GroupVersion{}) {
+		storageVersion = opts.Kind.GroupVersion()
+	}
+	storageKind := storageVersion.WithKind(opts.Kind.Kind)
+	if !scheme.Recognizes(storageKind) {
+		return nil, fmt.Errorf("storage kind %s is %w", storageKind, runtime.ErrNotRegistered)
+	}
+
+	fields := make([]string, 0, len(opts.FieldSelectors))
+	for _, path := range opts.FieldSelectors {
+		fields = append(fields, path)
+	}
+	sort.Strings(fields)
+	store, err := storage.NewMongoStore(ctx, database, opts.Resource, func() runtime.Object {
+		obj, _ := scheme.New(storageKind)
+		return obj
+	}, append([]storage.Option{storage.WithIndexedFields(fields...)}, storeOpts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return NewREST(scheme, store, opts), nil
+}
+
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// RegisterRoutes registers the routes of the resource:
+//
+//	GET    /<resource>       list, paginated with limit and continue and
+//	                         selected with labelSelector and fieldSelector,
+//	                         or watch with watch=true
+//	POST   /<resource>       create
+//	GET    /<resource>/:uid  get
+//	PUT    /<resource>/:uid  replace
+//	PATCH  /<resource>/:uid  merge patch, JSON patch or apply
+//	DELETE /<resource>/:uid  delete
+//
+// Every route requires authentication.
+func (r *REST) RegisterRoutes(router gin.IRouter, authMiddleware gin.HandlerFunc) {
+	read := router.Group("/"+r.Resource, authMiddleware)
+	if len(r.ReadScopes) > 0 {
+		read.Use(auth.ScopeMiddleware(r.ReadScopes...))
+	}
+	read.GET("", r.List)
+	read.GET("/:uid", r.Get)
+
+	write := router.Group("/"+r.Resource, authMiddleware)
+	if len(r.WriteScopes) > 0 {
+		write.Use(auth.ScopeMiddleware(r.WriteScopes...))
+	}
+	write.POST("", r.Create)
+	write.PUT("/:uid", r.Update)
+	write.PATCH("/:uid", r.Patch)
+	write.DELETE("/:uid", r.Delete)
+}
+
+// verbs are the operations served by RegisterRoutes.
+var verbs = []string{"get", "list", "watch", "create", "update", "patch", "delete"}
+
+// APIResource describes the resource served with the options, for discovery
+// and the OpenAPI document.
+func (o Options) APIResource() discovery.APIResource {
+	return discovery.APIResource{
+		GroupVersion: o.Kind.GroupVersion(),
+		Name:         o.Resource,
+		Kind:         o.Kind.Kind,
+		OwnerScoped:  o.Strategy.OwnerScoped(),
+		Verbs:        append([]string{}, verbs...),
+		ShortNames:   o.ShortNames,
+	}
+}
+
+// isAdmin reports whether the caller may act on the objects of any owner.
+func isAdmin(claims *auth.JWTClaims) bool {
+	return claims.HasScopes(auth.ScopeUsersAdminister)
+}
+
+// visible reports whether the caller may see obj. Objects of other owners are
+// answered as missing rather than forbidden, to not reveal they exist.
+func (r *REST) visible(claims *auth.JWTClaims, obj runtime.Object) bool {
+	if !r.Strategy.OwnerScoped() || isAdmin(claims) {
+		return true
+	}
+	meta, err := runtime.Accessor(obj)
+	return err == nil && meta.GetOwner() == claims.UserID
+}
+
+// owner returns the owner whose objects the caller lists or watches: the
+// caller for owner-scoped kinds, or for administrators the owner query
+// parameter, empty for every owner.
+func (r *REST) owner(c *gin.Context, claims *auth.JWTClaims) string {
+	switch {
+	case !r.Strategy.OwnerScoped():
+		return ""
+	case isAdmin(claims):
+		return c.Query("owner")
+	default:
+		return claims.UserID
+	}
+}
+
+// respond answers with obj encoded in its version and its ETag.
+func (r *REST) respond(c *gin.Context, status int, obj runtime.Object) {
+	data, err := r.scheme.EncodeJSON(obj)
+	if err != nil {
+		glog.Errorf("failed to encode %s: %v", r.Kind, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	server.SetETag(c, obj)
+	c.Data(status, "application/json; charset=utf-8", data)
+}
+
+// abortWithStoreError answers with the status of a storage or admission error
+// and logs the unexpected ones.
+func (r *REST) abortWithStoreError(c *gin.Context, err error) {
+	var fieldErrs FieldErrors
+	if errors.As(err, &fieldErrs) {
+		r.abortWithFieldErrors(c, fieldErrs)
+		return
+	}
+	var denied *DeniedError
+	if errors.As(err, &denied) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": denied.Reason})
+		return
+	}
+	if !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrConflict) &&
+		!errors.Is(err, storage.ErrAlreadyExists) && !errors.Is(err, storage.ErrInvalidContinue) &&
+		!errors.Is(err, storage.ErrInvalidResumeToken) && !errors.Is(err, storage.ErrResumeExpired) {
+		glog.Errorf("%s storage failed: %v", r.Resource, err)
+	}
+	server.AbortWithStorageError(c, err)
+}
+
+func (r *REST) abortWithFieldErrors(c *gin.Context, errs FieldErrors) {
+	c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+		"error":  fmt.Sprintf("%s is invalid", r.Kind.Kind),
+		"errors": errs,
+	})
+}
+
+// decode decodes an object of the served kind. The apiVersion and kind may be
+// omitted, but must match the route when given. Unknown fields are rejected.
+func (r *REST) decode(data []byte) (runtime.Object, error) {
+	var typeMeta runtime.TypeMeta
+	if err := json.Unmarshal(data, &typeMeta); err != nil {
+		return nil, fmt.Errorf("body must be a JSON object: %w", err)
+	}
+	if typeMeta.APIVersion != "" && typeMeta.APIVersion != r.Kind.GroupVersion().String() {
+		return nil, fmt.Errorf("apiVersion must be %s", r.Kind.GroupVersion())
+	}
+	if typeMeta.Kind != "" && typeMeta.Kind != r.Kind.Kind {
+		return nil, fmt.Errorf("kind must be %s", r.Kind.Kind)
+	}
+
+	obj, err := r.scheme.New(r.Kind)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(obj); err != nil {
+		return nil, err
+	}
+	if typed, ok := obj.(runtime.TypeAccessor); ok {
+		typed.SetGroupVersionKind(r.Kind)
+	}
+	return obj, nil
+}
+
+// validateObject checks the binding tags, labels and finalizers of obj then
+// runs the strategy validation.
+func (r *REST) validateObject(ctx context.Context, obj, old runtime.Object) FieldErrors {
+	errs := bindingErrors(r.validate, obj)
+	if meta, err := runtime.Accessor(obj); err == nil {
+		for _, message := range selection.ValidateLabels(meta.GetLabels()) {
+			errs = append(errs, FieldError{Field: "metadata.labels", Message: message})
+		}
+		errs = append(errs, validateFinalizers(meta, old)...)
+	}
+	if old == nil {
+		errs = append(errs, r.Strategy.Validate(ctx, obj)...)
+	} else {
+		errs = append(errs, r.Strategy.ValidateUpdate(ctx, obj, old)...)
+	}
+	return errs
+}
+
+// admit runs the admission of a create or an update: the mutating hooks, then
+// the validation of the object and the validating hooks, whose field errors
+// are returned together.
+func (r *REST) admit(ctx context.Context, attrs *Attributes) error {
+	if err := r.Admission.Mutate(ctx, attrs); err != nil {
+		return err
+	}
+	errs := r.validateObject(ctx, attrs.Object, attrs.OldObject)
+	err := r.Admission.Validate(ctx, attrs)
+	var hookErrs FieldErrors
+	if errors.As(err, &hookErrs) {
+		errs = append(errs, hookErrs...)
+	} else if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// finalizerPattern matches finalizer names qualified by a domain, such as
+// "jobros.io/revoke-tokens".
+var finalizerPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]*[a-z0-9])?/[A-Za-z0-9]([A-Za-z0-9_.-]*[A-Za-z0-9])?$`)
+
+// validateFinalizers checks the finalizer names, and that none is added to an
+// object being deleted.
+func validateFinalizers(meta runtime.MetaObject, old runtime.Object) FieldErrors {
+	var errs FieldErrors
+	for i, finalizer := range meta.GetFinalizers() {
+		if !finalizerPattern.MatchString(finalizer) {
+			errs = append(errs, FieldError{Field: fmt.Sprintf("metadata.finalizers[%d]", i), Message: fmt.Sprintf("%q must be qualified by a domain, such as jobros.io/cleanup", finalizer)})
+		}
+	}
+	if old == nil {
+		return errs
+	}
+	oldMeta, err := runtime.Accessor(old)
+	if err != nil || oldMeta.GetDeletionTimestamp() == nil {
+		return errs
+	}
+	for i, finalizer := range meta.GetFinalizers() {
+		if !runtime.HasFinalizer(oldMeta, finalizer) {
+			errs = append(errs, FieldError{Field: fmt.Sprintf("metadata.finalizers[%d]", i), Message: "cannot be added to an object being deleted"})
+		}
+	}
+	return errs
+}
+
+// List answers with a page of objects as a <Kind>List. Callers see their own
+// objects of owner-scoped kinds; administrators see every object, or those of
+// the owner query parameter. With watch=true, it streams the changes to the
+// objects instead, see Watch.
+func (r *REST) List(c *gin.Context) {
+	if c.Query("watch") == "true" {
+		r.Watch(c)
+		return
+	}
+	claims, _ := auth.ClaimsFromContext(c)
+
+	opts := storage.ListOptions{Continue: c.Query("continue")}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || n < 1 || n > storage.MaxListLimit {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", storage.MaxListLimit)})
+			return
+		}
+		opts.Limit = n
+	}
+	opts.Owner = r.owner(c, claims)
+	var err error
+	if opts.Labels, err = selection.ParseLabels(c.Query("labelSelector")); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if opts.Fields, err = selection.ParseFields(c.Query("fieldSelector"), r.FieldSelectors); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := r.store.List(c.Request.Context(), opts)
+	if err != nil {
+		r.abortWithStoreError(c, err)
+		return
+	}
+
+	items := make([]json.RawMessage, 0, len(result.Items))
+	for _, obj := range result.Items {
+		data, err := r.scheme.EncodeJSON(obj)
+		if err != nil {
+			glog.Errorf("failed to encode %s: %v", r.Kind, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		items = append(items, data)
+	}
+
+	metadata := gin.H{}
+	if result.Continue != "" {
+		metadata["continue"] = result.Continue
+	}
+	if result.RemainingItemCount != nil {
+		metadata["remainingItemCount"] = *result.RemainingItemCount
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"apiVersion": r.Kind.GroupVersion().String(),
+		"kind":       r.Kind.Kind + "List",
+		"metadata":   metadata,
+		"items":      items,
+	})
+}
+
+// Get answers with the object and its ETag.
+func (r *REST) Get(c *gin.Context) {
+	claims, _ := auth.ClaimsFromContext(c)
+	obj, err := r.store.Get(c.Request.Context(), c.Param("uid"))
+	if err == nil && !r.visible(claims, obj) {
+		err = storage.ErrNotFound
+	}
+	if err != nil {
+		r.abortWithStoreError(c, err)
+		return
+	}
+	r.respond(c, http.StatusOK, obj)
+}
+
+// Create creates the object of the body. Objects of owner-scoped kinds belong
+// to the caller, unless an administrator sets another owner.
+func (r *REST) Create(c *gin.Context) {
+	claims, _ := auth.ClaimsFromContext(c)
+	ctx := c.Request.Context()
+	manager, err := fieldManager(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	obj, err := r.decode(data)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if r.Strategy.OwnerScoped() {
+		meta, err := runtime.Accessor(obj)
+		if err != nil {
+			r.abortWithStoreError(c, err)
+			return
+		}
+		if !isAdmin(claims) || meta.GetOwner() == "" {
+			meta.SetOwner(claims.UserID)
+		}
+	}
+
+	if err := r.CreateObject(ctx, obj, claims, manager); err != nil {
+		r.abortWithStoreError(c, err)
+		return
+	}
+	r.respond(c, http.StatusCreated, obj)
+}
+
+// CreateObject creates obj on behalf of the caller of claims, nil for callers
+// without an account yet such as a registration, as Create does: the strategy
+// prepares obj and admission runs before it is stored. manager is recorded as
+// the manager of its fields. Use AbortWithError to answer its errors.
+func (r *REST) CreateObject(ctx context.Context, obj runtime.Object, claims *auth.JWTClaims, manager string) error {
+	meta, err := runtime.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	// The store assigns the identity and version of new objects.
+	meta.SetUID("")
+	meta.SetResourceVersion("")
+	meta.SetGeneration(0)
+	meta.SetDeletionTimestamp(nil)
+	meta.SetManagedFields(nil)
+	if !r.Strategy.OwnerScoped() {
+		meta.SetOwner("")
+	}
+
+	r.Strategy.PrepareForCreate(ctx, obj)
+	if err := r.admit(ctx, &Attributes{Operation: Create, Kind: r.Kind, Object: obj, User: claims}); err != nil {
+		return err
+	}
+	if err := r.trackFields(obj, nil, manager); err != nil {
+		return err
+	}
+	return r.store.Create(ctx, obj)
+}
+
+// AbortWithError answers with the status of an error of CreateObject.
+func (r *REST) AbortWithError(c *gin.Context, err error) {
+	r.abortWithStoreError(c, err)
+}
+
+// Update replaces the object with the body. The write is conditional on the
+// If-Match header, or else on the resourceVersion of the body when given.
+func (r *REST) Update(c *gin.Context) {
+	manager, err := fieldManager(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := r.decode(data); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Each attempt decodes the body again, as an attempt sets the metadata of
+	// the object it writes.
+	r.update(c, manager, false, func(runtime.Object) (runtime.Object, error) { return r.decode(data) })
+}
+
+// Patch patches the object with a JSON merge patch, a JSON patch, or a
+// configuration applied by the fieldManager, taking over the fields of other
+// managers with force=true. Without If-Match or resourceVersion in the patch,
+// it is retried when another write raced it.
+func (r *REST) Patch(c *gin.Context) {
+	contentType, _, _ := mime.ParseMediaType(c.ContentType())
+	manager, err := fieldManager(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var apply func([]byte, []byte) ([]byte, error)
+	switch contentType {
+	case mergePatchType:
+		apply = mergePatch
+	case jsonPatchType:
+		apply = jsonPatch
+	case applyPatchType:
+		if c.Query("fieldManager") == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "fieldManager is required to apply a configuration"})
+			return
+		}
+		force := c.Query("force") == "true"
+		r.update(c, manager, true, func(old runtime.Object) (runtime.Object, error) {
+			return r.applyConfiguration(old, patch, manager, force)
+		})
+		return
+	default:
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+			"error":               fmt.Sprintf("unsupported patch type %q", contentType),
+			"supportedPatchTypes": []string{mergePatchType, jsonPatchType, applyPatchType},
+		})
+		return
+	}
+
+	r.update(c, manager, false, func(old runtime.Object) (runtime.Object, error) {
+		current, err := r.scheme.EncodeJSON(old)
+		if err != nil {
+			return nil, err
+		}
+		patched, err := apply(current, patch)
+		if err != nil {
+			return nil, err
+		}
+		return r.decode(patched)
+	})
+}
+
+// applyConfiguration returns old with the configuration applied by manager,
+// and its managed fields updated. The resourceVersion of the configuration,
+// when given, is a precondition.
+func (r *REST) applyConfiguration(old runtime.Object, data []byte, manager string, force bool) (runtime.Object, error) {
+	// The configuration must be a valid object, though partial.
+	if _, err := r.decode(data); err != nil {
+		return nil, err
+	}
+	var config map[string]interface{}
+	if err := unmarshalNumbers(data, &config); err != nil {
+		return nil, err
+	}
+	doc, err := r.document(old)
+	if err != nil {
+		return nil, err
+	}
+	oldMeta, err := runtime.Accessor(old)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := applyConfiguration(doc, config, oldMeta.GetManagedFields(), manager, force, now())
+	if err != nil {
+		return nil, err
+	}
+	if metadata, ok := config["metadata"].(map[string]interface{}); ok && metadata["resourceVersion"] != nil {
+		doc["metadata"].(map[string]interface{})["resourceVersion"] = metadata["resourceVersion"]
+	}
+	applied, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := r.decode(applied)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := runtime.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	meta.SetManagedFields(entries)
+	return obj, nil
+}
+
+// document returns obj encoded as a decoded JSON object.
+func (r *REST) document(obj runtime.Object) (map[string]interface{}, error) {
+	data, err := r.scheme.EncodeJSON(obj)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := unmarshalNumbers(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// trackFields records the fields of obj changed from old, nil for a create,
+// as managed by manager through an update.
+func (r *REST) trackFields(obj, old runtime.Object, manager string) error {
+	meta, err := runtime.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	doc, err := r.document(obj)
+	if err != nil {
+		return err
+	}
+	oldValues := map[string]interface{}{}
+	var entries []runtime.ManagedFieldsEntry
+	if old != nil {
+		oldDoc, err := r.document(old)
+		if err != nil {
+			return err
+		}
+		oldValues = fieldValues(oldDoc)
+		oldMeta, err := runtime.Accessor(old)
+		if err != nil {
+			return err
+		}
+		entries = oldMeta.GetManagedFields()
+	}
+	meta.SetManagedFields(updateManagedFields(entries, oldValues, fieldValues(doc), manager, now()))
+	return nil
+}
+
+// badRequestError marks errors of the request body.
+type badRequestError struct{ error }
+
+// update applies the object built from the stored one by build, checking its
+// preconditions, retrying patches racing with other writes.
+func (r *REST) update(c *gin.Context, manager string, apply bool, build func(old runtime.Object) (runtime.Object, error)) {
+	claims, _ := auth.ClaimsFromContext(c)
+	ctx := c.Request.Context()
+	uid := c.Param("uid")
+
+	ifMatch, err := server.IfMatch(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		obj, retry, err := r.tryUpdate(ctx, claims, uid, ifMatch, manager, apply, build)
+		if retry && errors.Is(err, storage.ErrConflict) && attempt < patchAttempts {
+			continue
+		}
+		var conflict *applyConflictError
+		if errors.As(err, &conflict) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+				"error":     "Apply conflicts with other field managers, apply with force=true to take over the fields",
+				"conflicts": conflict.conflicts,
+			})
+			return
+		}
+		if errors.Is(err, errTestFailed) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		var badRequest badRequestError
+		if errors.As(err, &badRequest) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": badRequest.Error()})
+			return
+		}
+		if err != nil {
+			r.abortWithStoreError(c, err)
+			return
+		}
+		r.respond(c, http.StatusOK, obj)
+		return
+	}
+}
+
+// tryUpdate makes one attempt of an update. It reports whether the update had
+// no precondition of the client and can be retried on conflict. The fields
+// changed are recorded as managed by manager, unless it applied a
+// configuration, whose build records them.
+func (r *REST) tryUpdate(ctx context.Context, claims *auth.JWTClaims, uid string, ifMatch string, manager string, apply bool, build func(old runtime.Object) (runtime.Object, error)) (runtime.Object, bool, error) {
+	old, err := r.store.Get(ctx, uid)
+	if err == nil && !r.visible(claims, old) {
+		err = storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	oldMeta, _ := runtime.Accessor(old)
+
+	obj, err := build(old.DeepCopyObject())
+	var conflict *applyConflictError
+	if errors.As(err, &conflict) || errors.Is(err, errTestFailed) {
+		return nil, false, err
+	}
+	if err != nil {
+		return nil, false, badRequestError{err}
+	}
+	meta, err := runtime.Accessor(obj)
+	if err != nil {
+		return nil, false, err
+	}
+	if meta.GetUID() != "" && meta.GetUID() != uid {
+		return nil, false, badRequestError{fmt.Errorf("metadata.uid %s does not match the path", meta.GetUID())}
+	}
+
+	// The precondition is If-Match, or else the resourceVersion of the body. A
+	// patch without one keeps the version it was applied to, and is retried.
+	expected := meta.GetResourceVersion()
+	retry := false
+	if ifMatch != "" {
+		expected = ifMatch
+	} else if expected == "" || expected == oldMeta.GetResourceVersion() {
+		expected = oldMeta.GetResourceVersion()
+		retry = true
+	}
+	if expected != oldMeta.GetResourceVersion() {
+		return nil, false, fmt.Errorf("%w: %s is no longer at resourceVersion %s", storage.ErrConflict, uid, expected)
+	}
+
+	// Metadata managed by the server is kept.
+	meta.SetUID(uid)
+	meta.SetResourceVersion(expected)
+	meta.SetOwner(oldMeta.GetOwner())
+	meta.SetCreationTimestamp(oldMeta.GetCreationTimestamp())
+	meta.SetDeletionTimestamp(oldMeta.GetDeletionTimestamp())
+	meta.SetGeneration(oldMeta.GetGeneration())
+	if !apply {
+		meta.SetManagedFields(oldMeta.GetManagedFields())
+	}
+
+	r.Strategy.PrepareForUpdate(ctx, obj, old)
+	if err := r.admit(ctx, &Attributes{Operation: Update, Kind: r.Kind, Object: obj, OldObject: old, User: claims}); err != nil {
+		return nil, false, err
+	}
+	if !apply {
+		if err := r.trackFields(obj, old, manager); err != nil {
+			return nil, false, err
+		}
+	}
+
+	changed, err := r.specChanged(obj, old)
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		meta.SetGeneration(oldMeta.GetGeneration() + 1)
+	}
+
+	if err := r.store.Update(ctx, obj); err != nil {
+		return nil, retry, err
+	}
+	return obj, false, nil
+}
+
+// specChanged reports whether obj differs from old outside of the metadata, in
+// which case its generation is incremented.
+func (r *REST) specChanged(obj, old runtime.Object) (bool, error) {
+	encode := func(obj runtime.Object) ([]byte, error) {
+		data, err := r.scheme.EncodeJSON(obj)
+		if err != nil {
+			return nil, err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, err
+		}
+		delete(fields, "metadata")
+		return json.Marshal(fields)
+	}
+	a, err := encode(obj)
+	if err != nil {
+		return false, err
+	}
+	b, err := encode(old)
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(a, b), nil
+}
+
+// Delete deletes the object, conditionally on If-Match when given. An object
+// with finalizers is only marked for deletion, and answered with 202 until
+// its finalizers are cleared.
+func (r *REST) Delete(c *gin.Context) {
+	claims, _ := auth.ClaimsFromContext(c)
+	ctx := c.Request.Context()
+	uid := c.Param("uid")
+
+	ifMatch, err := server.IfMatch(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	obj, err := r.store.Get(ctx, uid)
+	if err == nil && !r.visible(claims, obj) {
+		err = storage.ErrNotFound
+	}
+	if err == nil {
+		err = r.Admission.Validate(ctx, &Attributes{Operation: Delete, Kind: r.Kind, OldObject: obj, User: claims})
+	}
+	if err == nil {
+		err = r.store.Delete(ctx, uid, ifMatch)
+	}
+	if err != nil {
+		r.abortWithStoreError(c, err)
+		return
+	}
+
+	if meta, _ := runtime.Accessor(obj); len(meta.GetFinalizers()) > 0 {
+		// Finalizers may have been cleared since.
+		marked, err := r.store.Get(ctx, uid)
+		if err == nil {
+			r.respond(c, http.StatusAccepted, marked)
+			return
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			r.abortWithStoreError(c, err)
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,490 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/selection"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testGroupVersion = runtime.GroupVersion{Group: "jobros.io", Version: "v1alpha1"}
+
+type testService struct {
+	runtime.TypeMeta   `bson:",inline"`
+	runtime.ObjectMeta `json:"metadata" bson:"metadata"`
+	Title              string `json:"title" bson:"title" binding:"required"`
+	Price              int64  `json:"price" bson:"price" binding:"min=0"`
+	// Rating is computed by the server from reviews.
+	Rating float64 `json:"rating" bson:"rating"`
+}
+
+func (s *testService) GetGroupVersionKind() runtime.GroupVersionKind {
+	return testGroupVersion.WithKind("Service")
+}
+
+func (s *testService) DeepCopyObject() runtime.Object {
+	out := *s
+	s.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+type testServiceStrategy struct{}
+
+func (testServiceStrategy) OwnerScoped() bool { return true }
+
+func (testServiceStrategy) PrepareForCreate(_ context.Context, obj runtime.Object) {
+	obj.(*testService).Rating = 0
+}
+
+func (testServiceStrategy) PrepareForUpdate(_ context.Context, obj, old runtime.Object) {
+	obj.(*testService).Rating = old.(*testService).Rating
+}
+
+func (testServiceStrategy) Validate(_ context.Context, obj runtime.Object) FieldErrors {
+	if strings.Contains(obj.(*testService).Title, "free") {
+		return FieldErrors{{Field: "title", Message: "must not advertise free services"}}
+	}
+	return nil
+}
+
+func (s testServiceStrategy) ValidateUpdate(ctx context.Context, obj, _ runtime.Object) FieldErrors {
+	return s.Validate(ctx, obj)
+}
+
+type restTest struct {
+	t      *testing.T
+	router *gin.Engine
+	rest   *REST
+	store  *storage.MemoryStore
+	tokens map[string]string
+}
+
+func newRESTTest(t *testing.T) *restTest {
+	gin.SetMode(gin.TestMode)
+	scheme := runtime.NewScheme()
+	require.NoError(t, scheme.AddKnownType(&testService{}))
+	jwtManager, err := auth.NewJWTManager(auth.WithSecret([]byte("test-secret-key")))
+	require.NoError(t, err)
+
+	rt := &restTest{t: t, router: gin.New(), store: storage.NewMemoryStore(), tokens: map[string]string{}}
+	rest := NewREST(scheme, rt.store, Options{
+		Resource:       "services",
+		Kind:           testGroupVersion.WithKind("Service"),
+		Strategy:       testServiceStrategy{},
+		ReadScopes:     []string{auth.ScopeServicesRead},
+		WriteScopes:    []string{auth.ScopeServicesWrite},
+		FieldSelectors: selection.Fields{"title": "title"},
+	})
+	rest.RegisterRoutes(rt.router, auth.AuthMiddleware(jwtManager))
+	rt.rest = rest
+
+	for user, role := range map[string]string{"provider-1": auth.RoleProvider, "provider-2": auth.RoleProvider, "client-1": auth.RoleClient, "admin-1": auth.RoleAdmin} {
+		rt.tokens[user], err = jwtManager.GenerateAccessToken(user, role)
+		require.NoError(t, err)
+	}
+	return rt
+}
+
+func (rt *restTest) do(user, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+rt.tokens[user])
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	rt.router.ServeHTTP(w, req)
+	return w
+}
+
+func (rt *restTest) create(user, body string) *testService {
+	w := rt.do(user, "POST", "/services", body)
+	require.Equal(rt.t, http.StatusCreated, w.Code, w.Body.String())
+	var service testService
+	require.NoError(rt.t, json.Unmarshal(w.Body.Bytes(), &service))
+	return &service
+}
+
+func TestREST_CreateGet(t *testing.T) {
+	rt := newRESTTest(t)
+	created := rt.create("provider-1", `{"metadata":{"name":"plumbing","owner":"provider-2","uid":"chosen"},"title":"Plumbing","price":4500,"rating":5}`)
+	assert.Equal(t, "jobros.io/v1alpha1", created.APIVersion)
+	assert.Equal(t, "Service", created.Kind)
+	assert.Equal(t, "provider-1", created.Owner)
+	assert.NotEqual(t, "chosen", created.UID)
+	assert.Equal(t, int64(1), created.Generation)
+	assert.Zero(t, created.Rating)
+
+	w := rt.do("provider-1", "GET", "/services/"+created.UID, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"`+created.ResourceVersion+`"`, w.Header().Get("ETag"))
+
+	// Objects of other owners look missing, except to administrators.
+	w = rt.do("provider-2", "GET", "/services/"+created.UID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = rt.do("admin-1", "GET", "/services/"+created.UID, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = rt.do("provider-1", "POST", "/services", `{"metadata":{"name":"plumbing"},"title":"Plumbing again"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestREST_Create_Errors(t *testing.T) {
+	rt := newRESTTest(t)
+	tests := []struct {
+		name   string
+		user   string
+		body   string
+		status int
+	}{
+		{"missing scope", "client-1", `{"title":"Plumbing"}`, http.StatusForbidden},
+		{"not JSON", "provider-1", `title`, http.StatusBadRequest},
+		{"unknown field", "provider-1", `{"title":"Plumbing","colour":"red"}`, http.StatusBadRequest},
+		{"wrong kind", "provider-1", `{"kind":"Booking","title":"Plumbing"}`, http.StatusBadRequest},
+		{"binding tags", "provider-1", `{"price":-1}`, http.StatusUnprocessableEntity},
+		{"strategy", "provider-1", `{"title":"free plumbing"}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := rt.do(tt.user, "POST", "/services", tt.body)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+
+	w := rt.do("provider-1", "POST", "/services", `{"price":-1}`)
+	var body struct {
+		Errors FieldErrors `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.ElementsMatch(t, FieldErrors{
+		{Field: "title", Message: "failed on the required rule"},
+		{Field: "price", Message: "failed on the min=0 rule"},
+	}, body.Errors)
+}
+
+func TestREST_List(t *testing.T) {
+	rt := newRESTTest(t)
+	for i := 0; i < 3; i++ {
+		rt.create("provider-1", `{"title":"Plumbing"}`)
+	}
+	rt.create("provider-2", `{"title":"Gardening"}`)
+
+	var list struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Continue           string `json:"continue"`
+			RemainingItemCount *int64 `json:"remainingItemCount"`
+		} `json:"metadata"`
+		Items []testService `json:"items"`
+	}
+	w := rt.do("provider-1", "GET", "/services?limit=2", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, "ServiceList", list.Kind)
+	assert.Len(t, list.Items, 2)
+	require.NotEmpty(t, list.Metadata.Continue)
+	require.NotNil(t, list.Metadata.RemainingItemCount)
+	assert.Equal(t, int64(1), *list.Metadata.RemainingItemCount)
+	w = rt.do("provider-1", "GET", "/services?limit=2&labelSelector=tier%3Dgold&continue="+list.Metadata.Continue, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = rt.do("provider-1", "GET", "/services?limit=2&continue="+list.Metadata.Continue, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	list.Metadata.Continue = ""
+	list.Metadata.RemainingItemCount = nil
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Items, 1)
+	assert.Empty(t, list.Metadata.Continue)
+	assert.Nil(t, list.Metadata.RemainingItemCount)
+	assert.Equal(t, "provider-1", list.Items[0].Owner)
+
+	w = rt.do("admin-1", "GET", "/services", "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Items, 4)
+	w = rt.do("admin-1", "GET", "/services?owner=provider-2", "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Items, 1)
+
+	assert.Equal(t, http.StatusBadRequest, rt.do("provider-1", "GET", "/services?limit=0", "").Code)
+	assert.Equal(t, http.StatusBadRequest, rt.do("provider-1", "GET", "/services?continue=%25", "").Code)
+}
+
+func TestREST_List_Selectors(t *testing.T) {
+	rt := newRESTTest(t)
+	rt.create("provider-1", `{"metadata":{"labels":{"tier":"gold"}},"title":"Plumbing"}`)
+	rt.create("provider-1", `{"metadata":{"labels":{"tier":"silver"}},"title":"Plumbing"}`)
+	rt.create("provider-1", `{"title":"Gardening"}`)
+
+	list := func(query string) []testService {
+		w := rt.do("provider-1", "GET", "/services?"+query, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var list struct {
+			Items []testService `json:"items"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		return list.Items
+	}
+	assert.Len(t, list("labelSelector=tier%3Dgold"), 1)
+	assert.Len(t, list("labelSelector=tier+notin+(gold)"), 2)
+	assert.Len(t, list("fieldSelector=title%3DPlumbing"), 2)
+	assert.Len(t, list("labelSelector=tier&fieldSelector=title!%3DPlumbing"), 0)
+
+	w := rt.do("provider-1", "GET", "/services?fieldSelector=price%3D10", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `field selector on \"price\" is not supported; supported fields: title`)
+	assert.Equal(t, http.StatusBadRequest, rt.do("provider-1", "GET", "/services?labelSelector=tier+in+(gold", "").Code)
+
+	w = rt.do("provider-1", "POST", "/services", `{"metadata":{"labels":{"jobros.io/tier":"gold"}},"title":"Roofing"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"metadata.labels"`)
+}
+
+func TestREST_Update(t *testing.T) {
+	rt := newRESTTest(t)
+	created := rt.create("provider-1", `{"metadata":{"name":"plumbing"},"title":"Plumbing","price":4500}`)
+	// The rating is owned by the server.
+	stored, err := rt.store.Get(context.Background(), created.UID)
+	require.NoError(t, err)
+	stored.(*testService).Rating = 4.5
+	require.NoError(t, rt.store.Update(context.Background(), stored))
+	current := stored.(*testService).ResourceVersion
+
+	path := "/services/" + created.UID
+	body := `{"metadata":{"name":"plumbing"},"title":"Plumbing and heating","price":5000,"rating":1}`
+	w := rt.do("provider-1", "PUT", path, body, "If-Match", `"`+created.ResourceVersion+`"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = rt.do("provider-1", "PUT", path, body, "If-Match", `"`+current+`"`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated testService
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, "Plumbing and heating", updated.Title)
+	assert.Equal(t, 4.5, updated.Rating)
+	assert.Equal(t, "provider-1", updated.Owner)
+	assert.Equal(t, created.CreationTimestamp, updated.CreationTimestamp)
+	assert.Equal(t, int64(2), updated.Generation)
+
+	// A stale resourceVersion in the body conflicts.
+	stale := `{"metadata":{"resourceVersion":"` + current + `"},"title":"Plumbing"}`
+	assert.Equal(t, http.StatusConflict, rt.do("provider-1", "PUT", path, stale).Code)
+
+	// Rewriting the same object keeps the generation.
+	w = rt.do("provider-1", "PUT", path, body)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, int64(2), updated.Generation)
+
+	assert.Equal(t, http.StatusNotFound, rt.do("provider-2", "PUT", path, body).Code)
+	assert.Equal(t, http.StatusBadRequest, rt.do("provider-1", "PUT", path, `{"metadata":{"uid":"other"},"title":"Plumbing"}`).Code)
+}
+
+// racingStore makes its first update race another write of the object.
+type racingStore struct {
+	storage.Interface
+	raced bool
+}
+
+func (s *racingStore) Update(ctx context.Context, obj runtime.Object) error {
+	if !s.raced {
+		s.raced = true
+		meta, _ := runtime.Accessor(obj)
+		current, err := s.Interface.Get(ctx, meta.GetUID())
+		if err != nil {
+			return err
+		}
+		if err := s.Interface.Update(ctx, current); err != nil {
+			return err
+		}
+	}
+	return s.Interface.Update(ctx, obj)
+}
+
+func TestREST_Update_Retry(t *testing.T) {
+	rt := newRESTTest(t)
+	created := rt.create("provider-1", `{"title":"Plumbing","price":4500}`)
+	rt.rest.store = &racingStore{Interface: rt.store}
+
+	// Without precondition, an update losing a race is retried.
+	w := rt.do("provider-1", "PUT", "/services/"+created.UID, `{"title":"Plumbing and heating","price":5000}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated testService
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, "Plumbing and heating", updated.Title)
+}
+
+func TestREST_Patch(t *testing.T) {
+	rt := newRESTTest(t)
+	created := rt.create("provider-1", `{"metadata":{"labels":{"city":"lyon","tier":"gold"}},"title":"Plumbing","price":4500}`)
+	path := "/services/" + created.UID
+
+	w := rt.do("provider-1", "PATCH", path, `{"price":5000,"metadata":{"labels":{"tier":null}}}`, "Content-Type", mergePatchType)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var patched testService
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &patched))
+	assert.Equal(t, int64(5000), patched.Price)
+	assert.Equal(t, "Plumbing", patched.Title)
+	assert.Equal(t, map[string]string{"city": "lyon"}, patched.Labels)
+
+	w = rt.do("provider-1", "PATCH", path, `{"price":6000}`, "Content-Type", mergePatchType, "If-Match", `"`+created.ResourceVersion+`"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = rt.do("provider-1", "PATCH", path, `{"price":6000}`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	w = rt.do("provider-1", "PATCH", path, `{"title":"free plumbing"}`, "Content-Type", mergePatchType)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = rt.do("provider-1", "PATCH", path, `[]`, "Content-Type", mergePatchType)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestREST_Patch_JSONPatch(t *testing.T) {
+	rt := newRESTTest(t)
+	created := rt.create("provider-1", `{"metadata":{"labels":{"tier":"gold"}},"title":"Plumbing","price":4500}`)
+	path := "/services/" + created.UID
+
+	w := rt.do("provider-1", "PATCH", path, `[{"op":"test","path":"/price","value":4500},{"op":"replace","path":"/price","value":5000},{"op":"remove","path":"/metadata/labels/tier"}]`, "Content-Type", jsonPatchType)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var patched testService
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &patched))
+	assert.Equal(t, int64(5000), patched.Price)
+	assert.Empty(t, patched.Labels)
+
+	// A failed test means the object changed since the client read it.
+	w = rt.do("provider-1", "PATCH", path, `[{"op":"test","path":"/price","value":4500},{"op":"replace","path":"/price","value":0}]`, "Content-Type", jsonPatchType)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = rt.do("provider-1", "PATCH", path, `[{"op":"remove","path":"/missing"}]`, "Content-Type", jsonPatchType)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = rt.do("provider-1", "PATCH", path, `[{"op":"add","path":"/unknown","value":1}]`, "Content-Type", jsonPatchType)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestREST_Patch_Apply(t *testing.T) {
+	rt := newRESTTest(t)
+	created := rt.create("provider-1", `{"title":"Plumbing","price":4500}`)
+	path := "/services/" + created.UID
+	apply := func(manager, config string, query ...string) *httptest.ResponseRecorder {
+		url := path + "?fieldManager=" + manager
+		for _, q := range query {
+			url += "&" + q
+		}
+		return rt.do("provider-1", "PATCH", url, config, "Content-Type", applyPatchType)
+	}
+	get := func() *testService {
+		var service testService
+		require.NoError(t, json.Unmarshal(rt.do("provider-1", "GET", path, "").Body.Bytes(), &service))
+		return &service
+	}
+
+	// Creates record the fields set as managed by the client.
+	require.Len(t, created.ManagedFields, 1)
+	assert.Equal(t, runtime.ManagedFieldsUpdate, created.ManagedFields[0].Operation)
+	assert.Contains(t, created.ManagedFields[0].Fields, "/title")
+
+	w := rt.do("provider-1", "PATCH", path, `{"price":5000}`, "Content-Type", applyPatchType)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "fieldManager is required")
+
+	w = apply("pricing", `{"price":5000,"metadata":{"labels":{"tier":"gold"}}}`)
+	require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	var conflict struct {
+		Conflicts []FieldConflict `json:"conflicts"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &conflict))
+	assert.Equal(t, []FieldConflict{{Field: "/price", Manager: created.ManagedFields[0].Manager}}, conflict.Conflicts)
+
+	// Forcing takes the field over; labels were managed by no one.
+	require.Equal(t, http.StatusOK, apply("pricing", `{"price":5000,"metadata":{"labels":{"tier":"gold"}}}`, "force=true").Code)
+	service := get()
+	assert.Equal(t, int64(5000), service.Price)
+	assert.Equal(t, "Plumbing", service.Title)
+	assert.Equal(t, map[string]string{"tier": "gold"}, service.Labels)
+
+	// Applying the same value as another manager shares the field.
+	require.Equal(t, http.StatusOK, apply("catalog", `{"metadata":{"labels":{"tier":"gold","city":"lyon"}}}`).Code)
+	assert.Equal(t, http.StatusConflict, apply("catalog", `{"price":1}`).Code)
+
+	// Fields left out of the configuration are removed, unless another
+	// manager still manages them.
+	require.Equal(t, http.StatusOK, apply("pricing", `{"price":5000}`).Code)
+	service = get()
+	assert.Equal(t, map[string]string{"tier": "gold", "city": "lyon"}, service.Labels)
+	require.Equal(t, http.StatusOK, apply("catalog", `{"metadata":{"labels":{"city":"lyon"}}}`).Code)
+	assert.Equal(t, map[string]string{"city": "lyon"}, get().Labels)
+
+	// Other writes take fields over without conflict.
+	w = rt.do("provider-1", "PATCH", path+"?fieldManager=console", `{"price":6000}`, "Content-Type", mergePatchType)
+	require.Equal(t, http.StatusOK, w.Code)
+	managers := map[string][]string{}
+	for _, entry := range get().ManagedFields {
+		managers[entry.Manager+"/"+string(entry.Operation)] = entry.Fields
+	}
+	assert.Equal(t, []string{"/price"}, managers["console/Update"])
+	assert.NotContains(t, managers, "pricing/Apply")
+	assert.Equal(t, []string{"/metadata/labels/city"}, managers["catalog/Apply"])
+
+	// A stale resourceVersion in the configuration conflicts.
+	assert.Equal(t, http.StatusConflict, apply("pricing", `{"metadata":{"resourceVersion":"stale"},"price":6000}`).Code)
+	assert.Equal(t, http.StatusBadRequest, apply("pricing", `{"unknown":1}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, apply("pricing", `{"title":"free plumbing"}`, "force=true").Code)
+}
+
+func TestREST_Delete(t *testing.T) {
+	rt := newRESTTest(t)
+	created := rt.create("provider-1", `{"title":"Plumbing"}`)
+	path := "/services/" + created.UID
+
+	assert.Equal(t, http.StatusNotFound, rt.do("provider-2", "DELETE", path, "").Code)
+	assert.Equal(t, http.StatusPreconditionFailed, rt.do("provider-1", "DELETE", path, "", "If-Match", `"stale"`).Code)
+
+	w := rt.do("provider-1", "DELETE", path, "", "If-Match", `"`+created.ResourceVersion+`"`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	body, _ := io.ReadAll(w.Body)
+	assert.Empty(t, body)
+	assert.Equal(t, http.StatusNotFound, rt.do("provider-1", "GET", path, "").Code)
+}
+
+func TestREST_Delete_Finalizers(t *testing.T) {
+	rt := newRESTTest(t)
+	created := rt.create("provider-1", `{"metadata":{"finalizers":["jobros.io/bookings"]},"title":"Plumbing"}`)
+	path := "/services/" + created.UID
+
+	// The object is marked for deletion until its finalizers are cleared.
+	w := rt.do("provider-1", "DELETE", path, "")
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var marked testService
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &marked))
+	assert.NotNil(t, marked.DeletionTimestamp)
+	assert.Equal(t, http.StatusOK, rt.do("provider-1", "GET", path, "").Code)
+
+	w = rt.do("provider-1", "PATCH", path, `{"metadata":{"finalizers":["jobros.io/bookings","jobros.io/payouts"]}}`, "Content-Type", mergePatchType)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "cannot be added to an object being deleted")
+
+	w = rt.do("provider-1", "PATCH", path, `{"metadata":{"finalizers":null}}`, "Content-Type", mergePatchType)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusNotFound, rt.do("provider-1", "GET", path, "").Code)
+
+	w = rt.do("provider-1", "POST", "/services", `{"metadata":{"finalizers":["cleanup"]},"title":"Plumbing"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "metadata.finalizers[0]")
+}
+
+func TestREST_APIResource(t *testing.T) {
+	rt := newRESTTest(t)
+	resource := rt.rest.APIResource()
+	assert.Equal(t, testGroupVersion, resource.GroupVersion)
+	assert.Equal(t, "services", resource.Name)
+	assert.Equal(t, "Service", resource.Kind)
+	assert.True(t, resource.OwnerScoped)
+	assert.Equal(t, []string{"get", "list", "watch", "create", "update", "patch", "delete"}, resource.Verbs)
+}
@@ -0,0 +1,79 @@
+// Package registry serves the registered kinds of the API over REST with the
+// same plumbing for every kind: create, get, list, update, patch and delete
+// handlers on top of a storage.Interface, with consistent errors, pagination
+// and optimistic concurrency. What differs between kinds is given by their
+// Strategy.
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+// FieldError is an invalid field of an object, shown next to the field by the
+// app.
+type FieldError struct {
+	// Field is the JSON path of the field, such as "metadata.name".
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrors are all the invalid fields of an object.
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Field + ": " + err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Strategy adapts the generic handlers to a kind.
+type Strategy interface {
+	// OwnerScoped reports whether objects belong to the account creating them,
+	// like objects of a namespace. Objects of owner-scoped kinds are only
+	// visible to their owner and to administrators.
+	OwnerScoped() bool
+	// PrepareForCreate clears the fields clients cannot set and sets defaults
+	// before obj is validated and created.
+	PrepareForCreate(ctx context.Context, obj runtime.Object)
+	// PrepareForUpdate restores from old the fields clients cannot change, such
+	// as the fields owned by the server or by another endpoint, before obj is
+	// validated and stored.
+	PrepareForUpdate(ctx context.Context, obj, old runtime.Object)
+	// Validate checks a new object.
+	Validate(ctx context.Context, obj runtime.Object) FieldErrors
+	// ValidateUpdate checks an object replacing old.
+	ValidateUpdate(ctx context.Context, obj, old runtime.Object) FieldErrors
+}
+
+// bindingErrors returns the violations of the binding tags of obj, the tags
+// gin checks when binding requests, as field errors.
+func bindingErrors(validate *validator.Validate, obj runtime.Object) FieldErrors {
+	err := validate.Struct(obj)
+	var violations validator.ValidationErrors
+	if !errors.As(err, &violations) {
+		return nil
+	}
+
+	errs := make(FieldErrors, 0, len(violations))
+	for _, violation := range violations {
+		// The namespace starts with the Go type name, replaced by the JSON path.
+		field := violation.Namespace()
+		if i := strings.Index(field, "."); i >= 0 {
+			field = field[i+1:]
+		}
+		message := fmt.Sprintf("failed on the %s rule", violation.Tag())
+		if violation.Param() != "" {
+			message = fmt.Sprintf("failed on the %s=%s rule", violation.Tag(), violation.Param())
+		}
+		errs = append(errs, FieldError{Field: field, Message: message})
+	}
+	return errs
+}
@@ -0,0 +1,84 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/apis/v1alpha1/identity/auth"
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/storage"
+)
+
+// watchHeartbeat is the interval of the comments keeping idle watches open
+// through proxies.
+const watchHeartbeat = 30 * time.Second
+
+// Watch streams the changes to the objects as Server-Sent Events, visible to
+// the caller like with List. Each event is named after its type, ADDED,
+// MODIFIED or DELETED, carries the object as data and has the resume token as
+// id. The watch resumes after the event of the Last-Event-ID header, which
+// EventSource clients send when reconnecting, or of the resumeToken query
+// parameter. A failed watch ends with an ERROR event.
+func (r *REST) Watch(c *gin.Context) {
+	claims, _ := auth.ClaimsFromContext(c)
+	opts := storage.WatchOptions{
+		Owner:       r.owner(c, claims),
+		ResumeToken: c.Query("resumeToken"),
+	}
+	if opts.ResumeToken == "" {
+		opts.ResumeToken = c.GetHeader("Last-Event-ID")
+	}
+
+	ctx := c.Request.Context()
+	events, err := r.store.Watch(ctx, opts)
+	if err != nil {
+		r.abortWithStoreError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(watchHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if !r.writeEvent(c, event) {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeEvent writes event to the stream, and reports whether the watch goes
+// on.
+func (r *REST) writeEvent(c *gin.Context, event storage.Event) bool {
+	if event.Type == storage.EventError {
+		glog.Errorf("%s watch failed: %v", r.Resource, event.Err)
+		fmt.Fprint(c.Writer, "event: ERROR\ndata: {\"error\":\"Watch failed\"}\n\n")
+		return false
+	}
+
+	data, err := r.scheme.EncodeJSON(event.Object)
+	if err != nil {
+		glog.Errorf("failed to encode %s: %v", r.Kind, err)
+		fmt.Fprint(c.Writer, "event: ERROR\ndata: {\"error\":\"Internal server error\"}\n\n")
+		return false
+	}
+	fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ResumeToken, event.Type, data)
+	return true
+}
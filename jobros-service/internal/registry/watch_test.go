@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	id, name string
+	service  testService
+}
+
+// openWatch watches the services as user until the end of the test.
+func (rt *restTest) openWatch(server *httptest.Server, user string, headers ...string) <-chan sseEvent {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/services?watch=true", nil)
+	require.NoError(rt.t, err)
+	req.Header.Set("Authorization", "Bearer "+rt.tokens[user])
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(rt.t, err)
+	require.Equal(rt.t, http.StatusOK, resp.StatusCode)
+	assert.Equal(rt.t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan sseEvent)
+	go func() {
+		defer resp.Body.Close()
+		defer close(events)
+		var event sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ": ")
+			switch field {
+			case "id":
+				event.id = value
+			case "event":
+				event.name = value
+			case "data":
+				assert.NoError(rt.t, json.Unmarshal([]byte(value), &event.service))
+			case "":
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+				event = sseEvent{}
+			}
+		}
+	}()
+	rt.t.Cleanup(cancel)
+	return events
+}
+
+func TestREST_Watch(t *testing.T) {
+	rt := newRESTTest(t)
+	server := httptest.NewServer(rt.router)
+	t.Cleanup(server.Close)
+
+	assert.Equal(t, http.StatusBadRequest, rt.do("provider-1", "GET", "/services?watch=true&resumeToken=x", "").Code)
+
+	events := rt.openWatch(server, "provider-1")
+	rt.create("provider-2", `{"title":"Gardening"}`)
+	created := rt.create("provider-1", `{"title":"Plumbing"}`)
+	path := "/services/" + created.UID
+	require.Equal(t, http.StatusOK, rt.do("provider-1", "PATCH", path, `{"price":40}`, "Content-Type", mergePatchType).Code)
+	require.Equal(t, http.StatusNoContent, rt.do("provider-1", "DELETE", path, "").Code)
+
+	// Events of other owners are not sent.
+	added, modified, deleted := <-events, <-events, <-events
+	assert.Equal(t, "ADDED", added.name)
+	assert.Equal(t, "Plumbing", added.service.Title)
+	assert.Equal(t, created.ResourceVersion, added.service.ResourceVersion)
+	assert.Equal(t, "MODIFIED", modified.name)
+	assert.Equal(t, int64(40), modified.service.Price)
+	assert.Equal(t, "DELETED", deleted.name)
+	assert.Equal(t, created.UID, deleted.service.UID)
+
+	// Reconnecting with the last event received resumes after it.
+	resumed := rt.openWatch(server, "provider-1", "Last-Event-ID", added.id)
+	assert.Equal(t, modified, <-resumed)
+	assert.Equal(t, deleted, <-resumed)
+
+	// Administrators see the events of every owner.
+	all := rt.openWatch(server, "admin-1", "Last-Event-ID", "0")
+	assert.Equal(t, "Gardening", (<-all).service.Title)
+}
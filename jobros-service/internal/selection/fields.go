@@ -0,0 +1,51 @@
+package selection
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Fields are the fields a kind may be selected by, mapping their JSON path, as
+// written in selectors, to their path in stored documents.
+type Fields map[string]string
+
+// names returns the JSON paths of the fields, sorted.
+func (f Fields) names() []string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseFields parses a field selector over the supported fields. The keys of
+// the requirements are the paths of the fields in stored documents. The empty
+// selector selects everything.
+func ParseFields(selector string, fields Fields) (Selector, error) {
+	if strings.TrimSpace(selector) == "" {
+		return nil, nil
+	}
+	terms, err := splitTerms(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid field selector %q: %w", selector, err)
+	}
+
+	s := make(Selector, 0, len(terms))
+	for _, term := range terms {
+		name, op, value, ok := parseComparison(term)
+		if !ok {
+			return nil, fmt.Errorf("invalid field selector %q: %q must compare a field with =, == or !=", selector, term)
+		}
+		path, supported := fields[name]
+		if !supported {
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("field selectors are not supported for this kind")
+			}
+			return nil, fmt.Errorf("field selector on %q is not supported; supported fields: %s", name, strings.Join(fields.names(), ", "))
+		}
+		s = append(s, Requirement{Key: path, Operator: op, Values: []string{value}})
+	}
+	return s, nil
+}
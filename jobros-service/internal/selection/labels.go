@@ -0,0 +1,115 @@
+package selection
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxLabelLength bounds label keys and values.
+const maxLabelLength = 63
+
+// labelPattern matches label keys and non-empty values: alphanumerics, '-' and
+// '_', starting and ending with an alphanumeric. Unlike Kubernetes label keys,
+// keys have no dots or prefix, as MongoDB reads dots in field names as paths.
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9_-]*[A-Za-z0-9])?$`)
+
+// setPattern matches "key in (values)" and "key notin (values)".
+var setPattern = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+
+// ValidateLabelKey returns why key cannot be a label key, if it cannot.
+func ValidateLabelKey(key string) error {
+	if len(key) > maxLabelLength {
+		return fmt.Errorf("must be at most %d characters", maxLabelLength)
+	}
+	if !labelPattern.MatchString(key) {
+		return fmt.Errorf("must consist of alphanumerics, '-' and '_', and start and end with an alphanumeric")
+	}
+	return nil
+}
+
+// ValidateLabelValue returns why value cannot be a label value, if it cannot.
+// Values may be empty.
+func ValidateLabelValue(value string) error {
+	if value == "" {
+		return nil
+	}
+	return ValidateLabelKey(value)
+}
+
+// ValidateLabels returns the errors of the labels of an object by key, sorted.
+func ValidateLabels(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []string
+	for _, key := range keys {
+		if err := ValidateLabelKey(key); err != nil {
+			errs = append(errs, fmt.Sprintf("key %q %v", key, err))
+		} else if err := ValidateLabelValue(labels[key]); err != nil {
+			errs = append(errs, fmt.Sprintf("value %q of %s %v", labels[key], key, err))
+		}
+	}
+	return errs
+}
+
+// ParseLabels parses a label selector. The empty selector selects everything.
+func ParseLabels(selector string) (Selector, error) {
+	if strings.TrimSpace(selector) == "" {
+		return nil, nil
+	}
+	terms, err := splitTerms(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector %q: %w", selector, err)
+	}
+
+	s := make(Selector, 0, len(terms))
+	for _, term := range terms {
+		r, err := parseLabelTerm(term)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %w", selector, err)
+		}
+		s = append(s, r)
+	}
+	return s, nil
+}
+
+func parseLabelTerm(term string) (Requirement, error) {
+	var r Requirement
+	switch {
+	case term == "":
+		return r, fmt.Errorf("empty requirement")
+	case setPattern.MatchString(term):
+		match := setPattern.FindStringSubmatch(term)
+		r = Requirement{Key: match[1], Operator: In}
+		if match[2] == "notin" {
+			r.Operator = NotIn
+		}
+		for _, value := range strings.Split(match[3], ",") {
+			r.Values = append(r.Values, strings.TrimSpace(value))
+		}
+	case strings.HasPrefix(term, "!") && !strings.Contains(term, "="):
+		r = Requirement{Key: strings.TrimSpace(term[1:]), Operator: DoesNotExist}
+	default:
+		key, op, value, ok := parseComparison(term)
+		if !ok {
+			r = Requirement{Key: term, Operator: Exists}
+		} else {
+			r = Requirement{Key: key, Operator: op, Values: []string{value}}
+		}
+	}
+
+	if err := ValidateLabelKey(r.Key); err != nil {
+		return r, fmt.Errorf("label key %q %v", r.Key, err)
+	}
+	for _, value := range r.Values {
+		if err := ValidateLabelValue(value); err != nil {
+			return r, fmt.Errorf("label value %q %v", value, err)
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,127 @@
+// Package selection parses the label and field selectors of list requests
+// into requirements the stores translate into queries.
+//
+// Label selectors follow the Kubernetes syntax: comma-separated requirements,
+// all of which must hold.
+//
+//	tier=premium          the label has the value ("==" is accepted too)
+//	tier!=premium         the label is missing or has another value
+//	tier in (gold,silver) the label has one of the values
+//	tier notin (gold)     the label is missing or has none of the values
+//	tier                  the label is set
+//	!tier                 the label is missing
+//
+// Field selectors select fields of the object with =, == and !=, among the
+// fields supported by its kind.
+package selection
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Operator is the comparison of a Requirement.
+type Operator string
+
+const (
+	Equals       Operator = "="
+	NotEquals    Operator = "!="
+	In           Operator = "in"
+	NotIn        Operator = "notin"
+	Exists       Operator = "exists"
+	DoesNotExist Operator = "!"
+)
+
+// Requirement is a condition on the value of a label or a field.
+type Requirement struct {
+	// Key is the label key, or the path of the field in stored documents.
+	Key      string
+	Operator Operator
+	// Values has one value for Equals and NotEquals, at least one for In and
+	// NotIn, and none otherwise.
+	Values []string
+}
+
+// Matches reports whether the requirement holds for a label or field with the
+// values, none when it is missing. Fields holding a list have a value per item,
+// and match Equals and In when one item does.
+func (r Requirement) Matches(values []string) bool {
+	switch r.Operator {
+	case Exists:
+		return len(values) > 0
+	case DoesNotExist:
+		return len(values) == 0
+	case Equals, In:
+		return containsAny(values, r.Values)
+	case NotEquals, NotIn:
+		return !containsAny(values, r.Values)
+	}
+	return false
+}
+
+func containsAny(values, wanted []string) bool {
+	for _, value := range values {
+		for _, w := range wanted {
+			if value == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Selector is a set of requirements, all of which must hold. The empty
+// selector selects everything.
+type Selector []Requirement
+
+// Matches reports whether every requirement holds, with values returning the
+// values of a key.
+func (s Selector) Matches(values func(key string) []string) bool {
+	for _, r := range s {
+		if !r.Matches(values(r.Key)) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitTerms splits a selector on the commas outside parentheses.
+func splitTerms(selector string) ([]string, error) {
+	var terms []string
+	depth, start := 0, 0
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses")
+			}
+		case ',':
+			if depth == 0 {
+				terms = append(terms, strings.TrimSpace(selector[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses")
+	}
+	return append(terms, strings.TrimSpace(selector[start:])), nil
+}
+
+// parseComparison parses "key=value", "key==value" or "key!=value", returning
+// false when term has no comparison.
+func parseComparison(term string) (key string, op Operator, value string, ok bool) {
+	if i := strings.Index(term, "!="); i >= 0 {
+		return strings.TrimSpace(term[:i]), NotEquals, strings.TrimSpace(term[i+2:]), true
+	}
+	if i := strings.Index(term, "=="); i >= 0 {
+		return strings.TrimSpace(term[:i]), Equals, strings.TrimSpace(term[i+2:]), true
+	}
+	if i := strings.Index(term, "="); i >= 0 {
+		return strings.TrimSpace(term[:i]), Equals, strings.TrimSpace(term[i+1:]), true
+	}
+	return "", "", "", false
+}
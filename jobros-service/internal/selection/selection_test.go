@@ -0,0 +1,107 @@
+package selection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     Selector
+	}{
+		{"", nil},
+		{"tier=premium", Selector{{Key: "tier", Operator: Equals, Values: []string{"premium"}}}},
+		{"tier == premium", Selector{{Key: "tier", Operator: Equals, Values: []string{"premium"}}}},
+		{"tier!=premium", Selector{{Key: "tier", Operator: NotEquals, Values: []string{"premium"}}}},
+		{"tier in (gold, silver)", Selector{{Key: "tier", Operator: In, Values: []string{"gold", "silver"}}}},
+		{"tier notin (gold)", Selector{{Key: "tier", Operator: NotIn, Values: []string{"gold"}}}},
+		{"featured", Selector{{Key: "featured", Operator: Exists}}},
+		{"!featured", Selector{{Key: "featured", Operator: DoesNotExist}}},
+		{"tier in (gold,silver),region=paris,!featured", Selector{
+			{Key: "tier", Operator: In, Values: []string{"gold", "silver"}},
+			{Key: "region", Operator: Equals, Values: []string{"paris"}},
+			{Key: "featured", Operator: DoesNotExist},
+		}},
+		{"region=", Selector{{Key: "region", Operator: Equals, Values: []string{""}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := ParseLabels(tt.selector)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseLabels_Errors(t *testing.T) {
+	for _, selector := range []string{
+		"tier=premium,",
+		"tier in (gold",
+		"tier in gold)",
+		"jobros.io/tier=premium",
+		"tier=premium plus",
+		"-tier",
+		"tier in (gold,-silver)",
+	} {
+		_, err := ParseLabels(selector)
+		assert.ErrorContains(t, err, "invalid label selector", selector)
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	fields := Fields{"status": "status", "metadata.name": "metadata.name"}
+
+	got, err := ParseFields("status=active,metadata.name!=jane", fields)
+	require.NoError(t, err)
+	assert.Equal(t, Selector{
+		{Key: "status", Operator: Equals, Values: []string{"active"}},
+		{Key: "metadata.name", Operator: NotEquals, Values: []string{"jane"}},
+	}, got)
+
+	_, err = ParseFields("email=jane@example.com", fields)
+	assert.EqualError(t, err, `field selector on "email" is not supported; supported fields: metadata.name, status`)
+	_, err = ParseFields("status", fields)
+	assert.ErrorContains(t, err, "must compare a field")
+	_, err = ParseFields("status=active", nil)
+	assert.EqualError(t, err, "field selectors are not supported for this kind")
+}
+
+func TestSelector_Matches(t *testing.T) {
+	labels := map[string][]string{"tier": {"gold"}, "roles": {"client", "provider"}}
+	values := func(key string) []string { return labels[key] }
+
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"", true},
+		{"tier=gold", true},
+		{"tier!=gold", false},
+		{"tier in (gold,silver)", true},
+		{"tier notin (silver)", true},
+		{"region!=paris", true},
+		{"region notin (paris)", true},
+		{"region", false},
+		{"!region", true},
+		{"roles=provider", true},
+		{"roles!=admin", true},
+		{"roles!=client", false},
+		{"tier=gold,region", false},
+	}
+	for _, tt := range tests {
+		s, err := ParseLabels(tt.selector)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, s.Matches(values), tt.selector)
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	assert.Empty(t, ValidateLabels(map[string]string{"tier": "gold", "featured": ""}))
+	assert.Equal(t, []string{
+		`key "jobros.io/tier" must consist of alphanumerics, '-' and '_', and start and end with an alphanumeric`,
+		`value "gold!" of region must consist of alphanumerics, '-' and '_', and start and end with an alphanumeric`,
+	}, ValidateLabels(map[string]string{"region": "gold!", "jobros.io/tier": "gold"}))
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// continueKeySize is the size of the random keys signing continue tokens.
+const continueKeySize = 32
+
+// Option configures a store.
+type Option func(*storeOptions)
+
+type storeOptions struct {
+	continueKey []byte
+	fields      []string
+}
+
+// WithContinueKey signs continue tokens with key. The servers listing the
+// same objects must share it, so that a list started on one continues on
+// another. Without it, stores sign with a random key.
+func WithContinueKey(key []byte) Option {
+	return func(o *storeOptions) {
+		o.continueKey = key
+	}
+}
+
+// WithIndexedFields indexes the document paths lists may select objects by,
+// each along with the UID lists page by. Only MongoStore has indexes.
+func WithIndexedFields(fields ...string) Option {
+	return func(o *storeOptions) {
+		o.fields = append(o.fields, fields...)
+	}
+}
+
+func newStoreOptions(opts []Option) storeOptions {
+	var o storeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if len(o.continueKey) == 0 {
+		o.continueKey = make([]byte, continueKeySize)
+		if _, err := rand.Read(o.continueKey); err != nil {
+			panic(fmt.Sprintf("failed to generate continue key: %v", err))
+		}
+	}
+	return o
+}
+
+// continuation is the position of a list between pages, carried by its
+// continue token.
+type continuation struct {
+	// After is the UID of the last object listed.
+	After string `json:"after"`
+	// Start is when the list started, in Unix milliseconds. Objects created
+	// later are left out, so that a list holds each object existing when it
+	// started once, except those deleted meanwhile, however pages shift.
+	Start int64 `json:"start"`
+	// Query is a digest of the options selecting the objects, which the
+	// following pages must keep.
+	Query string `json:"query"`
+	// Issued is when the token was issued, in Unix milliseconds. Tokens are
+	// valid for ContinueTokenTTL, so that a leaked or forgotten token does
+	// not page for ever.
+	Issued int64 `json:"issued"`
+}
+
+func (c continuation) startTime() time.Time {
+	return time.UnixMilli(c.Start).UTC()
+}
+
+// queryDigest returns the digest of the options selecting the objects.
+func queryDigest(opts ListOptions) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%q %v %v", opts.Owner, opts.Labels, opts.Fields)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// continueCodec signs continue tokens with HMAC-SHA256, so that clients can
+// neither forge nor alter them.
+type continueCodec struct {
+	key []byte
+}
+
+func (c continueCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// encode returns the token resuming a list at cont, issued at now.
+func (c continueCodec) encode(cont continuation, now time.Time) string {
+	cont.Issued = now.UnixMilli()
+	payload, _ := json.Marshal(cont)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload))
+}
+
+// decode returns the position of a list with opts: that of its continue
+// token, or the start of a new list at now.
+func (c continueCodec) decode(opts ListOptions, now time.Time) (continuation, error) {
+	query := queryDigest(opts)
+	if opts.Continue == "" {
+		return continuation{Start: now.UnixMilli(), Query: query}, nil
+	}
+
+	encodedPayload, encodedSignature, _ := strings.Cut(opts.Continue, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return continuation{}, ErrInvalidContinue
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, c.sign(payload)) {
+		return continuation{}, ErrInvalidContinue
+	}
+	var cont continuation
+	if err := json.Unmarshal(payload, &cont); err != nil || cont.After == "" {
+		return continuation{}, ErrInvalidContinue
+	}
+	if cont.Query != query {
+		return continuation{}, fmt.Errorf("%w: the selection changed since the first page", ErrInvalidContinue)
+	}
+	if now.Sub(time.UnixMilli(cont.Issued)) > ContinueTokenTTL {
+		return continuation{}, fmt.Errorf("%w: the token expired, list again from the first page", ErrInvalidContinue)
+	}
+	return cont, nil
+}
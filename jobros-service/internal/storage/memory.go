@@ -0,0 +1,279 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+const (
+	// memoryWatchHistory is the number of past events kept to resume watches.
+	memoryWatchHistory = 1000
+	// memoryWatchBuffer is the number of events a watcher may lag behind
+	// before it is closed.
+	memoryWatchBuffer = 100
+)
+
+// MemoryStore is an Interface keeping objects in memory, for tests and local
+// development.
+type MemoryStore struct {
+	mu        sync.Mutex
+	objects   map[string]runtime.Object
+	revision  int64
+	now       func() time.Time
+	continues continueCodec
+
+	// history holds the last events, oldest first. Every revision is the
+	// revision of one event, which is its resume token.
+	history  []memoryEvent
+	watchers map[*memoryWatcher]struct{}
+}
+
+type memoryEvent struct {
+	revision int64
+	Event
+}
+
+type memoryWatcher struct {
+	owner  string
+	events chan Event
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore(opts ...Option) *MemoryStore {
+	o := newStoreOptions(opts)
+	return &MemoryStore{
+		objects:   make(map[string]runtime.Object),
+		now:       time.Now,
+		continues: continueCodec{key: o.continueKey},
+		watchers:  make(map[*memoryWatcher]struct{}),
+	}
+}
+
+func (s *MemoryStore) nextResourceVersion() string {
+	s.revision++
+	return strconv.FormatInt(s.revision, 10)
+}
+
+func (s *MemoryStore) Create(_ context.Context, obj runtime.Object) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	meta, err := runtime.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.objects[meta.GetUID()]; ok && meta.GetUID() != "" {
+		return ErrAlreadyExists
+	}
+	if meta.GetName() != "" {
+		for _, existing := range s.objects {
+			other, _ := runtime.Accessor(existing)
+			if other.GetName() == meta.GetName() && other.GetOwner() == meta.GetOwner() {
+				return ErrAlreadyExists
+			}
+		}
+	}
+
+	if _, err := prepareCreate(obj, s.now(), s.nextResourceVersion()); err != nil {
+		return err
+	}
+	s.objects[meta.GetUID()] = obj.DeepCopyObject()
+	s.notify(EventAdded, obj)
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, uid string) (runtime.Object, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[uid]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return obj.DeepCopyObject(), nil
+}
+
+func (s *MemoryStore) List(_ context.Context, opts ListOptions) (*ListResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cont, err := s.continues.decode(opts, s.now())
+	if err != nil {
+		return nil, err
+	}
+	start := cont.startTime()
+	uids := make([]string, 0, len(s.objects))
+	for uid, obj := range s.objects {
+		if uid <= cont.After || !matchesOwner(obj, opts.Owner) {
+			continue
+		}
+		meta, _ := runtime.Accessor(obj)
+		if meta.GetCreationTimestamp().After(start) {
+			continue
+		}
+		matches, err := matchesSelectors(obj, opts.Labels, opts.Fields)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			uids = append(uids, uid)
+		}
+	}
+	sort.Strings(uids)
+
+	result := &ListResult{Items: []runtime.Object{}}
+	limit := listLimit(opts)
+	if int64(len(uids)) > limit {
+		remaining := int64(len(uids)) - limit
+		uids = uids[:limit]
+		cont.After = uids[limit-1]
+		result.Continue = s.continues.encode(cont, s.now())
+		result.RemainingItemCount = &remaining
+	}
+	for _, uid := range uids {
+		result.Items = append(result.Items, s.objects[uid].DeepCopyObject())
+	}
+	return result, nil
+}
+
+// checkResourceVersion returns the stored object with the UID, failing unless
+// it has the expected resourceVersion, when one is given.
+func (s *MemoryStore) checkResourceVersion(uid string, expected string) (runtime.Object, error) {
+	stored, ok := s.objects[uid]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	storedMeta, _ := runtime.Accessor(stored)
+	if expected != "" && storedMeta.GetResourceVersion() != expected {
+		return nil, conflictError(uid, expected)
+	}
+	return stored, nil
+}
+
+func (s *MemoryStore) Update(_ context.Context, obj runtime.Object) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	meta, err := runtime.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	if _, err := s.checkResourceVersion(meta.GetUID(), meta.GetResourceVersion()); err != nil {
+		return err
+	}
+
+	meta.SetResourceVersion(s.nextResourceVersion())
+	if removedByUpdate(meta) {
+		delete(s.objects, meta.GetUID())
+		s.notify(EventDeleted, obj)
+		return nil
+	}
+	s.objects[meta.GetUID()] = obj.DeepCopyObject()
+	s.notify(EventModified, obj)
+	return nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, uid string, resourceVersion string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, err := s.checkResourceVersion(uid, resourceVersion)
+	if err != nil {
+		return err
+	}
+	if meta, _ := runtime.Accessor(stored); len(meta.GetFinalizers()) > 0 {
+		if meta.GetDeletionTimestamp() == nil {
+			now := s.now().UTC().Truncate(time.Millisecond)
+			meta.SetDeletionTimestamp(&now)
+			meta.SetResourceVersion(s.nextResourceVersion())
+			s.notify(EventModified, stored)
+		}
+		return nil
+	}
+	delete(s.objects, uid)
+	s.nextResourceVersion()
+	s.notify(EventDeleted, stored)
+	return nil
+}
+
+// notify records the change to obj made at the current revision and sends it
+// to the watchers of its owner. Watchers too far behind are closed, to not
+// block writes. It must be called with the lock held.
+func (s *MemoryStore) notify(eventType EventType, obj runtime.Object) {
+	event := memoryEvent{
+		revision: s.revision,
+		Event:    Event{Type: eventType, Object: obj.DeepCopyObject(), ResumeToken: strconv.FormatInt(s.revision, 10)},
+	}
+	if len(s.history) == memoryWatchHistory {
+		s.history = append(s.history[:0], s.history[1:]...)
+	}
+	s.history = append(s.history, event)
+
+	for watcher := range s.watchers {
+		if !matchesOwner(obj, watcher.owner) {
+			continue
+		}
+		select {
+		case watcher.events <- copyEvent(event.Event):
+		default:
+			s.stopWatcher(watcher)
+		}
+	}
+}
+
+// copyEvent returns event with a copy of its object, so that watchers cannot
+// change each other's objects.
+func copyEvent(event Event) Event {
+	event.Object = event.Object.DeepCopyObject()
+	return event
+}
+
+// stopWatcher closes the events of a registered watcher. It must be called
+// with the lock held.
+func (s *MemoryStore) stopWatcher(watcher *memoryWatcher) {
+	if _, ok := s.watchers[watcher]; ok {
+		delete(s.watchers, watcher)
+		close(watcher.events)
+	}
+}
+
+func (s *MemoryStore) Watch(ctx context.Context, opts WatchOptions) (<-chan Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	after := s.revision
+	if opts.ResumeToken != "" {
+		revision, err := strconv.ParseInt(opts.ResumeToken, 10, 64)
+		if err != nil || revision < 0 || revision > s.revision {
+			return nil, ErrInvalidResumeToken
+		}
+		// The history must still hold the event following the token.
+		if revision < s.revision && (len(s.history) == 0 || s.history[0].revision > revision+1) {
+			return nil, ErrResumeExpired
+		}
+		after = revision
+	}
+
+	var backlog []Event
+	for _, event := range s.history {
+		if event.revision > after && matchesOwner(event.Object, opts.Owner) {
+			backlog = append(backlog, event.Event)
+		}
+	}
+	watcher := &memoryWatcher{owner: opts.Owner, events: make(chan Event, len(backlog)+memoryWatchBuffer)}
+	for _, event := range backlog {
+		watcher.events <- copyEvent(event)
+	}
+	s.watchers[watcher] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.stopWatcher(watcher)
+	}()
+	return watcher.events, nil
+}
@@ -0,0 +1,351 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/selection"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testListing struct {
+	runtime.TypeMeta   `bson:",inline"`
+	runtime.ObjectMeta `json:"metadata" bson:"metadata"`
+	Title              string `json:"title" bson:"title"`
+}
+
+func (l *testListing) GetGroupVersionKind() runtime.GroupVersionKind {
+	return runtime.GroupVersionKind{Group: "jobros.io", Version: "v1alpha1", Kind: "Listing"}
+}
+
+func (l *testListing) DeepCopyObject() runtime.Object {
+	out := *l
+	l.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+func TestMemoryStore_Create(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	listing := &testListing{ObjectMeta: runtime.ObjectMeta{Name: "plumbing", Owner: "provider-1"}, Title: "Plumbing"}
+	require.NoError(t, store.Create(ctx, listing))
+	assert.NotEmpty(t, listing.UID)
+	assert.NotEmpty(t, listing.ResourceVersion)
+	assert.Equal(t, int64(1), listing.Generation)
+	assert.Equal(t, "Listing", listing.Kind)
+	assert.False(t, listing.CreationTimestamp.IsZero())
+
+	duplicate := &testListing{ObjectMeta: runtime.ObjectMeta{Name: "plumbing", Owner: "provider-1"}}
+	assert.ErrorIs(t, store.Create(ctx, duplicate), ErrAlreadyExists)
+
+	otherOwner := &testListing{ObjectMeta: runtime.ObjectMeta{Name: "plumbing", Owner: "provider-2"}}
+	assert.NoError(t, store.Create(ctx, otherOwner))
+
+	_, err := store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryStore_Update_Conflict(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	listing := &testListing{Title: "Plumbing"}
+	require.NoError(t, store.Create(ctx, listing))
+
+	// Two editors read the same version.
+	first, err := store.Get(ctx, listing.UID)
+	require.NoError(t, err)
+	second, err := store.Get(ctx, listing.UID)
+	require.NoError(t, err)
+
+	first.(*testListing).Title = "Plumbing and heating"
+	require.NoError(t, store.Update(ctx, first))
+	assert.NotEqual(t, listing.ResourceVersion, first.(*testListing).ResourceVersion)
+
+	second.(*testListing).Title = "Emergency plumbing"
+	assert.ErrorIs(t, store.Update(ctx, second), ErrConflict)
+
+	stored, err := store.Get(ctx, listing.UID)
+	require.NoError(t, err)
+	assert.Equal(t, "Plumbing and heating", stored.(*testListing).Title)
+
+	// An update without resourceVersion is unconditional.
+	second.(*testListing).ResourceVersion = ""
+	assert.NoError(t, store.Update(ctx, second))
+}
+
+func TestMemoryStore_Delete(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	listing := &testListing{}
+	require.NoError(t, store.Create(ctx, listing))
+
+	assert.ErrorIs(t, store.Delete(ctx, listing.UID, "stale"), ErrConflict)
+	assert.NoError(t, store.Delete(ctx, listing.UID, listing.ResourceVersion))
+	assert.ErrorIs(t, store.Delete(ctx, listing.UID, ""), ErrNotFound)
+}
+
+func TestMemoryStore_Delete_Finalizers(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	listing := &testListing{ObjectMeta: runtime.ObjectMeta{Finalizers: []string{"jobros.io/bookings", "jobros.io/payouts"}}}
+	require.NoError(t, store.Create(ctx, listing))
+
+	// The object is marked rather than removed, once.
+	require.NoError(t, store.Delete(ctx, listing.UID, listing.ResourceVersion))
+	marked, err := store.Get(ctx, listing.UID)
+	require.NoError(t, err)
+	deletedAt := marked.(*testListing).DeletionTimestamp
+	require.NotNil(t, deletedAt)
+	assert.NotEqual(t, listing.ResourceVersion, marked.(*testListing).ResourceVersion)
+	require.NoError(t, store.Delete(ctx, listing.UID, ""))
+	again, err := store.Get(ctx, listing.UID)
+	require.NoError(t, err)
+	assert.Equal(t, marked, again)
+
+	marked.(*testListing).Finalizers = []string{"jobros.io/payouts"}
+	require.NoError(t, store.Update(ctx, marked))
+	_, err = store.Get(ctx, listing.UID)
+	require.NoError(t, err)
+
+	// Clearing the last finalizer removes the object.
+	marked.(*testListing).Finalizers = nil
+	require.NoError(t, store.Update(ctx, marked))
+	_, err = store.Get(ctx, listing.UID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryStore_List(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.Create(ctx, &testListing{ObjectMeta: runtime.ObjectMeta{Owner: "provider-1"}}))
+	}
+	require.NoError(t, store.Create(ctx, &testListing{ObjectMeta: runtime.ObjectMeta{Owner: "provider-2"}}))
+
+	all, err := store.List(ctx, ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, all.Items, 6)
+	assert.Empty(t, all.Continue)
+
+	var uids []string
+	opts := ListOptions{Owner: "provider-1", Limit: 2}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3)
+		page, err := store.List(ctx, opts)
+		require.NoError(t, err)
+		for _, obj := range page.Items {
+			listing := obj.(*testListing)
+			assert.Equal(t, "provider-1", listing.Owner)
+			uids = append(uids, listing.UID)
+		}
+		if page.Continue == "" {
+			break
+		}
+		opts.Continue = page.Continue
+	}
+	assert.Len(t, uids, 5)
+	assert.IsIncreasing(t, uids)
+
+	_, err = store.List(ctx, ListOptions{Continue: "not base64!"})
+	assert.ErrorIs(t, err, ErrInvalidContinue)
+}
+
+func TestMemoryStore_List_Continue(t *testing.T) {
+	ctx := context.Background()
+	key := []byte("continue-key")
+	store := NewMemoryStore(WithContinueKey(key))
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return clock }
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.Create(ctx, &testListing{ObjectMeta: runtime.ObjectMeta{Owner: "provider-1"}}))
+	}
+
+	first, err := store.List(ctx, ListOptions{Owner: "provider-1", Limit: 2})
+	require.NoError(t, err)
+	require.NotEmpty(t, first.Continue)
+	require.NotNil(t, first.RemainingItemCount)
+	assert.Equal(t, int64(3), *first.RemainingItemCount)
+
+	t.Run("objects created after the first page are left out", func(t *testing.T) {
+		clock = clock.Add(time.Second)
+		late := &testListing{ObjectMeta: runtime.ObjectMeta{UID: "zzz", Owner: "provider-1"}}
+		require.NoError(t, store.Create(ctx, late))
+		defer store.Delete(ctx, late.UID, "")
+
+		page, err := store.List(ctx, ListOptions{Owner: "provider-1", Limit: 2, Continue: first.Continue})
+		require.NoError(t, err)
+		require.NotNil(t, page.RemainingItemCount)
+		assert.Equal(t, int64(1), *page.RemainingItemCount)
+		last, err := store.List(ctx, ListOptions{Owner: "provider-1", Limit: 2, Continue: page.Continue})
+		require.NoError(t, err)
+		assert.Len(t, last.Items, 1)
+		assert.Empty(t, last.Continue)
+		assert.Nil(t, last.RemainingItemCount)
+		assert.NotEqual(t, "zzz", last.Items[0].(*testListing).UID)
+
+		fresh, err := store.List(ctx, ListOptions{Owner: "provider-1"})
+		require.NoError(t, err)
+		assert.Len(t, fresh.Items, 6)
+	})
+
+	t.Run("stores sharing the key accept the tokens", func(t *testing.T) {
+		other := NewMemoryStore(WithContinueKey(key))
+		other.now = store.now
+		_, err := other.List(ctx, ListOptions{Owner: "provider-1", Continue: first.Continue})
+		assert.NoError(t, err)
+		other = NewMemoryStore()
+		other.now = store.now
+		_, err = other.List(ctx, ListOptions{Owner: "provider-1", Continue: first.Continue})
+		assert.ErrorIs(t, err, ErrInvalidContinue)
+	})
+
+	t.Run("tokens expire", func(t *testing.T) {
+		issued := clock
+		defer func() { clock = issued }()
+		page, err := store.List(ctx, ListOptions{Owner: "provider-1", Limit: 2})
+		require.NoError(t, err)
+
+		clock = issued.Add(ContinueTokenTTL)
+		_, err = store.List(ctx, ListOptions{Owner: "provider-1", Limit: 2, Continue: page.Continue})
+		assert.NoError(t, err)
+		clock = issued.Add(ContinueTokenTTL + time.Second)
+		_, err = store.List(ctx, ListOptions{Owner: "provider-1", Limit: 2, Continue: page.Continue})
+		assert.ErrorIs(t, err, ErrInvalidContinue)
+	})
+
+	t.Run("tampered tokens are rejected", func(t *testing.T) {
+		payload, signature, ok := strings.Cut(first.Continue, ".")
+		require.True(t, ok)
+		forged := base64.RawURLEncoding.EncodeToString([]byte(`{"after":"","start":0,"query":""}`))
+		for _, token := range []string{
+			forged + "." + signature,
+			payload + "." + base64.RawURLEncoding.EncodeToString([]byte("signature")),
+			payload,
+			"not base64!",
+		} {
+			_, err := store.List(ctx, ListOptions{Owner: "provider-1", Continue: token})
+			assert.ErrorIs(t, err, ErrInvalidContinue, token)
+		}
+	})
+
+	t.Run("tokens are bound to the selection", func(t *testing.T) {
+		_, err := store.List(ctx, ListOptions{Owner: "provider-2", Continue: first.Continue})
+		assert.ErrorIs(t, err, ErrInvalidContinue)
+		labels, err := selection.ParseLabels("tier=gold")
+		require.NoError(t, err)
+		_, err = store.List(ctx, ListOptions{Owner: "provider-1", Labels: labels, Continue: first.Continue})
+		assert.ErrorIs(t, err, ErrInvalidContinue)
+	})
+}
+
+func TestMemoryStore_List_Selectors(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	listings := []*testListing{
+		{ObjectMeta: runtime.ObjectMeta{Labels: map[string]string{"tier": "gold"}}, Title: "Plumbing"},
+		{ObjectMeta: runtime.ObjectMeta{Labels: map[string]string{"tier": "silver"}}, Title: "Plumbing"},
+		{Title: "Gardening"},
+	}
+	for _, listing := range listings {
+		require.NoError(t, store.Create(ctx, listing))
+	}
+
+	titles := func(labels, fields string) []string {
+		opts := ListOptions{}
+		var err error
+		opts.Labels, err = selection.ParseLabels(labels)
+		require.NoError(t, err)
+		opts.Fields, err = selection.ParseFields(fields, selection.Fields{"title": "title"})
+		require.NoError(t, err)
+		result, err := store.List(ctx, opts)
+		require.NoError(t, err)
+		var titles []string
+		for _, obj := range result.Items {
+			titles = append(titles, obj.(*testListing).Title+"/"+obj.(*testListing).Labels["tier"])
+		}
+		sort.Strings(titles)
+		return titles
+	}
+
+	assert.Equal(t, []string{"Plumbing/gold"}, titles("tier=gold", ""))
+	assert.Equal(t, []string{"Gardening/", "Plumbing/silver"}, titles("tier!=gold", ""))
+	assert.Equal(t, []string{"Plumbing/gold", "Plumbing/silver"}, titles("tier in (gold,silver)", ""))
+	assert.Equal(t, []string{"Gardening/"}, titles("!tier", ""))
+	assert.Equal(t, []string{"Plumbing/silver"}, titles("tier,tier!=gold", "title=Plumbing,title!=Gardening"))
+	assert.Empty(t, titles("", "title=Roofing"))
+}
+
+func TestMemoryStore_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := NewMemoryStore()
+	events, err := store.Watch(ctx, WatchOptions{Owner: "provider-1"})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Create(ctx, &testListing{ObjectMeta: runtime.ObjectMeta{Owner: "provider-2"}}))
+	listing := &testListing{ObjectMeta: runtime.ObjectMeta{Owner: "provider-1"}, Title: "Plumbing"}
+	require.NoError(t, store.Create(ctx, listing))
+	listing.Title = "Plumbing and heating"
+	require.NoError(t, store.Update(ctx, listing))
+	require.NoError(t, store.Delete(ctx, listing.UID, ""))
+
+	added, modified, deleted := <-events, <-events, <-events
+	assert.Equal(t, EventAdded, added.Type)
+	assert.Equal(t, "Plumbing", added.Object.(*testListing).Title)
+	assert.Equal(t, EventModified, modified.Type)
+	assert.Equal(t, listing.ResourceVersion, modified.Object.(*testListing).ResourceVersion)
+	assert.Equal(t, EventDeleted, deleted.Type)
+	assert.Equal(t, listing.UID, deleted.Object.(*testListing).UID)
+
+	// A watch resumed from an event replays the events after it.
+	resumed, err := store.Watch(ctx, WatchOptions{Owner: "provider-1", ResumeToken: added.ResumeToken})
+	require.NoError(t, err)
+	assert.Equal(t, modified, <-resumed)
+	assert.Equal(t, deleted, <-resumed)
+
+	cancel()
+	_, open := <-events
+	assert.False(t, open)
+}
+
+func TestMemoryStore_Watch_ResumeErrors(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	for i := 0; i <= memoryWatchHistory; i++ {
+		require.NoError(t, store.Create(ctx, &testListing{}))
+	}
+
+	_, err := store.Watch(ctx, WatchOptions{ResumeToken: "0"})
+	assert.ErrorIs(t, err, ErrResumeExpired)
+	_, err = store.Watch(ctx, WatchOptions{ResumeToken: "1"})
+	assert.NoError(t, err)
+	_, err = store.Watch(ctx, WatchOptions{ResumeToken: "ten"})
+	assert.ErrorIs(t, err, ErrInvalidResumeToken)
+	_, err = store.Watch(ctx, WatchOptions{ResumeToken: "5000"})
+	assert.ErrorIs(t, err, ErrInvalidResumeToken)
+}
+
+func TestMemoryStore_Watch_SlowWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := NewMemoryStore()
+	events, err := store.Watch(ctx, WatchOptions{})
+	require.NoError(t, err)
+
+	// Writes do not wait for watchers: one too far behind is closed.
+	for i := 0; i <= memoryWatchBuffer; i++ {
+		require.NoError(t, store.Create(ctx, &testListing{}))
+	}
+	received := 0
+	for range events {
+		received++
+	}
+	assert.Equal(t, memoryWatchBuffer, received)
+}
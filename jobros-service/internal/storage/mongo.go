@@ -0,0 +1,397 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	creationTimestampField = "metadata.creationTimestamp"
+	resourceVersionField   = "metadata.resourceVersion"
+	deletionTimestampField = "metadata.deletionTimestamp"
+	finalizersField        = "metadata.finalizers"
+)
+
+// MongoStore is an Interface backed by a MongoDB collection. Objects are stored
+// as their BSON encoding with the UID as _id. Resource versions are ObjectIDs,
+// so that they are unique without a shared counter.
+type MongoStore struct {
+	collection *mongo.Collection
+	newObject  func() runtime.Object
+	now        func() time.Time
+	continues  continueCodec
+}
+
+// NewMongoStore creates a MongoStore over the collection, decoding documents
+// into the objects returned by newObject, and ensures its indexes exist.
+func NewMongoStore(ctx context.Context, database *mongo.Database, collection string, newObject func() runtime.Object, opts ...Option) (*MongoStore, error) {
+	o := newStoreOptions(opts)
+	store := &MongoStore{
+		collection: database.Collection(collection),
+		newObject:  newObject,
+		now:        time.Now,
+		continues:  continueCodec{key: o.continueKey},
+	}
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "metadata.owner", Value: 1}, {Key: "metadata.name", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"metadata.name": bson.M{"$exists": true}}),
+		},
+		// Lists of the objects of an owner page by UID.
+		{Keys: bson.D{{Key: "metadata.owner", Value: 1}, {Key: "_id", Value: 1}}},
+		// Label selectors may use any label.
+		{Keys: bson.D{{Key: labelsField + ".$**", Value: 1}}},
+	}
+	for _, field := range o.fields {
+		indexes = append(indexes, mongo.IndexModel{
+			Keys: bson.D{{Key: field, Value: 1}, {Key: "_id", Value: 1}},
+		})
+	}
+	_, err := store.collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create indexes on %s: %w", collection, err)
+	}
+
+	// Pre-images give the owner of deleted objects to watches (MongoDB 6.0).
+	// Without them, only watches of every owner see deletions.
+	err = database.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "changeStreamPreAndPostImages", Value: bson.M{"enabled": true}},
+	}).Err()
+	if err != nil {
+		glog.Warningf("Failed to enable change stream pre-images on %s, owners will not see deletions: %v", collection, err)
+	}
+
+	return store, nil
+}
+
+// document returns the BSON document storing obj under its UID.
+func document(obj runtime.Object, uid string) (bson.D, error) {
+	data, err := bson.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", obj.GetGroupVersionKind().Kind, err)
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return append(bson.D{{Key: "_id", Value: uid}}, doc...), nil
+}
+
+func newResourceVersion() string {
+	return primitive.NewObjectID().Hex()
+}
+
+func (s *MongoStore) Create(ctx context.Context, obj runtime.Object) error {
+	meta, err := prepareCreate(obj, s.now(), newResourceVersion())
+	if err != nil {
+		return err
+	}
+	doc, err := document(obj, meta.GetUID())
+	if err != nil {
+		return err
+	}
+
+	_, err = s.collection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+func (s *MongoStore) Get(ctx context.Context, uid string) (runtime.Object, error) {
+	obj := s.newObject()
+	err := s.collection.FindOne(ctx, bson.M{"_id": uid}).Decode(obj)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (s *MongoStore) List(ctx context.Context, opts ListOptions) (*ListResult, error) {
+	cont, err := s.continues.decode(opts, s.now())
+	if err != nil {
+		return nil, err
+	}
+	query := bson.M{creationTimestampField: bson.M{"$lte": cont.startTime()}}
+	if opts.Owner != "" {
+		query["metadata.owner"] = opts.Owner
+	}
+	if cont.After != "" {
+		query["_id"] = bson.M{"$gt": cont.After}
+	}
+	if conditions := selectorQuery(opts.Labels, opts.Fields); len(conditions) > 0 {
+		query["$and"] = conditions
+	}
+
+	// One more object than the limit tells whether there is a next page.
+	limit := listLimit(opts)
+	cursor, err := s.collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(limit+1))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	result := &ListResult{Items: []runtime.Object{}}
+	more := false
+	for cursor.Next(ctx) {
+		if int64(len(result.Items)) == limit {
+			more = true
+			break
+		}
+		obj := s.newObject()
+		if err := cursor.Decode(obj); err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, obj)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	if !more {
+		return result, nil
+	}
+
+	meta, _ := runtime.Accessor(result.Items[limit-1])
+	cont.After = meta.GetUID()
+	result.Continue = s.continues.encode(cont, s.now())
+	// The count is a separate read, so objects written since the page make it
+	// an estimate.
+	query["_id"] = bson.M{"$gt": cont.After}
+	remaining, err := s.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	result.RemainingItemCount = &remaining
+	return result, nil
+}
+
+// filter selects the object with the UID, at the resourceVersion when given.
+func filter(uid string, resourceVersion string) bson.M {
+	f := bson.M{"_id": uid}
+	if resourceVersion != "" {
+		f[resourceVersionField] = resourceVersion
+	}
+	return f
+}
+
+// missError tells apart a missing object from a failed precondition once a
+// conditional write matched nothing.
+func (s *MongoStore) missError(ctx context.Context, uid string, resourceVersion string) error {
+	count, err := s.collection.CountDocuments(ctx, bson.M{"_id": uid}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return conflictError(uid, resourceVersion)
+}
+
+func (s *MongoStore) Update(ctx context.Context, obj runtime.Object) error {
+	meta, err := runtime.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	expected := meta.GetResourceVersion()
+
+	meta.SetResourceVersion(newResourceVersion())
+	doc, err := document(obj, meta.GetUID())
+	if err != nil {
+		meta.SetResourceVersion(expected)
+		return err
+	}
+
+	var matched int64
+	if removedByUpdate(meta) {
+		var res *mongo.DeleteResult
+		if res, err = s.collection.DeleteOne(ctx, filter(meta.GetUID(), expected)); err == nil {
+			matched = res.DeletedCount
+		}
+	} else {
+		var res *mongo.UpdateResult
+		if res, err = s.collection.ReplaceOne(ctx, filter(meta.GetUID(), expected), doc); err == nil {
+			matched = res.MatchedCount
+		}
+	}
+	if err == nil && matched == 0 {
+		err = s.missError(ctx, meta.GetUID(), expected)
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		err = ErrAlreadyExists
+	}
+	if err != nil {
+		meta.SetResourceVersion(expected)
+		return err
+	}
+	return nil
+}
+
+func (s *MongoStore) Delete(ctx context.Context, uid string, resourceVersion string) error {
+	// Objects with finalizers are marked, once.
+	marking := filter(uid, resourceVersion)
+	marking[finalizersField+".0"] = bson.M{"$exists": true}
+	marking[deletionTimestampField] = bson.M{"$exists": false}
+	res, err := s.collection.UpdateOne(ctx, marking, bson.M{"$set": bson.M{
+		deletionTimestampField: s.now().UTC().Truncate(time.Millisecond),
+		resourceVersionField:   newResourceVersion(),
+	}})
+	if err != nil || res.MatchedCount > 0 {
+		return err
+	}
+
+	removal := filter(uid, resourceVersion)
+	removal[finalizersField+".0"] = bson.M{"$exists": false}
+	deleted, err := s.collection.DeleteOne(ctx, removal)
+	if err != nil || deleted.DeletedCount > 0 {
+		return err
+	}
+
+	// Otherwise the object is missing, at another version, or already marked.
+	marked := filter(uid, resourceVersion)
+	marked[finalizersField+".0"] = bson.M{"$exists": true}
+	count, err := s.collection.CountDocuments(ctx, marked, options.Count().SetLimit(1))
+	if err != nil || count > 0 {
+		return err
+	}
+	return s.missError(ctx, uid, resourceVersion)
+}
+
+// Server error codes of change streams that cannot resume.
+const (
+	changeStreamFatalError  = 280
+	changeStreamHistoryLost = 286
+)
+
+// changeEvent is the part of a change stream event read by Watch.
+type changeEvent struct {
+	OperationType            string   `bson:"operationType"`
+	FullDocument             bson.Raw `bson:"fullDocument"`
+	FullDocumentBeforeChange bson.Raw `bson:"fullDocumentBeforeChange"`
+	DocumentKey              struct {
+		ID string `bson:"_id"`
+	} `bson:"documentKey"`
+}
+
+// Watch follows a change stream of the collection, which requires a replica
+// set. Resume tokens are those of the change stream, and expire with the
+// oplog.
+func (s *MongoStore) Watch(ctx context.Context, opts WatchOptions) (<-chan Event, error) {
+	match := bson.M{"operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}}}
+	if opts.Owner != "" {
+		match["$or"] = bson.A{
+			bson.M{"fullDocument.metadata.owner": opts.Owner},
+			bson.M{"fullDocumentBeforeChange.metadata.owner": opts.Owner},
+		}
+	}
+	streamOpts := options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetFullDocumentBeforeChange(options.WhenAvailable)
+	if opts.ResumeToken != "" {
+		if _, err := hex.DecodeString(opts.ResumeToken); err != nil {
+			return nil, ErrInvalidResumeToken
+		}
+		streamOpts.SetResumeAfter(bson.M{"_data": opts.ResumeToken})
+	}
+
+	stream, err := s.collection.Watch(ctx, mongo.Pipeline{{{Key: "$match", Value: match}}}, streamOpts)
+	if err != nil {
+		return nil, watchError(err)
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer stream.Close(context.Background())
+
+		for stream.Next(ctx) {
+			event, ok, err := s.event(stream)
+			if err != nil {
+				event = Event{Type: EventError, Err: err}
+			} else if !ok {
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			select {
+			case events <- Event{Type: EventError, Err: watchError(err)}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return events, nil
+}
+
+// event returns the Event of the current change of stream, if it has one.
+// Updates of objects deleted since have no document and are skipped.
+func (s *MongoStore) event(stream *mongo.ChangeStream) (Event, bool, error) {
+	var change changeEvent
+	if err := stream.Decode(&change); err != nil {
+		return Event{}, false, err
+	}
+	event := Event{ResumeToken: stream.ResumeToken().Lookup("_data").StringValue()}
+
+	document := change.FullDocument
+	switch change.OperationType {
+	case "insert":
+		event.Type = EventAdded
+	case "update", "replace":
+		event.Type = EventModified
+	case "delete":
+		event.Type = EventDeleted
+		document = change.FullDocumentBeforeChange
+	}
+
+	obj := s.newObject()
+	if document == nil {
+		if event.Type != EventDeleted {
+			return Event{}, false, nil
+		}
+		// Without a pre-image, the deleted object is known by its UID only.
+		meta, err := runtime.Accessor(obj)
+		if err != nil {
+			return Event{}, false, err
+		}
+		meta.SetUID(change.DocumentKey.ID)
+	} else if err := bson.Unmarshal(document, obj); err != nil {
+		return Event{}, false, err
+	}
+	event.Object = obj
+	return event, true, nil
+}
+
+// watchError returns the storage error of a change stream error.
+func watchError(err error) error {
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) &&
+		(serverErr.HasErrorCode(changeStreamHistoryLost) || serverErr.HasErrorCode(changeStreamFatalError)) {
+		return fmt.Errorf("%w: %v", ErrResumeExpired, err)
+	}
+	return err
+}
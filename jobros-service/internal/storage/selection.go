@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"strings"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/selection"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// labelsField is the path of the labels in stored documents.
+const labelsField = "metadata.labels"
+
+// selectorQuery returns the conditions of the label and field selectors on
+// stored documents.
+func selectorQuery(labels, fields selection.Selector) bson.A {
+	conditions := bson.A{}
+	for _, r := range labels {
+		conditions = append(conditions, requirementQuery(labelsField+"."+r.Key, r))
+	}
+	for _, r := range fields {
+		conditions = append(conditions, requirementQuery(r.Key, r))
+	}
+	return conditions
+}
+
+func requirementQuery(path string, r selection.Requirement) bson.M {
+	switch r.Operator {
+	case selection.Equals:
+		return bson.M{path: r.Values[0]}
+	case selection.NotEquals:
+		return bson.M{path: bson.M{"$ne": r.Values[0]}}
+	case selection.In:
+		return bson.M{path: bson.M{"$in": r.Values}}
+	case selection.NotIn:
+		return bson.M{path: bson.M{"$nin": r.Values}}
+	case selection.Exists:
+		return bson.M{path: bson.M{"$exists": true}}
+	default:
+		return bson.M{path: bson.M{"$exists": false}}
+	}
+}
+
+// matchesSelectors evaluates the selectors on obj like selectorQuery does on
+// its stored document. Fields are read from the BSON encoding of obj, and only
+// strings and lists of strings have values.
+func matchesSelectors(obj runtime.Object, labels, fields selection.Selector) (bool, error) {
+	if len(labels) > 0 {
+		meta, err := runtime.Accessor(obj)
+		if err != nil {
+			return false, err
+		}
+		objLabels := meta.GetLabels()
+		matches := labels.Matches(func(key string) []string {
+			if value, ok := objLabels[key]; ok {
+				return []string{value}
+			}
+			return nil
+		})
+		if !matches {
+			return false, nil
+		}
+	}
+	if len(fields) == 0 {
+		return true, nil
+	}
+
+	doc, err := bson.Marshal(obj)
+	if err != nil {
+		return false, err
+	}
+	return fields.Matches(func(path string) []string {
+		return stringValues(bson.Raw(doc), path)
+	}), nil
+}
+
+// stringValues returns the string, or strings of the list, at path in doc.
+func stringValues(doc bson.Raw, path string) []string {
+	value, err := doc.LookupErr(strings.Split(path, ".")...)
+	if err != nil {
+		return nil
+	}
+	switch value.Type {
+	case bsontype.String:
+		return []string{value.StringValue()}
+	case bsontype.Array:
+		items, err := value.Array().Values()
+		if err != nil {
+			return nil
+		}
+		var values []string
+		for _, item := range items {
+			if s, ok := item.StringValueOK(); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/selection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSelectorQuery(t *testing.T) {
+	labels, err := selection.ParseLabels("tier in (gold,silver),region!=paris,featured,!hidden")
+	require.NoError(t, err)
+	fields, err := selection.ParseFields("status=active", selection.Fields{"status": "status"})
+	require.NoError(t, err)
+
+	assert.Equal(t, bson.A{
+		bson.M{"metadata.labels.tier": bson.M{"$in": []string{"gold", "silver"}}},
+		bson.M{"metadata.labels.region": bson.M{"$ne": "paris"}},
+		bson.M{"metadata.labels.featured": bson.M{"$exists": true}},
+		bson.M{"metadata.labels.hidden": bson.M{"$exists": false}},
+		bson.M{"status": "active"},
+	}, selectorQuery(labels, fields))
+}
@@ -0,0 +1,134 @@
+// Package storage persists runtime objects with optimistic concurrency: every
+// write changes the resourceVersion of the object, and updates giving a
+// resourceVersion only apply if the stored object still has it.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/internal/selection"
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+var (
+	// ErrNotFound is returned when the object does not exist.
+	ErrNotFound = errors.New("object not found")
+	// ErrAlreadyExists is returned when creating an object whose UID, or name
+	// within its owner, is taken.
+	ErrAlreadyExists = errors.New("object already exists")
+	// ErrConflict is returned when the stored object does not have the
+	// resourceVersion the write was based on.
+	ErrConflict = errors.New("the object has been modified; apply your changes to the latest version and try again")
+	// ErrInvalidContinue is returned for continue tokens not issued by List.
+	ErrInvalidContinue = errors.New("invalid continue token")
+)
+
+const (
+	// DefaultListLimit is the page size of List when no limit is given.
+	DefaultListLimit = 100
+	// MaxListLimit is the largest page size of List.
+	MaxListLimit = 500
+	// ContinueTokenTTL is how long the continue token of a page is valid.
+	ContinueTokenTTL = time.Hour
+)
+
+// ListOptions select and paginate the objects returned by List. Objects are
+// listed by UID, so that pages are stable while objects are written, and a
+// list leaves out the objects created after its first page.
+type ListOptions struct {
+	// Owner restricts the list to the objects of an owner when not empty.
+	Owner string
+	// Labels and Fields select the objects by labels and fields. The keys of
+	// field requirements are paths in stored documents.
+	Labels selection.Selector
+	Fields selection.Selector
+	// Limit is the maximum number of objects returned, DefaultListLimit when
+	// zero and at most MaxListLimit.
+	Limit int64
+	// Continue is the token returned with the previous page. It is only
+	// valid with the same Owner, Labels and Fields.
+	Continue string
+}
+
+// ListResult is a page of objects.
+type ListResult struct {
+	Items []runtime.Object
+	// Continue is the token of the next page, empty on the last page.
+	Continue string
+	// RemainingItemCount estimates the number of objects after the page, when
+	// there is a next page. Objects written meanwhile make it inexact.
+	RemainingItemCount *int64
+}
+
+// Interface stores the objects of one kind, keyed by UID.
+type Interface interface {
+	// Create stores a new object. It assigns a UID if the object has none, the
+	// creation timestamp, the first generation and a resourceVersion.
+	Create(ctx context.Context, obj runtime.Object) error
+	// Get returns the object with the UID.
+	Get(ctx context.Context, uid string) (runtime.Object, error)
+	// List returns a page of objects.
+	List(ctx context.Context, opts ListOptions) (*ListResult, error)
+	// Update replaces the stored object and assigns it a new resourceVersion.
+	// When obj has a resourceVersion, the update fails with ErrConflict unless
+	// the stored object has the same one; an empty resourceVersion makes the
+	// update unconditional. An update clearing the last finalizer of an object
+	// being deleted removes it.
+	Update(ctx context.Context, obj runtime.Object) error
+	// Delete removes the object. An object with finalizers is only marked for
+	// deletion: its deletionTimestamp is set, and it is removed once its
+	// finalizers are cleared. A non-empty resourceVersion makes the delete
+	// conditional, like Update.
+	Delete(ctx context.Context, uid string, resourceVersion string) error
+	// Watch streams the changes to the objects until ctx is done. The channel
+	// is closed when the watch ends: when ctx is done, after an EventError, or
+	// when the consumer falls too far behind, in which case it resumes from the
+	// last event it received.
+	Watch(ctx context.Context, opts WatchOptions) (<-chan Event, error)
+}
+
+// prepareCreate sets the metadata assigned by the store on creation.
+func prepareCreate(obj runtime.Object, now time.Time, resourceVersion string) (runtime.MetaObject, error) {
+	meta, err := runtime.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	if meta.GetUID() == "" {
+		meta.SetUID(runtime.NewUID())
+	}
+	if meta.GetGeneration() == 0 {
+		meta.SetGeneration(1)
+	}
+	meta.SetCreationTimestamp(now.UTC().Truncate(time.Millisecond))
+	meta.SetResourceVersion(resourceVersion)
+	if typed, ok := obj.(runtime.TypeAccessor); ok {
+		typed.SetGroupVersionKind(obj.GetGroupVersionKind())
+	}
+	return meta, nil
+}
+
+// removedByUpdate reports whether an update of the object removes it: it is
+// being deleted and has no finalizer left.
+func removedByUpdate(meta runtime.MetaObject) bool {
+	return meta.GetDeletionTimestamp() != nil && len(meta.GetFinalizers()) == 0
+}
+
+// conflictError describes a failed precondition on the resourceVersion.
+func conflictError(uid string, expected string) error {
+	return fmt.Errorf("%w: %s is no longer at resourceVersion %s", ErrConflict, uid, expected)
+}
+
+// listLimit returns the page size for opts.
+func listLimit(opts ListOptions) int64 {
+	switch {
+	case opts.Limit <= 0:
+		return DefaultListLimit
+	case opts.Limit > MaxListLimit:
+		return MaxListLimit
+	default:
+		return opts.Limit
+	}
+}
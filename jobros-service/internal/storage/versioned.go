@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+// Versioned stores objects in one version of their kind while serving them in
+// another. Objects are converted to the storage version before being written
+// and to the served version when read, so each served version of the API
+// wraps the same store.
+type Versioned struct {
+	store          Interface
+	scheme         *runtime.Scheme
+	storageVersion runtime.GroupVersion
+	servedVersion  runtime.GroupVersion
+}
+
+var _ Interface = &Versioned{}
+
+// NewVersioned wraps store, which holds objects of storageVersion, to serve
+// them in servedVersion with the conversions of scheme.
+func NewVersioned(store Interface, scheme *runtime.Scheme, storageVersion, servedVersion runtime.GroupVersion) *Versioned {
+	return &Versioned{store: store, scheme: scheme, storageVersion: storageVersion, servedVersion: servedVersion}
+}
+
+func (v *Versioned) Create(ctx context.Context, obj runtime.Object) error {
+	stored, err := v.scheme.ConvertToVersion(obj, v.storageVersion)
+	if err != nil {
+		return err
+	}
+	if err := v.store.Create(ctx, stored); err != nil {
+		return err
+	}
+	return v.copyMeta(stored, obj)
+}
+
+func (v *Versioned) Get(ctx context.Context, uid string) (runtime.Object, error) {
+	stored, err := v.store.Get(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return v.scheme.ConvertToVersion(stored, v.servedVersion)
+}
+
+func (v *Versioned) List(ctx context.Context, opts ListOptions) (*ListResult, error) {
+	result, err := v.store.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i, stored := range result.Items {
+		if result.Items[i], err = v.scheme.ConvertToVersion(stored, v.servedVersion); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (v *Versioned) Update(ctx context.Context, obj runtime.Object) error {
+	stored, err := v.scheme.ConvertToVersion(obj, v.storageVersion)
+	if err != nil {
+		return err
+	}
+	if err := v.store.Update(ctx, stored); err != nil {
+		return err
+	}
+	return v.copyMeta(stored, obj)
+}
+
+func (v *Versioned) Delete(ctx context.Context, uid string, resourceVersion string) error {
+	return v.store.Delete(ctx, uid, resourceVersion)
+}
+
+// Watch converts the objects of the events to the served version. A failed
+// conversion ends the watch with an EventError.
+func (v *Versioned) Watch(ctx context.Context, opts WatchOptions) (<-chan Event, error) {
+	stored, err := v.store.Watch(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		for event := range stored {
+			if event.Object != nil {
+				obj, err := v.scheme.ConvertToVersion(event.Object, v.servedVersion)
+				if err != nil {
+					event = Event{Type: EventError, Err: err}
+				} else {
+					event.Object = obj
+				}
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+			if event.Type == EventError {
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// copyMeta gives obj the metadata the store assigned to stored, its converted
+// copy.
+func (v *Versioned) copyMeta(stored, obj runtime.Object) error {
+	storedMeta, err := runtime.Accessor(stored)
+	if err != nil {
+		return err
+	}
+	meta, err := runtime.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	storedMeta.GetObjectMeta().DeepCopyInto(meta.GetObjectMeta())
+	if typed, ok := obj.(runtime.TypeAccessor); ok {
+		typed.SetGroupVersionKind(obj.GetGroupVersionKind())
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	listingV1alpha1 = runtime.GroupVersion{Group: "jobros.io", Version: "v1alpha1"}
+	listingInternal = runtime.GroupVersion{Group: "jobros.io", Version: runtime.APIVersionInternal}
+	listingV1       = runtime.GroupVersion{Group: "jobros.io", Version: "v1"}
+)
+
+type testInternalListing struct {
+	runtime.TypeMeta
+	runtime.ObjectMeta
+	Title string
+}
+
+func (l *testInternalListing) GetGroupVersionKind() runtime.GroupVersionKind {
+	return listingInternal.WithKind("Listing")
+}
+
+func (l *testInternalListing) DeepCopyObject() runtime.Object {
+	out := *l
+	l.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+// testListingV1 renamed title to headline.
+type testListingV1 struct {
+	runtime.TypeMeta   `bson:",inline"`
+	runtime.ObjectMeta `json:"metadata" bson:"metadata"`
+	Headline           string `json:"headline" bson:"headline"`
+}
+
+func (l *testListingV1) GetGroupVersionKind() runtime.GroupVersionKind {
+	return listingV1.WithKind("Listing")
+}
+
+func (l *testListingV1) DeepCopyObject() runtime.Object {
+	out := *l
+	l.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+func newListingScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, scheme.AddKnownTypes(&testListing{}, &testInternalListing{}, &testListingV1{}))
+
+	conversions := []struct {
+		in, out runtime.Object
+		fn      runtime.ConversionFunc
+	}{
+		{(*testListing)(nil), (*testInternalListing)(nil), func(in, out runtime.Object) error {
+			l, hub := in.(*testListing), out.(*testInternalListing)
+			l.ObjectMeta.DeepCopyInto(&hub.ObjectMeta)
+			hub.Title = l.Title
+			return nil
+		}},
+		{(*testInternalListing)(nil), (*testListing)(nil), func(in, out runtime.Object) error {
+			hub, l := in.(*testInternalListing), out.(*testListing)
+			hub.ObjectMeta.DeepCopyInto(&l.ObjectMeta)
+			l.Title = hub.Title
+			return nil
+		}},
+		{(*testListingV1)(nil), (*testInternalListing)(nil), func(in, out runtime.Object) error {
+			l, hub := in.(*testListingV1), out.(*testInternalListing)
+			l.ObjectMeta.DeepCopyInto(&hub.ObjectMeta)
+			hub.Title = l.Headline
+			return nil
+		}},
+		{(*testInternalListing)(nil), (*testListingV1)(nil), func(in, out runtime.Object) error {
+			hub, l := in.(*testInternalListing), out.(*testListingV1)
+			hub.ObjectMeta.DeepCopyInto(&l.ObjectMeta)
+			l.Headline = hub.Title
+			return nil
+		}},
+	}
+	for _, c := range conversions {
+		require.NoError(t, scheme.AddConversionFunc(c.in, c.out, c.fn))
+	}
+	return scheme
+}
+
+func TestVersioned_ServesSeveralVersions(t *testing.T) {
+	ctx := context.Background()
+	scheme := newListingScheme(t)
+	store := NewMemoryStore()
+	v1alpha1 := NewVersioned(store, scheme, listingV1alpha1, listingV1alpha1)
+	v1 := NewVersioned(store, scheme, listingV1alpha1, listingV1)
+
+	created := &testListingV1{ObjectMeta: runtime.ObjectMeta{Name: "plumbing"}, Headline: "Plumbing"}
+	require.NoError(t, v1.Create(ctx, created))
+	assert.NotEmpty(t, created.UID)
+	assert.NotEmpty(t, created.ResourceVersion)
+	assert.Equal(t, "jobros.io/v1", created.APIVersion)
+
+	// The object is stored in the storage version.
+	stored, err := store.Get(ctx, created.UID)
+	require.NoError(t, err)
+	assert.Equal(t, "Plumbing", stored.(*testListing).Title)
+	assert.Equal(t, "jobros.io/v1alpha1", stored.(*testListing).APIVersion)
+
+	old, err := v1alpha1.Get(ctx, created.UID)
+	require.NoError(t, err)
+	assert.Equal(t, "Plumbing", old.(*testListing).Title)
+
+	old.(*testListing).Title = "Plumbing and heating"
+	require.NoError(t, v1alpha1.Update(ctx, old))
+
+	latest, err := v1.Get(ctx, created.UID)
+	require.NoError(t, err)
+	assert.Equal(t, "Plumbing and heating", latest.(*testListingV1).Headline)
+	assert.Equal(t, old.(*testListing).ResourceVersion, latest.(*testListingV1).ResourceVersion)
+
+	// The version read through v1 before the update is stale.
+	created.Headline = "Emergency plumbing"
+	assert.ErrorIs(t, v1.Update(ctx, created), ErrConflict)
+
+	require.NoError(t, v1.Delete(ctx, created.UID, latest.(*testListingV1).ResourceVersion))
+	_, err = v1alpha1.Get(ctx, created.UID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestVersioned_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := NewMemoryStore()
+	v1 := NewVersioned(store, newListingScheme(t), listingV1alpha1, listingV1)
+	events, err := v1.Watch(ctx, WatchOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Create(ctx, &testListing{Title: "Plumbing"}))
+	event := <-events
+	assert.Equal(t, EventAdded, event.Type)
+	assert.Equal(t, "Plumbing", event.Object.(*testListingV1).Headline)
+	assert.Equal(t, "jobros.io/v1", event.Object.(*testListingV1).APIVersion)
+	assert.NotEmpty(t, event.ResumeToken)
+}
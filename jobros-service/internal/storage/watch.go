@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/maxime-joseph/Jobros/jobros-service/runtime"
+)
+
+var (
+	// ErrInvalidResumeToken is returned for resume tokens not issued by Watch.
+	ErrInvalidResumeToken = errors.New("invalid resume token")
+	// ErrResumeExpired is returned when the events following a resume token
+	// are no longer kept. The client has to list the objects again and watch
+	// from now.
+	ErrResumeExpired = errors.New("resume token has expired; list the objects again and watch from now")
+)
+
+// EventType is the kind of change an Event reports.
+type EventType string
+
+const (
+	EventAdded    EventType = "ADDED"
+	EventModified EventType = "MODIFIED"
+	EventDeleted  EventType = "DELETED"
+	// EventError ends a watch that failed. Its Err tells why.
+	EventError EventType = "ERROR"
+)
+
+// Event is a change to an object.
+type Event struct {
+	Type EventType
+	// Object is the object after the change, or as last stored for
+	// EventDeleted. It is nil for EventError.
+	Object runtime.Object
+	// ResumeToken resumes a watch right after this event.
+	ResumeToken string
+	Err         error
+}
+
+// WatchOptions select the events returned by Watch.
+type WatchOptions struct {
+	// Owner restricts the events to the objects of an owner when not empty.
+	Owner string
+	// ResumeToken starts the watch after the event it was issued with, rather
+	// than at the next change.
+	ResumeToken string
+}
+
+// matchesOwner reports whether obj belongs to owner, any owner matching an
+// empty one.
+func matchesOwner(obj runtime.Object, owner string) bool {
+	if owner == "" {
+		return true
+	}
+	meta, err := runtime.Accessor(obj)
+	return err == nil && meta.GetOwner() == owner
+}
@@ -0,0 +1,87 @@
+package testutils
+
+import (
+	"math/rand"
+	"reflect"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Fill sets the exported fields of the struct obj points to, recursively, to
+// random values drawn from r, for round-trip tests. Slices, maps and pointers
+// are left nil one time out of four and are otherwise given up to three
+// elements, possibly none. Times are in UTC with a whole number of
+// milliseconds, like the times MongoDB stores.
+func Fill(obj interface{}, r *rand.Rand) {
+	fill(reflect.ValueOf(obj).Elem(), r)
+}
+
+func fill(v reflect.Value, r *rand.Rand) {
+	if v.Type() == timeType {
+		v.Set(reflect.ValueOf(time.UnixMilli(r.Int63n(1 << 42)).UTC()))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(r.Int63n(100))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(r.Intn(100)))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(r.Float64())
+	case reflect.String:
+		v.SetString(randomString(r))
+	case reflect.Pointer:
+		if r.Intn(4) == 0 {
+			return
+		}
+		p := reflect.New(v.Type().Elem())
+		fill(p.Elem(), r)
+		v.Set(p)
+	case reflect.Slice:
+		if r.Intn(4) == 0 {
+			return
+		}
+		n := r.Intn(4)
+		s := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			fill(s.Index(i), r)
+		}
+		v.Set(s)
+	case reflect.Map:
+		if r.Intn(4) == 0 {
+			return
+		}
+		m := reflect.MakeMap(v.Type())
+		for i := r.Intn(4); i > 0; i-- {
+			key := reflect.New(v.Type().Key()).Elem()
+			fill(key, r)
+			value := reflect.New(v.Type().Elem()).Elem()
+			fill(value, r)
+			m.SetMapIndex(key, value)
+		}
+		v.Set(m)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fill(v.Index(i), r)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i), r)
+			}
+		}
+	}
+}
+
+func randomString(r *rand.Rand) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz0123456789-"
+	b := make([]byte, r.Intn(12))
+	for i := range b {
+		b[i] = letters[r.Intn(len(letters))]
+	}
+	return string(b)
+}
@@ -0,0 +1,159 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// typeMeta is the apiVersion/kind envelope of an encoded object.
+type typeMeta struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+// EncodeJSON encodes obj with the apiVersion and kind under which its type is
+// registered.
+func (s *Scheme) EncodeJSON(obj Object) ([]byte, error) {
+	gvk, err := s.ObjectKind(obj)
+	if err != nil {
+		return nil, err
+	}
+	if gvk.GroupVersion().IsInternal() {
+		return nil, fmt.Errorf("internal %s cannot be encoded, convert it to a version first", gvk.Kind)
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", gvk, err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("%s does not encode to a JSON object: %w", gvk, err)
+	}
+	fields["apiVersion"], _ = json.Marshal(gvk.GroupVersion().String())
+	fields["kind"], _ = json.Marshal(gvk.Kind)
+	return json.Marshal(fields)
+}
+
+// DecodeJSON decodes an object whose type is selected by its apiVersion and
+// kind. Unknown kinds are rejected with ErrNotRegistered.
+func (s *Scheme) DecodeJSON(data []byte) (Object, error) {
+	var meta typeMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to decode object: %w", err)
+	}
+	if meta.APIVersion == "" || meta.Kind == "" {
+		return nil, fmt.Errorf("object has no apiVersion or kind")
+	}
+	gv, err := ParseGroupVersion(meta.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+	if gv.IsInternal() {
+		return nil, fmt.Errorf("apiVersion %q is internal", meta.APIVersion)
+	}
+
+	obj, err := s.New(gv.WithKind(meta.Kind))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", gv.WithKind(meta.Kind), err)
+	}
+	return obj, nil
+}
+
+// EncodeYAML encodes obj like EncodeJSON, as YAML.
+func (s *Scheme) EncodeYAML(obj Object) ([]byte, error) {
+	data, err := s.EncodeJSON(obj)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML: parsing it into a node keeps the field order and
+	// the exact numbers, and re-encoding it uses the block style.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	clearStyle(&node)
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// DecodeYAML decodes an object like DecodeJSON, from YAML.
+func (s *Scheme) DecodeYAML(data []byte) (Object, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to decode object: %w", err)
+	}
+	value, err := jsonCompatible(value)
+	if err != nil {
+		return nil, err
+	}
+	data, err = json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode object: %w", err)
+	}
+	return s.DecodeJSON(data)
+}
+
+// clearStyle resets the style of the nodes parsed from JSON. Strings that
+// would read back as another type are still quoted by the encoder.
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// jsonCompatible converts the maps decoded from YAML to maps with string keys.
+func jsonCompatible(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			converted, err := jsonCompatible(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			k, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("unsupported non-string key %v", key)
+			}
+			converted, err := jsonCompatible(item)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = converted
+		}
+		return m, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := jsonCompatible(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,111 @@
+package runtime
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// APIVersionInternal is the version of the internal types of a group. Each
+// kind has one internal type, the hub every served version converts to and
+// from, so that N versions need 2N conversion functions rather than N².
+// Internal types are never encoded.
+const APIVersionInternal = "__internal"
+
+// ConversionFunc converts in into out. It is registered for the types of in
+// and out, and must not modify in or set the TypeMeta of out.
+type ConversionFunc func(in, out Object) error
+
+type conversionPair struct {
+	in, out reflect.Type
+}
+
+// IsInternal reports whether gv is the internal version of its group.
+func (gv GroupVersion) IsInternal() bool {
+	return gv.Version == APIVersionInternal
+}
+
+// AddConversionFunc registers fn to convert objects of the type of in into
+// objects of the type of out. in and out are only used for their types and may
+// be typed nil pointers.
+func (s *Scheme) AddConversionFunc(in, out Object, fn ConversionFunc) error {
+	inType, outType := reflect.TypeOf(in), reflect.TypeOf(out)
+	if inType == nil || inType.Kind() != reflect.Pointer || outType == nil || outType.Kind() != reflect.Pointer {
+		return fmt.Errorf("conversion from %T to %T must be between pointers", in, out)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pair := conversionPair{in: inType.Elem(), out: outType.Elem()}
+	if _, ok := s.conversions[pair]; ok {
+		return fmt.Errorf("conversion from %T to %T is already registered", in, out)
+	}
+	s.conversions[pair] = fn
+	return nil
+}
+
+// Convert converts in into out with the conversion registered for their types.
+// Objects of the same type are deep copied.
+func (s *Scheme) Convert(in, out Object) error {
+	inType, outType := reflect.TypeOf(in), reflect.TypeOf(out)
+	if inType == outType {
+		reflect.ValueOf(out).Elem().Set(reflect.ValueOf(in.DeepCopyObject()).Elem())
+		return nil
+	}
+
+	s.mu.RLock()
+	fn, ok := s.conversions[conversionPair{in: inType.Elem(), out: outType.Elem()}]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("conversion from %T to %T is %w", in, out, ErrNotRegistered)
+	}
+	return fn(in, out)
+}
+
+// ConvertToVersion returns a new object of the kind of in, in version gv.
+// Without a conversion registered between the two types, in is converted
+// through the internal type of its kind. The TypeMeta of the result, if it
+// has one, is set to its kind, except for internal objects.
+func (s *Scheme) ConvertToVersion(in Object, gv GroupVersion) (Object, error) {
+	gvk, err := s.ObjectKind(in)
+	if err != nil {
+		return nil, err
+	}
+	target := gv.WithKind(gvk.Kind)
+	out, err := s.New(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.convertVia(in, out, gvk); err != nil {
+		return nil, fmt.Errorf("failed to convert %s to %s: %w", gvk, target, err)
+	}
+
+	if typed, ok := out.(TypeAccessor); ok {
+		if gv.IsInternal() {
+			typed.SetGroupVersionKind(GroupVersionKind{})
+		} else {
+			typed.SetGroupVersionKind(target)
+		}
+	}
+	return out, nil
+}
+
+// convertVia converts in into out directly if possible, otherwise through the
+// internal type of the kind of in.
+func (s *Scheme) convertVia(in, out Object, gvk GroupVersionKind) error {
+	s.mu.RLock()
+	_, direct := s.conversions[conversionPair{in: reflect.TypeOf(in).Elem(), out: reflect.TypeOf(out).Elem()}]
+	s.mu.RUnlock()
+	if direct || reflect.TypeOf(in) == reflect.TypeOf(out) {
+		return s.Convert(in, out)
+	}
+
+	hub, err := s.New(GroupVersion{Group: gvk.Group, Version: APIVersionInternal}.WithKind(gvk.Kind))
+	if err != nil {
+		return err
+	}
+	if err := s.Convert(in, hub); err != nil {
+		return err
+	}
+	return s.Convert(hub, out)
+}
@@ -0,0 +1,133 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testInternalService is the hub of testService and testServiceV2.
+type testInternalService struct {
+	Name       string
+	PriceCents int64
+	Tags       []string
+	Verified   bool
+}
+
+func (s *testInternalService) GetGroupVersionKind() GroupVersionKind {
+	return GroupVersion{Group: testGroupVersion.Group, Version: APIVersionInternal}.WithKind("Service")
+}
+
+func (s *testInternalService) DeepCopyObject() Object {
+	c := *s
+	c.Tags = append([]string(nil), s.Tags...)
+	return &c
+}
+
+// testServiceV2 renamed price and replaced verified by a status.
+type testServiceV2 struct {
+	TypeMeta   `json:",inline"`
+	Name       string   `json:"name"`
+	PriceCents int64    `json:"priceCents"`
+	Labels     []string `json:"labels,omitempty"`
+	Status     string   `json:"status"`
+}
+
+func (s *testServiceV2) GetGroupVersionKind() GroupVersionKind {
+	return GroupVersionKind{Group: testGroupVersion.Group, Version: "v2", Kind: "Service"}
+}
+
+func (s *testServiceV2) DeepCopyObject() Object {
+	c := *s
+	c.Labels = append([]string(nil), s.Labels...)
+	return &c
+}
+
+func newConversionScheme(t *testing.T) *Scheme {
+	scheme := newTestScheme(t)
+	require.NoError(t, scheme.AddKnownTypes(&testInternalService{}, &testServiceV2{}))
+
+	require.NoError(t, scheme.AddConversionFunc((*testService)(nil), (*testInternalService)(nil), func(in, out Object) error {
+		s, hub := in.(*testService), out.(*testInternalService)
+		*hub = testInternalService{Name: s.Name, PriceCents: s.Price, Tags: append([]string(nil), s.Tags...), Verified: s.Verified}
+		return nil
+	}))
+	require.NoError(t, scheme.AddConversionFunc((*testInternalService)(nil), (*testService)(nil), func(in, out Object) error {
+		hub, s := in.(*testInternalService), out.(*testService)
+		*s = testService{Name: hub.Name, Price: hub.PriceCents, Tags: append([]string(nil), hub.Tags...), Verified: hub.Verified}
+		return nil
+	}))
+	require.NoError(t, scheme.AddConversionFunc((*testServiceV2)(nil), (*testInternalService)(nil), func(in, out Object) error {
+		s, hub := in.(*testServiceV2), out.(*testInternalService)
+		*hub = testInternalService{Name: s.Name, PriceCents: s.PriceCents, Tags: append([]string(nil), s.Labels...), Verified: s.Status == "verified"}
+		return nil
+	}))
+	require.NoError(t, scheme.AddConversionFunc((*testInternalService)(nil), (*testServiceV2)(nil), func(in, out Object) error {
+		hub, s := in.(*testInternalService), out.(*testServiceV2)
+		*s = testServiceV2{Name: hub.Name, PriceCents: hub.PriceCents, Labels: append([]string(nil), hub.Tags...), Status: "unverified"}
+		if hub.Verified {
+			s.Status = "verified"
+		}
+		return nil
+	}))
+	return scheme
+}
+
+func TestScheme_ConvertToVersion(t *testing.T) {
+	scheme := newConversionScheme(t)
+	v1 := &testService{Name: "plumbing", Price: 4500, Tags: []string{"emergency"}, Verified: true}
+
+	converted, err := scheme.ConvertToVersion(v1, GroupVersion{Group: "jobros.io", Version: "v2"})
+	require.NoError(t, err)
+	v2 := converted.(*testServiceV2)
+	assert.Equal(t, "jobros.io/v2", v2.APIVersion)
+	assert.Equal(t, "Service", v2.Kind)
+	assert.Equal(t, int64(4500), v2.PriceCents)
+	assert.Equal(t, []string{"emergency"}, v2.Labels)
+	assert.Equal(t, "verified", v2.Status)
+
+	back, err := scheme.ConvertToVersion(v2, testGroupVersion)
+	require.NoError(t, err)
+	assert.Equal(t, v1, back)
+
+	v2.Labels[0] = "changed"
+	assert.Equal(t, []string{"emergency"}, v1.Tags)
+}
+
+func TestScheme_ConvertToVersion_SameVersion(t *testing.T) {
+	scheme := newConversionScheme(t)
+	v1 := &testService{Name: "plumbing", Tags: []string{"emergency"}}
+
+	copied, err := scheme.ConvertToVersion(v1, testGroupVersion)
+	require.NoError(t, err)
+	assert.Equal(t, v1, copied)
+	copied.(*testService).Tags[0] = "changed"
+	assert.Equal(t, "emergency", v1.Tags[0])
+}
+
+func TestScheme_ConvertToVersion_NotRegistered(t *testing.T) {
+	scheme := newTestScheme(t)
+	require.NoError(t, scheme.AddKnownType(&testServiceV2{}))
+
+	_, err := scheme.ConvertToVersion(&testService{}, GroupVersion{Group: "jobros.io", Version: "v2"})
+	assert.ErrorIs(t, err, ErrNotRegistered)
+
+	_, err = scheme.ConvertToVersion(&testService{}, GroupVersion{Group: "jobros.io", Version: "v3"})
+	assert.ErrorIs(t, err, ErrNotRegistered)
+}
+
+func TestScheme_AddConversionFunc_Duplicate(t *testing.T) {
+	scheme := newConversionScheme(t)
+	err := scheme.AddConversionFunc((*testService)(nil), (*testInternalService)(nil), func(in, out Object) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestScheme_Internal_NotEncoded(t *testing.T) {
+	scheme := newConversionScheme(t)
+	_, err := scheme.EncodeJSON(&testInternalService{Name: "plumbing"})
+	assert.Error(t, err)
+
+	_, err = scheme.DecodeJSON([]byte(`{"apiVersion":"jobros.io/__internal","kind":"Service"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,196 @@
+package runtime
+
+//go:generate go run github.com/maxime-joseph/Jobros/jobros-service/cmd/deepcopy-gen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+)
+
+// TypeMeta carries the apiVersion and kind of an encoded object.
+//
+// +jobros:deepcopy-gen=true
+type TypeMeta struct {
+	APIVersion string `json:"apiVersion,omitempty" bson:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty" bson:"kind,omitempty"`
+}
+
+// GetAPIVersion returns the apiVersion of the object.
+func (t *TypeMeta) GetAPIVersion() string { return t.APIVersion }
+
+// GetKind returns the kind of the object.
+func (t *TypeMeta) GetKind() string { return t.Kind }
+
+// SetGroupVersionKind sets the apiVersion and kind from gvk.
+func (t *TypeMeta) SetGroupVersionKind(gvk GroupVersionKind) {
+	t.APIVersion = gvk.GroupVersion().String()
+	t.Kind = gvk.Kind
+}
+
+// ObjectMeta is the metadata every stored resource carries. Stores key objects
+// by UID; Name is unique among the objects of a kind with the same Owner.
+//
+// +jobros:deepcopy-gen=true
+type ObjectMeta struct {
+	Name string `json:"name,omitempty" bson:"name,omitempty"`
+	UID  string `json:"uid,omitempty" bson:"uid,omitempty"`
+	// Owner scopes the object, like a namespace: the ID of the account the
+	// object belongs to. It is empty for cluster-wide objects such as users.
+	Owner       string            `json:"owner,omitempty" bson:"owner,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty" bson:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty" bson:"annotations,omitempty"`
+	// ResourceVersion changes on every write and is used for optimistic
+	// concurrency. Clients must treat it as opaque.
+	ResourceVersion string `json:"resourceVersion,omitempty" bson:"resourceVersion,omitempty"`
+	// Generation is incremented on every change of the desired state.
+	Generation        int64     `json:"generation,omitempty" bson:"generation,omitempty"`
+	CreationTimestamp time.Time `json:"creationTimestamp" bson:"creationTimestamp,omitempty"`
+	// DeletionTimestamp is set when the object is deleted while it has
+	// finalizers. The object is removed once they are all cleared.
+	DeletionTimestamp *time.Time `json:"deletionTimestamp,omitempty" bson:"deletionTimestamp,omitempty"`
+	// Finalizers name the cleanups to perform before the object is removed,
+	// each cleared by the controller performing it.
+	Finalizers []string `json:"finalizers,omitempty" bson:"finalizers,omitempty"`
+	// ManagedFields record which client set which fields, so that clients
+	// applying configurations do not overwrite each other unknowingly.
+	ManagedFields []ManagedFieldsEntry `json:"managedFields,omitempty" bson:"managedFields,omitempty"`
+}
+
+// ManagedFieldsOperation is the kind of write through which a manager set
+// its fields.
+type ManagedFieldsOperation string
+
+const (
+	// ManagedFieldsApply is for the fields of the configuration a manager
+	// applied last.
+	ManagedFieldsApply ManagedFieldsOperation = "Apply"
+	// ManagedFieldsUpdate is for the fields a manager changed with other
+	// writes.
+	ManagedFieldsUpdate ManagedFieldsOperation = "Update"
+)
+
+// ManagedFieldsEntry lists the fields a manager set through an operation.
+//
+// +jobros:deepcopy-gen=true
+type ManagedFieldsEntry struct {
+	// Manager names the client, such as "billing-controller".
+	Manager   string                 `json:"manager" bson:"manager"`
+	Operation ManagedFieldsOperation `json:"operation" bson:"operation"`
+	// Time is when the fields last changed hands.
+	Time time.Time `json:"time" bson:"time"`
+	// Fields are the JSON pointers of the fields, such as "/metadata/labels/tier".
+	// Objects are managed field by field, and lists as a whole.
+	Fields []string `json:"fields" bson:"fields"`
+}
+
+func (m *ObjectMeta) GetName() string                               { return m.Name }
+func (m *ObjectMeta) SetName(name string)                           { m.Name = name }
+func (m *ObjectMeta) GetUID() string                                { return m.UID }
+func (m *ObjectMeta) SetUID(uid string)                             { m.UID = uid }
+func (m *ObjectMeta) GetOwner() string                              { return m.Owner }
+func (m *ObjectMeta) SetOwner(owner string)                         { m.Owner = owner }
+func (m *ObjectMeta) GetLabels() map[string]string                  { return m.Labels }
+func (m *ObjectMeta) SetLabels(labels map[string]string)            { m.Labels = labels }
+func (m *ObjectMeta) GetAnnotations() map[string]string             { return m.Annotations }
+func (m *ObjectMeta) SetAnnotations(annotations map[string]string)  { m.Annotations = annotations }
+func (m *ObjectMeta) GetResourceVersion() string                    { return m.ResourceVersion }
+func (m *ObjectMeta) SetResourceVersion(version string)             { m.ResourceVersion = version }
+func (m *ObjectMeta) GetGeneration() int64                          { return m.Generation }
+func (m *ObjectMeta) SetGeneration(generation int64)                { m.Generation = generation }
+func (m *ObjectMeta) GetCreationTimestamp() time.Time               { return m.CreationTimestamp }
+func (m *ObjectMeta) SetCreationTimestamp(t time.Time)              { m.CreationTimestamp = t }
+func (m *ObjectMeta) GetDeletionTimestamp() *time.Time              { return m.DeletionTimestamp }
+func (m *ObjectMeta) SetDeletionTimestamp(t *time.Time)             { m.DeletionTimestamp = t }
+func (m *ObjectMeta) GetFinalizers() []string                       { return m.Finalizers }
+func (m *ObjectMeta) SetFinalizers(finalizers []string)             { m.Finalizers = finalizers }
+func (m *ObjectMeta) GetManagedFields() []ManagedFieldsEntry        { return m.ManagedFields }
+func (m *ObjectMeta) SetManagedFields(entries []ManagedFieldsEntry) { m.ManagedFields = entries }
+func (m *ObjectMeta) GetObjectMeta() *ObjectMeta                    { return m }
+
+// MetaObject gives access to the metadata of a resource. Types embedding
+// ObjectMeta implement it through a pointer.
+type MetaObject interface {
+	GetName() string
+	SetName(name string)
+	GetUID() string
+	SetUID(uid string)
+	GetOwner() string
+	SetOwner(owner string)
+	GetLabels() map[string]string
+	SetLabels(labels map[string]string)
+	GetAnnotations() map[string]string
+	SetAnnotations(annotations map[string]string)
+	GetResourceVersion() string
+	SetResourceVersion(version string)
+	GetGeneration() int64
+	SetGeneration(generation int64)
+	GetCreationTimestamp() time.Time
+	SetCreationTimestamp(t time.Time)
+	GetDeletionTimestamp() *time.Time
+	SetDeletionTimestamp(t *time.Time)
+	GetFinalizers() []string
+	SetFinalizers(finalizers []string)
+	GetManagedFields() []ManagedFieldsEntry
+	SetManagedFields(entries []ManagedFieldsEntry)
+	GetObjectMeta() *ObjectMeta
+}
+
+// TypeAccessor gives access to the apiVersion and kind of an object. Types
+// embedding TypeMeta implement it through a pointer.
+type TypeAccessor interface {
+	GetAPIVersion() string
+	GetKind() string
+	SetGroupVersionKind(gvk GroupVersionKind)
+}
+
+// Accessor returns the metadata accessor of obj, or an error if obj has no
+// ObjectMeta.
+func Accessor(obj interface{}) (MetaObject, error) {
+	meta, ok := obj.(MetaObject)
+	if !ok {
+		return nil, fmt.Errorf("%T does not carry object metadata", obj)
+	}
+	return meta, nil
+}
+
+// NewUID returns a random RFC 4122 version 4 UUID.
+func NewUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate uid: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// HasFinalizer reports whether the object has the finalizer.
+func HasFinalizer(meta MetaObject, finalizer string) bool {
+	for _, f := range meta.GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveFinalizer removes the finalizer from the object, and reports whether
+// it had it.
+func RemoveFinalizer(meta MetaObject, finalizer string) bool {
+	finalizers := meta.GetFinalizers()
+	kept := make([]string, 0, len(finalizers))
+	for _, f := range finalizers {
+		if f != finalizer {
+			kept = append(kept, f)
+		}
+	}
+	if len(kept) == len(finalizers) {
+		return false
+	}
+	if len(kept) == 0 {
+		kept = nil
+	}
+	meta.SetFinalizers(kept)
+	return true
+}
@@ -0,0 +1,91 @@
+package runtime
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testWidget struct {
+	TypeMeta
+	ObjectMeta `json:"metadata"`
+	Size       int `json:"size"`
+}
+
+func (w *testWidget) GetGroupVersionKind() GroupVersionKind {
+	return testGroupVersion.WithKind("Widget")
+}
+
+func (w *testWidget) DeepCopyObject() Object {
+	out := *w
+	w.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+func TestAccessor(t *testing.T) {
+	widget := &testWidget{}
+	meta, err := Accessor(widget)
+	require.NoError(t, err)
+
+	meta.SetName("blue")
+	meta.SetLabels(map[string]string{"color": "blue"})
+	assert.Equal(t, "blue", widget.Name)
+	assert.Equal(t, "blue", widget.Labels["color"])
+
+	_, err = Accessor(&testService{})
+	assert.Error(t, err)
+
+	var typed TypeAccessor = widget
+	typed.SetGroupVersionKind(widget.GetGroupVersionKind())
+	assert.Equal(t, "jobros.io/v1alpha1", widget.APIVersion)
+	assert.Equal(t, "Widget", widget.GetKind())
+}
+
+func TestObjectMeta_DeepCopyInto(t *testing.T) {
+	deleted := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	widget := &testWidget{ObjectMeta: ObjectMeta{
+		Name:              "blue",
+		Labels:            map[string]string{"color": "blue"},
+		Annotations:       map[string]string{"note": "x"},
+		DeletionTimestamp: &deleted,
+	}}
+
+	copied := widget.DeepCopyObject().(*testWidget)
+	assert.Equal(t, widget, copied)
+
+	copied.Labels["color"] = "red"
+	copied.Annotations["note"] = "y"
+	*copied.DeletionTimestamp = deleted.Add(time.Hour)
+	assert.Equal(t, "blue", widget.Labels["color"])
+	assert.Equal(t, "x", widget.Annotations["note"])
+	assert.Equal(t, deleted, *widget.DeletionTimestamp)
+}
+
+func TestScheme_EncodeJSON_Metadata(t *testing.T) {
+	scheme := NewScheme()
+	require.NoError(t, scheme.AddKnownType(&testWidget{}))
+
+	widget := &testWidget{ObjectMeta: ObjectMeta{Name: "blue", UID: "u1", Generation: 2}, Size: 3}
+	data, err := scheme.EncodeJSON(widget)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"apiVersion": "jobros.io/v1alpha1",
+		"kind": "Widget",
+		"metadata": {"name": "blue", "uid": "u1", "generation": 2, "creationTimestamp": "0001-01-01T00:00:00Z"},
+		"size": 3
+	}`, string(data))
+
+	decoded, err := scheme.DecodeJSON(data)
+	require.NoError(t, err)
+	assert.Equal(t, "jobros.io/v1alpha1", decoded.(*testWidget).APIVersion)
+	assert.Equal(t, widget.ObjectMeta, decoded.(*testWidget).ObjectMeta)
+}
+
+func TestNewUID(t *testing.T) {
+	uid := NewUID()
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), uid)
+	assert.NotEqual(t, uid, NewUID())
+}
@@ -8,7 +8,9 @@ type GroupVersionKind struct {
 }
 
 // Object is a common interface that all resources should implement.
+// DeepCopyObject is generated by deepcopy-gen for types annotated with
+// +jobros:deepcopy-gen:object=true.
 type Object interface {
 	GetGroupVersionKind() GroupVersionKind
-	DeepCopy() Object
+	DeepCopyObject() Object
 }
@@ -0,0 +1,180 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotRegistered is returned for kinds or types unknown to a Scheme.
+var ErrNotRegistered = errors.New("not registered in the scheme")
+
+// GroupVersion identifies a version of an API group.
+type GroupVersion struct {
+	Group   string
+	Version string
+}
+
+// ParseGroupVersion parses an apiVersion such as "jobros.io/v1alpha1", or a
+// bare version for the core group.
+func ParseGroupVersion(apiVersion string) (GroupVersion, error) {
+	parts := strings.Split(apiVersion, "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		return GroupVersion{Version: parts[0]}, nil
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return GroupVersion{Group: parts[0], Version: parts[1]}, nil
+	default:
+		return GroupVersion{}, fmt.Errorf("invalid apiVersion %q", apiVersion)
+	}
+}
+
+// String returns the group version in apiVersion form.
+func (gv GroupVersion) String() string {
+	if gv.Group == "" {
+		return gv.Version
+	}
+	return gv.Group + "/" + gv.Version
+}
+
+// WithKind returns the GroupVersionKind of kind in the group version.
+func (gv GroupVersion) WithKind(kind string) GroupVersionKind {
+	return GroupVersionKind{Group: gv.Group, Version: gv.Version, Kind: kind}
+}
+
+// GroupVersion returns the group version of the kind.
+func (gvk GroupVersionKind) GroupVersion() GroupVersion {
+	return GroupVersion{Group: gvk.Group, Version: gvk.Version}
+}
+
+func (gvk GroupVersionKind) String() string {
+	return gvk.GroupVersion().String() + ", Kind=" + gvk.Kind
+}
+
+// Scheme maps the kinds of the API to the Go types implementing them, so that
+// resources can be created, encoded and decoded generically.
+type Scheme struct {
+	mu          sync.RWMutex
+	gvkToType   map[GroupVersionKind]reflect.Type
+	typeToGVK   map[reflect.Type]GroupVersionKind
+	conversions map[conversionPair]ConversionFunc
+}
+
+// NewScheme creates an empty Scheme.
+func NewScheme() *Scheme {
+	return &Scheme{
+		gvkToType:   make(map[GroupVersionKind]reflect.Type),
+		typeToGVK:   make(map[reflect.Type]GroupVersionKind),
+		conversions: make(map[conversionPair]ConversionFunc),
+	}
+}
+
+// AddKnownType registers the type of obj, a pointer to a struct, under the kind
+// it reports. Registering another type for a kind already known fails.
+func (s *Scheme) AddKnownType(obj Object) error {
+	t := reflect.TypeOf(obj)
+	if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("type %T must be a pointer to a struct", obj)
+	}
+	t = t.Elem()
+
+	gvk := obj.GetGroupVersionKind()
+	if gvk.Version == "" || gvk.Kind == "" {
+		return fmt.Errorf("type %T has an incomplete kind %s", obj, gvk)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.gvkToType[gvk]; ok && existing != t {
+		return fmt.Errorf("kind %s is already registered for type %s", gvk, existing)
+	}
+	s.gvkToType[gvk] = t
+	s.typeToGVK[t] = gvk
+	return nil
+}
+
+// AddKnownTypes registers several types, stopping at the first failure.
+func (s *Scheme) AddKnownTypes(objs ...Object) error {
+	for _, obj := range objs {
+		if err := s.AddKnownType(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// New returns a new zero instance of the type registered for gvk.
+func (s *Scheme) New(gvk GroupVersionKind) (Object, error) {
+	s.mu.RLock()
+	t, ok := s.gvkToType[gvk]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("kind %s is %w", gvk, ErrNotRegistered)
+	}
+	return reflect.New(t).Interface().(Object), nil
+}
+
+// Recognizes reports whether gvk is registered.
+func (s *Scheme) Recognizes(gvk GroupVersionKind) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.gvkToType[gvk]
+	return ok
+}
+
+// ObjectKind returns the kind under which the type of obj is registered.
+func (s *Scheme) ObjectKind(obj Object) (GroupVersionKind, error) {
+	t := reflect.TypeOf(obj)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	gvk, ok := s.typeToGVK[t]
+	if !ok {
+		return GroupVersionKind{}, fmt.Errorf("type %T is %w", obj, ErrNotRegistered)
+	}
+	return gvk, nil
+}
+
+// KnownKinds returns the registered kinds, sorted.
+func (s *Scheme) KnownKinds() []GroupVersionKind {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	kinds := make([]GroupVersionKind, 0, len(s.gvkToType))
+	for gvk := range s.gvkToType {
+		kinds = append(kinds, gvk)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].String() < kinds[j].String() })
+	return kinds
+}
+
+// SchemeBuilder collects the functions registering the types of an API
+// package, to be applied to a Scheme with AddToScheme.
+type SchemeBuilder []func(*Scheme) error
+
+// NewSchemeBuilder creates a SchemeBuilder with the registration functions.
+func NewSchemeBuilder(funcs ...func(*Scheme) error) SchemeBuilder {
+	var sb SchemeBuilder
+	sb.Register(funcs...)
+	return sb
+}
+
+// Register adds registration functions to the builder.
+func (sb *SchemeBuilder) Register(funcs ...func(*Scheme) error) {
+	*sb = append(*sb, funcs...)
+}
+
+// AddToScheme applies all the registration functions to s.
+func (sb SchemeBuilder) AddToScheme(s *Scheme) error {
+	for _, register := range sb {
+		if err := register(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
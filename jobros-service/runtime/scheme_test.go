@@ -0,0 +1,141 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testGroupVersion = GroupVersion{Group: "jobros.io", Version: "v1alpha1"}
+
+type testService struct {
+	Name     string   `json:"name"`
+	Price    int64    `json:"price"`
+	Tags     []string `json:"tags,omitempty"`
+	Verified bool     `json:"verified"`
+}
+
+func (s *testService) GetGroupVersionKind() GroupVersionKind {
+	return testGroupVersion.WithKind("Service")
+}
+
+func (s *testService) DeepCopyObject() Object {
+	c := *s
+	c.Tags = append([]string(nil), s.Tags...)
+	return &c
+}
+
+type testBooking struct {
+	Service string `json:"service"`
+}
+
+func (b *testBooking) GetGroupVersionKind() GroupVersionKind {
+	return testGroupVersion.WithKind("Booking")
+}
+
+func (b *testBooking) DeepCopyObject() Object {
+	c := *b
+	return &c
+}
+
+func newTestScheme(t *testing.T) *Scheme {
+	var builder SchemeBuilder
+	builder.Register(func(s *Scheme) error {
+		return s.AddKnownTypes(&testService{}, &testBooking{})
+	})
+
+	scheme := NewScheme()
+	require.NoError(t, builder.AddToScheme(scheme))
+	return scheme
+}
+
+func TestParseGroupVersion(t *testing.T) {
+	gv, err := ParseGroupVersion("jobros.io/v1alpha1")
+	require.NoError(t, err)
+	assert.Equal(t, testGroupVersion, gv)
+
+	gv, err = ParseGroupVersion("v1")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", gv.String())
+
+	for _, invalid := range []string{"", "/v1", "jobros.io/", "a/b/c"} {
+		_, err := ParseGroupVersion(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestScheme_New(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	obj, err := scheme.New(testGroupVersion.WithKind("Service"))
+	require.NoError(t, err)
+	assert.IsType(t, &testService{}, obj)
+
+	_, err = scheme.New(testGroupVersion.WithKind("Invoice"))
+	assert.ErrorIs(t, err, ErrNotRegistered)
+
+	gvk, err := scheme.ObjectKind(&testBooking{})
+	require.NoError(t, err)
+	assert.Equal(t, "Booking", gvk.Kind)
+	assert.True(t, scheme.Recognizes(gvk))
+	assert.Len(t, scheme.KnownKinds(), 2)
+}
+
+type conflictingService struct{ testService }
+
+func TestScheme_AddKnownType_Conflict(t *testing.T) {
+	scheme := newTestScheme(t)
+	assert.NoError(t, scheme.AddKnownType(&testService{}))
+	assert.Error(t, scheme.AddKnownType(&conflictingService{}))
+}
+
+func TestScheme_JSON(t *testing.T) {
+	scheme := newTestScheme(t)
+	service := &testService{Name: "Plumbing", Price: 9007199254740993, Tags: []string{"home"}}
+
+	data, err := scheme.EncodeJSON(service)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"apiVersion": "jobros.io/v1alpha1",
+		"kind": "Service",
+		"name": "Plumbing",
+		"price": 9007199254740993,
+		"tags": ["home"],
+		"verified": false
+	}`, string(data))
+
+	decoded, err := scheme.DecodeJSON(data)
+	require.NoError(t, err)
+	assert.Equal(t, service, decoded)
+
+	_, err = scheme.DecodeJSON([]byte(`{"apiVersion":"jobros.io/v1alpha1","kind":"Invoice"}`))
+	assert.ErrorIs(t, err, ErrNotRegistered)
+
+	_, err = scheme.DecodeJSON([]byte(`{"name":"Plumbing"}`))
+	assert.Error(t, err)
+}
+
+func TestScheme_YAML(t *testing.T) {
+	scheme := newTestScheme(t)
+	service := &testService{Name: "true", Price: 120, Tags: []string{"home", "1"}, Verified: true}
+
+	data, err := scheme.EncodeYAML(service)
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: jobros.io/v1alpha1
+kind: Service
+name: "true"
+price: 120
+tags:
+  - home
+  - "1"
+verified: true
+`, string(data))
+
+	decoded, err := scheme.DecodeYAML(data)
+	require.NoError(t, err)
+	assert.Equal(t, service, decoded)
+
+	_, err = scheme.DecodeYAML([]byte("apiVersion: jobros.io/v1alpha1\nkind: Invoice\n"))
+	assert.ErrorIs(t, err, ErrNotRegistered)
+}
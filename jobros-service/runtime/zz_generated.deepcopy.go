@@ -0,0 +1,82 @@
+// Code generated by deepcopy-gen. DO NOT EDIT.
+
+package runtime
+
+import (
+	"time"
+)
+
+// DeepCopyInto copies the receiver into out. in must be non-nil.
+func (in *TypeMeta) DeepCopyInto(out *TypeMeta) {
+	*out = *in
+}
+
+// DeepCopy creates a new TypeMeta copying the receiver.
+func (in *TypeMeta) DeepCopy() *TypeMeta {
+	if in == nil {
+		return nil
+	}
+	out := new(TypeMeta)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into out. in must be non-nil.
+func (in *ObjectMeta) DeepCopyInto(out *ObjectMeta) {
+	*out = *in
+	if in.Labels != nil {
+		out.Labels = make(map[string]string, len(in.Labels))
+		for key, val := range in.Labels {
+			out.Labels[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		out.Annotations = make(map[string]string, len(in.Annotations))
+		for key, val := range in.Annotations {
+			out.Annotations[key] = val
+		}
+	}
+	if in.DeletionTimestamp != nil {
+		out.DeletionTimestamp = new(time.Time)
+		*out.DeletionTimestamp = *in.DeletionTimestamp
+	}
+	if in.Finalizers != nil {
+		out.Finalizers = make([]string, len(in.Finalizers))
+		copy(out.Finalizers, in.Finalizers)
+	}
+	if in.ManagedFields != nil {
+		out.ManagedFields = make([]ManagedFieldsEntry, len(in.ManagedFields))
+		for i := range in.ManagedFields {
+			in.ManagedFields[i].DeepCopyInto(&out.ManagedFields[i])
+		}
+	}
+}
+
+// DeepCopy creates a new ObjectMeta copying the receiver.
+func (in *ObjectMeta) DeepCopy() *ObjectMeta {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectMeta)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into out. in must be non-nil.
+func (in *ManagedFieldsEntry) DeepCopyInto(out *ManagedFieldsEntry) {
+	*out = *in
+	if in.Fields != nil {
+		out.Fields = make([]string, len(in.Fields))
+		copy(out.Fields, in.Fields)
+	}
+}
+
+// DeepCopy creates a new ManagedFieldsEntry copying the receiver.
+func (in *ManagedFieldsEntry) DeepCopy() *ManagedFieldsEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedFieldsEntry)
+	in.DeepCopyInto(out)
+	return out
+}